@@ -29,6 +29,7 @@ import (
 	"github.com/infomark-org/infomark/api/helper"
 	"github.com/infomark-org/infomark/auth"
 	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/email"
 	"github.com/infomark-org/infomark/model"
@@ -349,6 +350,7 @@ func (rs *AccountResource) DeleteAvatarHandler(w http.ResponseWriter, r *http.Re
 // RESPONSE: 401,Unauthenticated
 // SUMMARY:  Retrieve the specific account avatar from the request identity
 // This lists all course enrollments of the request identity including role.
+// Enrollments as a student additionally contain the exam admission.
 func (rs *AccountResource) GetEnrollmentsHandler(w http.ResponseWriter, r *http.Request) {
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 
@@ -359,13 +361,57 @@ func (rs *AccountResource) GetEnrollmentsHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	admissions := make(map[int64]*AdmissionResponse)
+	for _, enrollment := range enrollments {
+		if authorize.CourseRole(enrollment.Role) != authorize.STUDENT {
+			continue
+		}
+
+		course, err := rs.Stores.Course.Get(enrollment.CourseID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		admission, err := AdmissionOfUser(rs.Stores, course, accessClaims.LoginID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+		admissions[course.ID] = newAdmissionResponse(admission, course)
+	}
+
 	// render JSON response
-	if err = render.RenderList(w, r, rs.newUserEnrollmentsResponse(enrollments)); err != nil {
+	if err = render.RenderList(w, r, rs.newUserEnrollmentsResponse(enrollments, admissions)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
 }
 
+// ExportHandler is public endpoint for
+// URL: /account/export
+// METHOD: get
+// TAG: account
+// RESPONSE: 200,ZipFile
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// SUMMARY:  export all personal data of the request identity
+// DESCRIPTION:
+// The zip file contains "data.json" with the account, the enrollments and all
+// submissions together with the submitted files. Grades including their
+// feedback files are only contained once they are released.
+func (rs *AccountResource) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"infomark-account%d.zip\"", accessClaims.LoginID))
+
+	if err := ExportAccount(rs.Stores, accessClaims.LoginID, w); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+}
+
 // GetExamEnrollmentsHandler is public endpoint for
 // URL: /account/exams/enrollments
 // METHOD: get
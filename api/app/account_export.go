@@ -0,0 +1,187 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authorize"
+)
+
+// An account export is a zip file containing "data.json" with the personal
+// data of a user and all files the user has uploaded or received as feedback.
+
+// AccountExport is the manifest of the personal data of a user.
+type AccountExport struct {
+	ExportedAt    time.Time             `json:"exported_at"`
+	ID            int64                 `json:"id"`
+	FirstName     string                `json:"first_name"`
+	LastName      string                `json:"last_name"`
+	Email         string                `json:"email"`
+	StudentNumber string                `json:"student_number"`
+	Semester      int                   `json:"semester"`
+	Subject       string                `json:"subject"`
+	Language      string                `json:"language"`
+	Courses       []AccountExportCourse `json:"courses"`
+}
+
+// AccountExportCourse is a course the user is enrolled in.
+type AccountExportCourse struct {
+	ID          int64                     `json:"id"`
+	Name        string                    `json:"name"`
+	Role        int64                     `json:"role"`
+	Submissions []AccountExportSubmission `json:"submissions"`
+}
+
+// AccountExportSubmission is a submission of the user together with the grade
+// once it is released.
+type AccountExportSubmission struct {
+	TaskID      int64               `json:"task_id"`
+	SheetName   string              `json:"sheet_name"`
+	TaskName    string              `json:"task_name"`
+	SubmittedAt time.Time           `json:"submitted_at"`
+	File        string              `json:"file"`
+	Grade       *AccountExportGrade `json:"grade"`
+}
+
+// AccountExportGrade is a released grade of a submission.
+type AccountExportGrade struct {
+	AcquiredPoints int    `json:"acquired_points"`
+	Feedback       string `json:"feedback"`
+	FeedbackFile   string `json:"feedback_file"`
+}
+
+// ExportAccount writes the personal data of a user as a zip file. Grades, their
+// feedback and feedback files are only included once they are released.
+func ExportAccount(stores *Stores, userID int64, w io.Writer) error {
+	user, err := stores.User.Get(userID)
+	if err != nil {
+		return err
+	}
+
+	enrollments, err := stores.User.GetEnrollments(user.ID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	export := AccountExport{
+		ExportedAt:    NowUTC(),
+		ID:            user.ID,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         user.Email,
+		StudentNumber: user.StudentNumber,
+		Semester:      user.Semester,
+		Subject:       user.Subject,
+		Language:      user.Language,
+		Courses:       []AccountExportCourse{},
+	}
+
+	for _, enrollment := range enrollments {
+		course, err := stores.Course.Get(enrollment.CourseID)
+		if err != nil {
+			return err
+		}
+
+		entry := AccountExportCourse{
+			ID:          course.ID,
+			Name:        course.Name,
+			Role:        enrollment.Role,
+			Submissions: []AccountExportSubmission{},
+		}
+
+		// only students submit solutions
+		if authorize.CourseRole(enrollment.Role) != authorize.STUDENT {
+			export.Courses = append(export.Courses, entry)
+			continue
+		}
+
+		sheets, err := stores.Sheet.SheetsOfCourse(course.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, sheet := range sheets {
+			tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+			if err != nil {
+				return err
+			}
+
+			for _, task := range tasks {
+				submission, err := stores.Submission.GetByUserAndTask(user.ID, task.ID)
+				if err != nil {
+					// the user has not submitted a solution
+					continue
+				}
+
+				file, err := addZipFile(zw, fmt.Sprintf("submissions/%d", submission.ID),
+					helper.NewSubmissionFileHandle(submission.ID))
+				if err != nil {
+					return err
+				}
+
+				item := AccountExportSubmission{
+					TaskID:      task.ID,
+					SheetName:   sheet.Name,
+					TaskName:    task.Name,
+					SubmittedAt: submission.CreatedAt,
+					File:        file,
+				}
+
+				grade, err := stores.Grade.GetForSubmission(submission.ID)
+				if err == nil && GradesReleasedYet(&sheet) {
+					feedbackFile, err := addZipFile(zw, fmt.Sprintf("feedback/%d", grade.ID),
+						helper.NewGradeFeedbackFileHandle(grade.ID))
+					if err != nil {
+						return err
+					}
+
+					item.Grade = &AccountExportGrade{
+						AcquiredPoints: grade.AcquiredPoints,
+						Feedback:       grade.Feedback,
+						FeedbackFile:   feedbackFile,
+					}
+				}
+
+				entry.Submissions = append(entry.Submissions, item)
+			}
+		}
+
+		export.Courses = append(export.Courses, entry)
+	}
+
+	manifest, err := zw.Create("data.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(manifest)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(&export); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -31,6 +31,8 @@ type UserEnrollmentResponse struct {
 	ID       int64 `json:"id" example:"31"`
 	CourseID int64 `json:"course_id" example:"1"`
 	Role     int64 `json:"role" example:"1"`
+	// the exam admission is only given for courses the identity is a student in
+	Admission *AdmissionResponse `json:"admission,omitempty"`
 }
 
 // Render post-processes a userAccountCreatedResponse.
@@ -48,11 +50,23 @@ func (rs *AccountResource) newUserEnrollmentResponse(p *model.Enrollment) *UserE
 	}
 }
 
-func (rs *AccountResource) newUserEnrollmentsResponse(enrollments []model.Enrollment) []render.Renderer {
+func (rs *AccountResource) newUserEnrollmentsResponse(enrollments []model.Enrollment, admissions map[int64]*AdmissionResponse) []render.Renderer {
 	list := []render.Renderer{}
 	for k := range enrollments {
-		list = append(list, rs.newUserEnrollmentResponse(&enrollments[k]))
+		resp := rs.newUserEnrollmentResponse(&enrollments[k])
+		resp.Admission = admissions[enrollments[k].CourseID]
+		list = append(list, resp)
 	}
 
 	return list
 }
+
+// CalendarResponse is the secret address of the personal calendar feed.
+type CalendarResponse struct {
+	URL string `json:"url" example:"https://example.com/api/v1/calendar/4b1a..."`
+}
+
+// Render post-processes a CalendarResponse.
+func (body *CalendarResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
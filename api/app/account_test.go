@@ -20,6 +20,8 @@
 package app
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -32,6 +34,7 @@ import (
 	"github.com/infomark-org/infomark/auth"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/email"
+	null "gopkg.in/guregu/null.v3"
 )
 
 func TestAccount(t *testing.T) {
@@ -80,6 +83,81 @@ func TestAccount(t *testing.T) {
 			}
 		})
 
+		g.It("Should export the own data including released feedback files", func() {
+			submission, err := stores.Submission.GetByUserAndTask(112, 1)
+			g.Assert(err).Equal(nil)
+			grade, err := stores.Grade.GetForSubmission(submission.ID)
+			g.Assert(err).Equal(nil)
+
+			hnd := helper.NewGradeFeedbackFileHandle(grade.ID)
+			src := fmt.Sprintf("%s/empty.pdf", configuration.Configuration.Server.Debugging.Fixtures)
+			_, err = copyFile(src, hnd.Path())
+			g.Assert(err).Equal(nil)
+			defer hnd.Delete()
+
+			sheet, err := stores.Task.IdentifySheetOfTask(1)
+			g.Assert(err).Equal(nil)
+			sheet.GradesWithheld = true
+			g.Assert(stores.Sheet.Update(sheet)).Equal(nil)
+
+			readExport := func() (*AccountExport, map[string]bool) {
+				w := tape.Get("/api/v1/account/export", studentJWT)
+				g.Assert(w.Code).Equal(http.StatusOK)
+				g.Assert(w.Header().Get("Content-Type")).Equal("application/zip")
+
+				body := w.Body.Bytes()
+				zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+				g.Assert(err).Equal(nil)
+
+				export := &AccountExport{}
+				files := make(map[string]bool)
+				for _, file := range zr.File {
+					files[file.Name] = true
+					if file.Name == "data.json" {
+						rc, err := file.Open()
+						g.Assert(err).Equal(nil)
+						g.Assert(json.NewDecoder(rc).Decode(export)).Equal(nil)
+						rc.Close()
+					}
+				}
+				g.Assert(export.ID).Equal(int64(112))
+				return export, files
+			}
+
+			findSubmission := func(export *AccountExport) *AccountExportSubmission {
+				for _, course := range export.Courses {
+					for k, item := range course.Submissions {
+						if course.ID == 1 && item.TaskID == submission.TaskID {
+							return &course.Submissions[k]
+						}
+					}
+				}
+				return nil
+			}
+
+			w := tape.Get("/api/v1/account/export")
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			// withheld grades are not exported
+			export, files := readExport()
+			item := findSubmission(export)
+			g.Assert(item != nil).IsTrue()
+			g.Assert(item.Grade == nil).IsTrue()
+			g.Assert(files[fmt.Sprintf("feedback/%d.pdf", grade.ID)]).IsFalse()
+
+			sheet.GradesWithheld = false
+			sheet.GradesReleaseAt = null.Time{}
+			g.Assert(stores.Sheet.Update(sheet)).Equal(nil)
+
+			export, files = readExport()
+			item = findSubmission(export)
+			g.Assert(item != nil).IsTrue()
+			g.Assert(item.Grade != nil).IsTrue()
+			g.Assert(item.Grade.AcquiredPoints).Equal(grade.AcquiredPoints)
+			g.Assert(item.Grade.FeedbackFile).Equal(fmt.Sprintf("feedback/%d.pdf", grade.ID))
+			g.Assert(files[item.Grade.FeedbackFile]).IsTrue()
+		})
+
 		g.It("Should get all own exam enrollments", func() {
 			userID := studentJWT.Claims.LoginID
 			enrollmentsExpected, err := stores.Exam.GetEnrollmentsOfUser(userID)
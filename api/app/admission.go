@@ -0,0 +1,374 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// AdmissionResource specifies exam admission management handler.
+type AdmissionResource struct {
+	Stores *Stores
+}
+
+// NewAdmissionResource create and returns a AdmissionResource.
+func NewAdmissionResource(stores *Stores) *AdmissionResource {
+	return &AdmissionResource{
+		Stores: stores,
+	}
+}
+
+// ComputeAdmissions determines the exam admission of all students in a course.
+// A student is admitted when the acquired points reach the required percentage
+// of the course and the minimum percentage of each sheet. Only sheets with
+// released grades are taken into account, as long as there are none, nobody is
+// admitted. Additionally, the student has to attend and present in group
+// sessions as often as the course requires. Overrides of admins always win.
+func ComputeAdmissions(stores *Stores, course *model.Course) ([]model.Admission, error) {
+	return computeAdmissions(stores, course, 0)
+}
+
+// AdmissionOfUser determines the exam admission of a single student.
+func AdmissionOfUser(stores *Stores, course *model.Course, userID int64) (*model.Admission, error) {
+	admissions, err := computeAdmissions(stores, course, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(admissions) == 0 {
+		return nil, errors.New("user is not a student of this course")
+	}
+
+	return &admissions[0], nil
+}
+
+// computeAdmissions determines the exam admissions of all students in a course
+// or, if the userID is not 0, only of this student.
+func computeAdmissions(stores *Stores, course *model.Course, userID int64) ([]model.Admission, error) {
+	students, err := stores.Grade.GetExportStudents(course.ID, 0, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := stores.Grade.GetExportPoints(course.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := stores.Course.GetAdmissionOverrides(course.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	attendanceCounts, err := stores.Attendance.CountsOfCourse(course.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sheet of each task and the max points of each sheet
+	sheetOfTask := make(map[int64]int64)
+	maxPointsOfSheet := make(map[int64]int)
+	releasedSheets := []model.Sheet{}
+	for _, sheet := range sheets {
+		if !GradesReleasedYet(&sheet) {
+			continue
+		}
+		releasedSheets = append(releasedSheets, sheet)
+
+		tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			sheetOfTask[task.ID] = sheet.ID
+			// bonus tasks only add to the acquired points
+			if !task.IsBonus {
+				maxPointsOfSheet[sheet.ID] += task.MaxPoints
+			}
+		}
+	}
+
+	// points[userID][sheetID]
+	points := make(map[int64]map[int64]int)
+	for _, entry := range entries {
+		sheetID, ok := sheetOfTask[entry.TaskID]
+		if !ok {
+			continue
+		}
+		if points[entry.UserID] == nil {
+			points[entry.UserID] = make(map[int64]int)
+		}
+		points[entry.UserID][sheetID] += entry.AcquiredPoints
+	}
+
+	attendanceOfUser := make(map[int64]model.AttendanceCount)
+	for _, count := range attendanceCounts {
+		attendanceOfUser[count.UserID] = count
+	}
+
+	overrideOfUser := make(map[int64]model.AdmissionOverride)
+	for _, override := range overrides {
+		overrideOfUser[override.UserID] = override
+	}
+
+	admissions := make([]model.Admission, 0, len(students))
+	for _, student := range students {
+		admission := model.Admission{
+			UserID:            student.UserID,
+			UserFirstName:     student.UserFirstName,
+			UserLastName:      student.UserLastName,
+			UserEmail:         student.UserEmail,
+			UserStudentNumber: student.UserStudentNumber,
+			MissedSheetIDs:    []int64{},
+		}
+
+		for _, sheet := range releasedSheets {
+			acquired := points[student.UserID][sheet.ID]
+			admission.AcquiredPoints += acquired
+			admission.MaxPoints += maxPointsOfSheet[sheet.ID]
+
+			if acquired*100 < sheet.MinPercentage*maxPointsOfSheet[sheet.ID] {
+				admission.MissedSheetIDs = append(admission.MissedSheetIDs, sheet.ID)
+			}
+		}
+
+		admission.Attended = attendanceOfUser[student.UserID].Attended
+		admission.Presented = attendanceOfUser[student.UserID].Presented
+
+		// without released points the admission cannot be determined yet
+		admission.Admitted = admission.MaxPoints > 0 &&
+			len(admission.MissedSheetIDs) == 0 &&
+			admission.AcquiredPoints*100 >= course.RequiredPercentage*admission.MaxPoints &&
+			admission.Attended >= course.RequiredAttendances &&
+			admission.Presented >= course.RequiredPresentations
+
+		if override, ok := overrideOfUser[student.UserID]; ok {
+			admission.Override = null.BoolFrom(override.Admitted)
+			admission.Comment = override.Comment
+			admission.Admitted = override.Admitted
+		}
+
+		admissions = append(admissions, admission)
+	}
+
+	return admissions, nil
+}
+
+// GetHandler is public endpoint for
+// URL: /courses/{course_id}/admission
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: admissions
+// RESPONSE: 200,AdmissionResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  the exam admission of the request identity
+func (rs *AdmissionResource) GetHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	admission, err := AdmissionOfUser(rs.Stores, course, accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := render.Render(w, r, newAdmissionResponse(admission, course)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// IndexHandler is public endpoint for
+// URL: /courses/{course_id}/admissions
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: admissions
+// RESPONSE: 200,AdmissionResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  the exam admission of all students in a course
+func (rs *AdmissionResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	admissions, err := ComputeAdmissions(rs.Stores, course)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newAdmissionListResponse(admissions, course)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// ExportHandler is public endpoint for
+// URL: /courses/{course_id}/admissions/export
+// URLPARAM: course_id,integer
+// QUERYPARAM: format,string
+// METHOD: get
+// TAG: admissions
+// RESPONSE: 200,SpreadsheetFile
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  export all admitted students of a course for the examination office
+// DESCRIPTION:
+// The format is either "csv" (default) or "xlsx".
+func (rs *AdmissionResource) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	format := helper.StringFromURL(r, "format", "csv")
+	if format != "csv" && format != "xlsx" {
+		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("unknown format %s", format)))
+		return
+	}
+
+	admissions, err := ComputeAdmissions(rs.Stores, course)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	table := [][]string{{"student_number", "last_name", "first_name", "email", "acquired_points", "max_points"}}
+	for _, admission := range admissions {
+		if !admission.Admitted {
+			continue
+		}
+		table = append(table, []string{
+			admission.UserStudentNumber,
+			admission.UserLastName,
+			admission.UserFirstName,
+			admission.UserEmail,
+			strconv.Itoa(admission.AcquiredPoints),
+			strconv.Itoa(admission.MaxPoints),
+		})
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"infomark-course%d-admissions.%s\"", course.ID, format))
+
+	if format == "xlsx" {
+		w.Header().Set("Content-Type", helper.XLSXContentType)
+		if err := helper.WriteXLSX(w, "admissions", table); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(table); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+}
+
+// EditHandler is public endpoint for
+// URL: /courses/{course_id}/admissions/{user_id}
+// URLPARAM: course_id,integer
+// URLPARAM: user_id,integer
+// METHOD: put
+// TAG: admissions
+// REQUEST: AdmissionOverrideRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  override the computed exam admission of a student
+func (rs *AdmissionResource) EditHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	user := r.Context().Value(symbol.CtxKeyUser).(*model.User)
+
+	data := &AdmissionOverrideRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	role, err := rs.Stores.Course.RoleInCourse(user.ID, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if role != authorize.STUDENT {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("user is not a student of this course")))
+		return
+	}
+
+	if err := rs.Stores.Course.SetAdmissionOverride(&model.AdmissionOverride{
+		CourseID: course.ID,
+		UserID:   user.ID,
+		Admitted: data.Admitted,
+		Comment:  data.Comment,
+	}); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeleteHandler is public endpoint for
+// URL: /courses/{course_id}/admissions/{user_id}
+// URLPARAM: course_id,integer
+// URLPARAM: user_id,integer
+// METHOD: delete
+// TAG: admissions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  remove the override and use the computed exam admission of a student again
+func (rs *AdmissionResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	user := r.Context().Value(symbol.CtxKeyUser).(*model.User)
+
+	if err := rs.Stores.Course.DeleteAdmissionOverride(course.ID, user.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
@@ -0,0 +1,41 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+)
+
+// AdmissionOverrideRequest is the request payload for admins deciding about
+// the exam admission of a student.
+type AdmissionOverrideRequest struct {
+	Admitted bool   `json:"admitted" example:"true"`
+	Comment  string `json:"comment" example:"sick leave during sheet 3"`
+}
+
+// Bind preprocesses a AdmissionOverrideRequest.
+func (body *AdmissionOverrideRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"admission\" data")
+	}
+
+	return nil
+}
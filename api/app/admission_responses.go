@@ -0,0 +1,96 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// AdmissionResponse is the response payload for the exam admission of a student.
+type AdmissionResponse struct {
+	User *struct {
+		ID            int64  `json:"id" example:"112"`
+		FirstName     string `json:"first_name" example:"Max"`
+		LastName      string `json:"last_name" example:"Mustermensch"`
+		Email         string `json:"email" example:"test@uni-tuebingen.de"`
+		StudentNumber string `json:"student_number" example:"0816"`
+	} `json:"user"`
+	AcquiredPoints        int       `json:"acquired_points" example:"84"`
+	MaxPoints             int       `json:"max_points" example:"120"`
+	RequiredPercentage    int       `json:"required_percentage" example:"50"`
+	MissedSheetIDs        []int64   `json:"missed_sheet_ids" example:"3"`
+	Attended              int       `json:"attended" example:"11"`
+	RequiredAttendances   int       `json:"required_attendances" example:"10"`
+	Presented             int       `json:"presented" example:"1"`
+	RequiredPresentations int       `json:"required_presentations" example:"2"`
+	Override              null.Bool `json:"override" example:"false"`
+	Comment               string    `json:"comment" example:"sick leave during sheet 3"`
+	Admitted              bool      `json:"admitted" example:"true"`
+}
+
+// Render post-processes a AdmissionResponse.
+func (body *AdmissionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newAdmissionResponse creates a response from an Admission model.
+func newAdmissionResponse(p *model.Admission, course *model.Course) *AdmissionResponse {
+	user := &struct {
+		ID            int64  `json:"id" example:"112"`
+		FirstName     string `json:"first_name" example:"Max"`
+		LastName      string `json:"last_name" example:"Mustermensch"`
+		Email         string `json:"email" example:"test@uni-tuebingen.de"`
+		StudentNumber string `json:"student_number" example:"0816"`
+	}{
+		ID:            p.UserID,
+		FirstName:     p.UserFirstName,
+		LastName:      p.UserLastName,
+		Email:         p.UserEmail,
+		StudentNumber: p.UserStudentNumber,
+	}
+
+	return &AdmissionResponse{
+		User:                  user,
+		AcquiredPoints:        p.AcquiredPoints,
+		MaxPoints:             p.MaxPoints,
+		RequiredPercentage:    course.RequiredPercentage,
+		MissedSheetIDs:        p.MissedSheetIDs,
+		Attended:              p.Attended,
+		RequiredAttendances:   course.RequiredAttendances,
+		Presented:             p.Presented,
+		RequiredPresentations: course.RequiredPresentations,
+		Override:              p.Override,
+		Comment:               p.Comment,
+		Admitted:              p.Admitted,
+	}
+}
+
+// newAdmissionListResponse creates a response from a list of Admission models.
+func newAdmissionListResponse(admissions []model.Admission, course *model.Course) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range admissions {
+		list = append(list, newAdmissionResponse(&admissions[k], course))
+	}
+	return list
+}
@@ -0,0 +1,221 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise materials and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestAdmission(t *testing.T) {
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	studentJWT := tape.NewJWTRequest(112, false)
+	tutorJWT := tape.NewJWTRequest(2, false)
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	g.Describe("Admission", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should compute the admission of the request identity", func() {
+			w := tape.Get("/api/v1/courses/1/admission")
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			_, err := tape.DB.Exec("UPDATE courses SET required_percentage = 0 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get("/api/v1/courses/1/admission", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			admissionActual := &AdmissionResponse{}
+			err = json.NewDecoder(w.Body).Decode(admissionActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(admissionActual.User.ID).Equal(studentJWT.Claims.LoginID)
+			g.Assert(admissionActual.Admitted).Equal(true)
+			g.Assert(admissionActual.Override.Valid).Equal(false)
+
+			// without released grades nobody is admitted yet
+			_, err = tape.DB.Exec("UPDATE sheets SET grades_withheld = true;")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get("/api/v1/courses/1/admission", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			admissionActual = &AdmissionResponse{}
+			err = json.NewDecoder(w.Body).Decode(admissionActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(admissionActual.MaxPoints).Equal(0)
+			g.Assert(admissionActual.Admitted).Equal(false)
+
+			_, err = tape.DB.Exec("UPDATE sheets SET grades_withheld = false;")
+			g.Assert(err).Equal(nil)
+
+			// nobody reaches more than all points
+			_, err = tape.DB.Exec("UPDATE courses SET required_percentage = 101 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get("/api/v1/courses/1/admission", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			admissionActual = &AdmissionResponse{}
+			err = json.NewDecoder(w.Body).Decode(admissionActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(admissionActual.Admitted).Equal(false)
+		})
+
+		g.It("Should respect the minimum percentage of a sheet", func() {
+			_, err := tape.DB.Exec("UPDATE courses SET required_percentage = 0 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+
+			_, err = tape.DB.Exec("UPDATE grades SET acquired_points = 0;")
+			g.Assert(err).Equal(nil)
+
+			sheets, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+
+			sheet := sheets[0]
+			sheet.MinPercentage = 50
+			g.Assert(stores.Sheet.Update(&sheet)).Equal(nil)
+
+			course, err := stores.Course.Get(1)
+			g.Assert(err).Equal(nil)
+
+			admission, err := AdmissionOfUser(stores, course, studentJWT.Claims.LoginID)
+			g.Assert(err).Equal(nil)
+			g.Assert(admission.Admitted).Equal(false)
+			g.Assert(admission.MissedSheetIDs).Equal([]int64{sheet.ID})
+
+			// the points of the request identity state the missed sheet
+			w := tape.Get("/api/v1/courses/1/points", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			pointsActual := []SheetPointsResponse{}
+			err = json.NewDecoder(w.Body).Decode(&pointsActual)
+			g.Assert(err).Equal(nil)
+			found := false
+			for _, points := range pointsActual {
+				if int64(points.SheetID) == sheet.ID {
+					found = true
+					g.Assert(points.MinPercentage).Equal(50)
+					g.Assert(points.MissedMinPercentage).Equal(true)
+				} else {
+					g.Assert(points.MissedMinPercentage).Equal(false)
+				}
+			}
+			g.Assert(found).Equal(true)
+
+			// the enrollments of the account contain the admission
+			w = tape.Get("/api/v1/account/enrollments", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			enrollmentsActual := []UserEnrollmentResponse{}
+			err = json.NewDecoder(w.Body).Decode(&enrollmentsActual)
+			g.Assert(err).Equal(nil)
+			found = false
+			for _, enrollment := range enrollmentsActual {
+				if enrollment.CourseID == 1 {
+					found = true
+					g.Assert(enrollment.Admission != nil).Equal(true)
+					g.Assert(enrollment.Admission.Admitted).Equal(false)
+					g.Assert(enrollment.Admission.MissedSheetIDs).Equal([]int64{sheet.ID})
+				}
+			}
+			g.Assert(found).Equal(true)
+		})
+
+		g.It("Should allow admins to override the admission", func() {
+			_, err := tape.DB.Exec("UPDATE courses SET required_percentage = 101 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+
+			w := tape.Put("/api/v1/courses/1/admissions/112", H{"admitted": true}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// only students can be admitted
+			w = tape.Put("/api/v1/courses/1/admissions/2", H{"admitted": true}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Put("/api/v1/courses/1/admissions/112", H{
+				"admitted": true,
+				"comment":  "sick leave",
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/admissions", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/admissions", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			admissionsActual := []AdmissionResponse{}
+			err = json.NewDecoder(w.Body).Decode(&admissionsActual)
+			g.Assert(err).Equal(nil)
+
+			admitted := 0
+			for _, el := range admissionsActual {
+				if el.Admitted {
+					admitted++
+					g.Assert(el.User.ID).Equal(int64(112))
+					g.Assert(el.Comment).Equal("sick leave")
+					g.Assert(el.Override.Bool).Equal(true)
+				}
+			}
+			g.Assert(admitted).Equal(1)
+
+			w = tape.Get("/api/v1/courses/1/admissions/export", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			table, err := csv.NewReader(w.Body).ReadAll()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(table)).Equal(2)
+
+			user, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			g.Assert(table[1][0]).Equal(user.StudentNumber)
+
+			w = tape.Delete("/api/v1/courses/1/admissions/112", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			course, err := stores.Course.Get(1)
+			g.Assert(err).Equal(nil)
+
+			admission, err := AdmissionOfUser(stores, course, 112)
+			g.Assert(err).Equal(nil)
+			g.Assert(admission.Admitted).Equal(false)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+}
@@ -0,0 +1,372 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	"github.com/lib/pq"
+)
+
+// AnnouncementResource specifies announcement management handler.
+type AnnouncementResource struct {
+	Stores *Stores
+}
+
+// NewAnnouncementResource create and returns an AnnouncementResource.
+func NewAnnouncementResource(stores *Stores) *AnnouncementResource {
+	return &AnnouncementResource{
+		Stores: stores,
+	}
+}
+
+// SendAnnouncementEmails sends the announcement to all addressed users who did
+// not mute announcement emails. Nothing is sent before the announcement is
+// published or if it has been sent already.
+func SendAnnouncementEmails(stores *Stores, announcement *model.Announcement) error {
+	if !announcement.SendEmail || !announcement.Published() {
+		return nil
+	}
+
+	claimed, err := stores.Announcement.ClaimEmails(announcement.ID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	course, err := stores.Course.Get(announcement.CourseID)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := stores.Announcement.Recipients(announcement)
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		msg, err := email.NewEmailFromTemplate(
+			configuration.Configuration.Server.Email.From,
+			recipient.Email,
+			fmt.Sprintf("[%s] %s", course.Name, announcement.Title),
+			email.AnnouncementTemplateEN,
+			map[string]string{
+				"first_name":  recipient.FirstName,
+				"last_name":   recipient.LastName,
+				"course_name": course.Name,
+				"title":       announcement.Title,
+				"body":        announcement.Body,
+				"course_url":  fmt.Sprintf("%s/#/course/%d", configuration.Configuration.Server.ExternalURL(), course.ID),
+			})
+		if err != nil {
+			return err
+		}
+		email.OutgoingEmailsChannel <- msg
+	}
+
+	return nil
+}
+
+// applyRequest copies the request into the announcement and makes sure the
+// addressed group belongs to the course.
+func (rs *AnnouncementResource) applyRequest(announcement *model.Announcement, data *AnnouncementRequest) error {
+	if data.GroupID.Valid {
+		group, err := rs.Stores.Group.Get(data.GroupID.Int64)
+		if err != nil || group.CourseID != announcement.CourseID {
+			return errors.New("group does not belong to the course")
+		}
+	}
+
+	announcement.Title = data.Title
+	announcement.Body = data.Body
+	announcement.Pinned = data.Pinned
+	announcement.Roles = pq.Int64Array(data.Roles)
+	announcement.GroupID = data.GroupID
+	announcement.SendEmail = data.SendEmail
+
+	if data.PublishedAt.Valid {
+		announcement.PublishedAt = data.PublishedAt.Time
+	} else if announcement.ID == 0 {
+		// new announcements are published right away, edits keep the date
+		announcement.PublishedAt = time.Now()
+	}
+
+	return nil
+}
+
+// IndexHandler is public endpoint for
+// URL: /courses/{course_id}/announcements
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: announcements
+// RESPONSE: 200,AnnouncementResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all announcements of a course including unpublished ones
+func (rs *AnnouncementResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	announcements, err := rs.Stores.Announcement.AnnouncementsOfCourse(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newAnnouncementListResponse(announcements)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// FeedHandler is public endpoint for
+// URL: /courses/{course_id}/announcements/feed
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: announcements
+// RESPONSE: 200,AnnouncementResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all published announcements addressed to the identity
+// DESCRIPTION:
+// Pinned announcements come first, then the newest ones. Announcements for a
+// group are only listed for its members and tutors.
+func (rs *AnnouncementResource) FeedHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	announcements, err := rs.Stores.Announcement.FeedOfUser(course.ID, accessClaims.LoginID, int64(givenRole))
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newAnnouncementListResponse(announcements)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateHandler is public endpoint for
+// URL: /courses/{course_id}/announcements
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: announcements
+// REQUEST: AnnouncementRequest
+// RESPONSE: 201,AnnouncementResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  create a new announcement
+// DESCRIPTION:
+// Without "published_at" the announcement is published immediately. Without
+// "roles" everybody in the course is addressed. If "send_email" is given, the
+// announcement is also sent by email once it is published to everybody who
+// did not mute announcement emails.
+func (rs *AnnouncementResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &AnnouncementRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	announcement := &model.Announcement{
+		CourseID: course.ID,
+		AuthorID: accessClaims.LoginID,
+	}
+
+	if err := rs.applyRequest(announcement, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	newAnnouncement, err := rs.Stores.Announcement.Create(announcement)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := SendAnnouncementEmails(rs.Stores, newAnnouncement); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	newAnnouncement, err = rs.Stores.Announcement.Get(newAnnouncement.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newAnnouncementResponse(newAnnouncement)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// GetHandler is public endpoint for
+// URL: /courses/{course_id}/announcements/{announcement_id}
+// URLPARAM: course_id,integer
+// URLPARAM: announcement_id,integer
+// METHOD: get
+// TAG: announcements
+// RESPONSE: 200,AnnouncementResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get a specific announcement
+func (rs *AnnouncementResource) GetHandler(w http.ResponseWriter, r *http.Request) {
+	announcement := r.Context().Value(symbol.CtxKeyAnnouncement).(*model.Announcement)
+
+	// render JSON response
+	if err := render.Render(w, r, newAnnouncementResponse(announcement)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// EditHandler is public endpoint for
+// URL: /courses/{course_id}/announcements/{announcement_id}
+// URLPARAM: course_id,integer
+// URLPARAM: announcement_id,integer
+// METHOD: put
+// TAG: announcements
+// REQUEST: AnnouncementRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  update an announcement
+// DESCRIPTION:
+// An announcement is sent by email at most once, later updates are not sent again.
+// Without "published_at" the publication date stays unchanged.
+func (rs *AnnouncementResource) EditHandler(w http.ResponseWriter, r *http.Request) {
+	announcement := r.Context().Value(symbol.CtxKeyAnnouncement).(*model.Announcement)
+
+	data := &AnnouncementRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.applyRequest(announcement, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.Stores.Announcement.Update(announcement); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := SendAnnouncementEmails(rs.Stores, announcement); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeleteHandler is public endpoint for
+// URL: /courses/{course_id}/announcements/{announcement_id}
+// URLPARAM: course_id,integer
+// URLPARAM: announcement_id,integer
+// METHOD: delete
+// TAG: announcements
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  delete an announcement
+func (rs *AnnouncementResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	announcement := r.Context().Value(symbol.CtxKeyAnnouncement).(*model.Announcement)
+
+	if err := rs.Stores.Announcement.Delete(announcement.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// .............................................................................
+
+// Context middleware is used to load an Announcement object from
+// the URL parameter `announcement_id` passed through as the request. In case
+// the Announcement could not be found, we stop here and return a 404.
+// We do NOT check whether the identity is authorized to get this Announcement.
+func (rs *AnnouncementResource) Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+		var announcementID int64
+		var err error
+
+		// try to get id from URL
+		if announcementID, err = strconv.ParseInt(chi.URLParam(r, "announcement_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific announcement in database
+		announcement, err := rs.Stores.Announcement.Get(announcementID)
+		if err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		if announcement.CourseID != course.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), symbol.CtxKeyAnnouncement, announcement)
+
+		// serve next
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
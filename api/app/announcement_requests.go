@@ -0,0 +1,76 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// AnnouncementRequest is the request payload for announcement management.
+type AnnouncementRequest struct {
+	Title       string    `json:"title" example:"Lecture moved"`
+	Body        string    `json:"body" example:"The lecture on **Monday** takes place in room A104."`
+	Pinned      bool      `json:"pinned" example:"false"`
+	PublishedAt null.Time `json:"published_at" example:"auto" required:"false"`
+	Roles       []int64   `json:"roles" example:"0,1" required:"false"`
+	GroupID     null.Int  `json:"group_id" example:"1" required:"false"`
+	SendEmail   bool      `json:"send_email" example:"true"`
+}
+
+// Bind preprocesses an AnnouncementRequest.
+func (body *AnnouncementRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"announcement\" data")
+	}
+
+	body.Title = strings.TrimSpace(body.Title)
+
+	// without an explicit audience everybody in the course is addressed
+	if len(body.Roles) == 0 {
+		body.Roles = []int64{0, 1, 2}
+	}
+
+	return body.Validate()
+}
+
+// Validate validates an AnnouncementRequest.
+func (body *AnnouncementRequest) Validate() error {
+	err := validation.ValidateStruct(body,
+		validation.Field(
+			&body.Title,
+			validation.Required,
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, role := range body.Roles {
+		if role < 0 || role > 2 {
+			return errors.New("roles should only contain 0 (student), 1 (tutor) or 2 (admin)")
+		}
+	}
+
+	return nil
+}
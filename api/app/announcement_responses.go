@@ -0,0 +1,78 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// AnnouncementResponse is the response payload for announcements.
+type AnnouncementResponse struct {
+	ID           int64     `json:"id" example:"1"`
+	CreatedAt    time.Time `json:"created_at" example:"auto"`
+	UpdatedAt    time.Time `json:"updated_at" example:"auto"`
+	CourseID     int64     `json:"course_id" example:"1"`
+	AuthorID     int64     `json:"author_id" example:"1"`
+	Title        string    `json:"title" example:"Lecture moved"`
+	Body         string    `json:"body" example:"The lecture on **Monday** takes place in room A104."`
+	Pinned       bool      `json:"pinned" example:"false"`
+	PublishedAt  time.Time `json:"published_at" example:"auto"`
+	Roles        []int64   `json:"roles" example:"0,1"`
+	GroupID      null.Int  `json:"group_id" example:"1"`
+	SendEmail    bool      `json:"send_email" example:"true"`
+	EmailsSentAt null.Time `json:"emails_sent_at" example:"auto"`
+}
+
+// Render post-processes an AnnouncementResponse.
+func (body *AnnouncementResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newAnnouncementResponse creates a response from an Announcement model.
+func newAnnouncementResponse(p *model.Announcement) *AnnouncementResponse {
+	return &AnnouncementResponse{
+		ID:           p.ID,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+		CourseID:     p.CourseID,
+		AuthorID:     p.AuthorID,
+		Title:        p.Title,
+		Body:         p.Body,
+		Pinned:       p.Pinned,
+		PublishedAt:  p.PublishedAt,
+		Roles:        p.Roles,
+		GroupID:      p.GroupID,
+		SendEmail:    p.SendEmail,
+		EmailsSentAt: p.EmailsSentAt,
+	}
+}
+
+// newAnnouncementListResponse creates a response from a list of Announcement models.
+func newAnnouncementListResponse(announcements []model.Announcement) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range announcements {
+		list = append(list, newAnnouncementResponse(&announcements[k]))
+	}
+	return list
+}
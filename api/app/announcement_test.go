@@ -0,0 +1,250 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestAnnouncement(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+	go email.BackgroundSend(email.OutgoingEmailsChannel)
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	studentJWT := tape.NewJWTRequest(112, false)
+	tutorJWT := tape.NewJWTRequest(2, false)
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	g.Describe("Announcement", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		createAnnouncement := func(data H) *AnnouncementResponse {
+			w := tape.Post("/api/v1/courses/1/announcements", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			announcement := &AnnouncementResponse{}
+			err := json.NewDecoder(w.Body).Decode(announcement)
+			g.Assert(err).Equal(nil)
+			return announcement
+		}
+
+		feedOf := func(jwt JWTRequest) []AnnouncementResponse {
+			w := tape.Get("/api/v1/courses/1/announcements/feed", jwt)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			announcements := []AnnouncementResponse{}
+			err := json.NewDecoder(w.Body).Decode(&announcements)
+			g.Assert(err).Equal(nil)
+			return announcements
+		}
+
+		g.It("Should create, list, update and delete announcements", func() {
+			data := H{
+				"title": "Lecture moved",
+				"body":  "The lecture takes place in room **A104**.",
+			}
+
+			w := tape.Post("/api/v1/courses/1/announcements", data, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/announcements", H{"body": "no title"}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/announcements", H{"title": "bad", "roles": []int{3}}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			announcement := createAnnouncement(data)
+			g.Assert(announcement.CourseID).Equal(int64(1))
+			g.Assert(announcement.AuthorID).Equal(int64(1))
+			g.Assert(announcement.Title).Equal("Lecture moved")
+			g.Assert(announcement.Roles).Equal([]int64{0, 1, 2})
+			g.Assert(announcement.GroupID.Valid).IsFalse()
+
+			w = tape.Get("/api/v1/courses/1/announcements", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/announcements", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			announcements := []AnnouncementResponse{}
+			err := json.NewDecoder(w.Body).Decode(&announcements)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(announcements)).Equal(1)
+
+			// announcements belong to their course
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/2/announcements/%d", announcement.ID), adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			announcementBefore, err := stores.Announcement.Get(announcement.ID)
+			g.Assert(err).Equal(nil)
+
+			data["title"] = "Lecture cancelled"
+			data["pinned"] = true
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/announcements/%d", announcement.ID), data, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/announcements/%d", announcement.ID), data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			announcementAfter, err := stores.Announcement.Get(announcement.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(announcementAfter.Title).Equal("Lecture cancelled")
+			g.Assert(announcementAfter.Pinned).IsTrue()
+			// editing keeps the publication date
+			g.Assert(announcementAfter.PublishedAt.Equal(announcementBefore.PublishedAt)).IsTrue()
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/announcements/%d", announcement.ID), studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/announcements/%d", announcement.ID), adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			_, err = stores.Announcement.Get(announcement.ID)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("Should only list announcements addressed to the identity in the feed", func() {
+			enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			ownGroupID := enrollment.GroupID
+			otherGroupID := int64(1)
+			if ownGroupID == otherGroupID {
+				otherGroupID = 3
+			}
+
+			everybody := createAnnouncement(H{"title": "everybody"})
+			pinned := createAnnouncement(H{"title": "pinned", "pinned": true,
+				"published_at": time.Now().Add(-time.Hour)})
+			students := createAnnouncement(H{"title": "students", "roles": []int{0}})
+			tutors := createAnnouncement(H{"title": "tutors", "roles": []int{1, 2}})
+			ownGroup := createAnnouncement(H{"title": "own group", "group_id": ownGroupID})
+			createAnnouncement(H{"title": "other group", "group_id": otherGroupID})
+			createAnnouncement(H{"title": "later", "published_at": time.Now().Add(time.Hour)})
+
+			// groups of other courses cannot be addressed
+			w := tape.Post("/api/v1/courses/1/announcements", H{"title": "wrong", "group_id": 999999}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			feed := feedOf(studentJWT)
+			g.Assert(len(feed)).Equal(4)
+			g.Assert(feed[0].ID).Equal(pinned.ID)
+			ids := []int64{}
+			for _, announcement := range feed {
+				ids = append(ids, announcement.ID)
+			}
+			g.Assert(ids[1:]).Equal([]int64{ownGroup.ID, students.ID, everybody.ID})
+
+			feed = feedOf(tutorJWT)
+			ids = []int64{}
+			for _, announcement := range feed {
+				ids = append(ids, announcement.ID)
+				g.Assert(announcement.ID == students.ID).IsFalse()
+			}
+			g.Assert(ids[0]).Equal(pinned.ID)
+			g.Assert(ids[len(ids)-1]).Equal(everybody.ID)
+			g.Assert(ids[len(ids)-2]).Equal(tutors.ID)
+
+			w = tape.Get("/api/v1/courses/1/announcements", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			announcements := []AnnouncementResponse{}
+			err = json.NewDecoder(w.Body).Decode(&announcements)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(announcements)).Equal(7)
+		})
+
+		g.It("Should send emails once published and respect muted users", func() {
+			w := tape.Put("/api/v1/me", H{
+				"first_name":                "Max",
+				"last_name":                 "Mustermensch",
+				"student_number":            "0815",
+				"semester":                  2,
+				"subject":                   "informatics",
+				"language":                  "en",
+				"announcement_emails_muted": true,
+			}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			student, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			g.Assert(student.AnnouncementEmailsMuted).IsTrue()
+
+			now := createAnnouncement(H{"title": "now", "roles": []int{0}, "send_email": true})
+			g.Assert(now.EmailsSentAt.Valid).IsTrue()
+
+			announcement, err := stores.Announcement.Get(now.ID)
+			g.Assert(err).Equal(nil)
+			recipients, err := stores.Announcement.Recipients(announcement)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(recipients) > 0).IsTrue()
+			for _, recipient := range recipients {
+				g.Assert(recipient.ID == student.ID).IsFalse()
+			}
+
+			later := createAnnouncement(H{"title": "later", "send_email": true,
+				"published_at": time.Now().Add(time.Hour)})
+			g.Assert(later.EmailsSentAt.Valid).IsFalse()
+
+			pending, err := stores.Announcement.PendingEmails()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pending)).Equal(0)
+
+			_, err = tape.DB.Exec("UPDATE announcements SET published_at = now() - interval '1 minute' WHERE id = $1", later.ID)
+			g.Assert(err).Equal(nil)
+
+			pending, err = stores.Announcement.PendingEmails()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pending)).Equal(1)
+			g.Assert(pending[0].ID).Equal(later.ID)
+
+			g.Assert(SendAnnouncementEmails(stores, &pending[0])).Equal(nil)
+
+			announcement, err = stores.Announcement.Get(later.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(announcement.EmailsSentAt.Valid).IsTrue()
+
+			// an announcement is only sent once
+			claimed, err := stores.Announcement.ClaimEmails(later.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(claimed).IsFalse()
+
+			pending, err = stores.Announcement.PendingEmails()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pending)).Equal(0)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
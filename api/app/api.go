@@ -20,6 +20,8 @@
 package app
 
 import (
+	"time"
+
 	"github.com/alexedwards/scs"
 	"github.com/infomark-org/infomark/auth/authenticate"
 	"github.com/infomark-org/infomark/auth/authorize"
@@ -27,6 +29,7 @@ import (
 	"github.com/infomark-org/infomark/model"
 	"github.com/infomark-org/infomark/symbol"
 	"github.com/jmoiron/sqlx"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // UserStore defines user related database queries
@@ -37,6 +40,7 @@ type UserStore interface {
 	Create(p *model.User) (*model.User, error)
 	Delete(userID int64) error
 	FindByEmail(email string) (*model.User, error)
+	FindByCalendarToken(token string) (*model.User, error)
 	Find(query string) ([]model.User, error)
 	GetEnrollments(userID int64) ([]model.Enrollment, error)
 }
@@ -83,6 +87,17 @@ type CourseStore interface {
 	PointsForUser(userID int64, courseID int64) ([]model.SheetPoints, error)
 	RoleInCourse(userID int64, courseID int64) (authorize.CourseRole, error)
 	UpdateRole(courseID, userID int64, role int) error
+
+	GetAdmissionOverrides(courseID int64, userID int64) ([]model.AdmissionOverride, error)
+	SetAdmissionOverride(p *model.AdmissionOverride) error
+	DeleteAdmissionOverride(courseID int64, userID int64) error
+
+	Lock(courseID int64) error
+	CountStudents(courseID int64) (int, error)
+	CreateEnrollmentRequest(courseID int64, userID int64) error
+	GetEnrollmentRequests(courseID int64) ([]model.EnrollmentRequest, error)
+	GetEnrollmentRequest(courseID int64, userID int64) (*model.EnrollmentRequest, error)
+	DeleteEnrollmentRequest(courseID int64, userID int64) error
 }
 
 // SheetStore specifies required database queries for Sheet management.
@@ -93,6 +108,11 @@ type SheetStore interface {
 	Create(p *model.Sheet, courseID int64) (*model.Sheet, error)
 	Delete(SheetID int64) error
 	SheetsOfCourse(courseID int64) ([]model.Sheet, error)
+	Reorder(courseID int64, sheetIDs []int64) error
+	PendingGradeReleaseEmails() ([]model.Sheet, error)
+	ClaimGradeReleaseEmails(sheetID int64) (bool, error)
+	RearmGradeReleaseEmails(sheetID int64) error
+	GradeReleaseRecipients(courseID int64) ([]model.User, error)
 	IdentifyCourseOfSheet(sheetID int64) (*model.Course, error)
 	PointsForUser(userID int64, sheetID int64) ([]model.TaskPoints, error)
 }
@@ -105,6 +125,7 @@ type TaskStore interface {
 	Create(p *model.Task, sheetID int64) (*model.Task, error)
 	Delete(TaskID int64) error
 	TasksOfSheet(sheetID int64) ([]model.Task, error)
+	Reorder(sheetID int64, taskIDs []int64) error
 	IdentifyCourseOfTask(taskID int64) (*model.Course, error)
 	IdentifySheetOfTask(taskID int64) (*model.Sheet, error)
 
@@ -128,6 +149,10 @@ type GroupStore interface {
 	GetInCourseWithUser(userID int64, courseID int64) ([]model.GroupWithTutor, error)
 	GetMembers(groupID int64) ([]model.User, error)
 	GetOfTutor(tutorID int64, courseID int64) ([]model.GroupWithTutor, error)
+	IsTutorOfGroup(userID int64, groupID int64) (bool, error)
+	GetCoTutors(groupID int64) ([]model.User, error)
+	CoTutorsOfCourse(courseID int64) ([]model.GroupCoTutor, error)
+	SetCoTutors(groupID int64, tutorIDs []int64) error
 	IdentifyCourseOfGroup(groupID int64) (*model.Course, error)
 
 	GetBidOfUserForGroup(userID int64, groupID int64) (bid int, err error)
@@ -140,6 +165,15 @@ type GroupStore interface {
 	GetGroupEnrollmentOfUserInCourse(userID int64, courseID int64) (*model.GroupEnrollment, error)
 	CreateGroupEnrollmentOfUserInCourse(p *model.GroupEnrollment) (*model.GroupEnrollment, error)
 	ChangeGroupEnrollmentOfUserInCourse(p *model.GroupEnrollment) error
+	ReplaceGroupEnrollmentsInCourse(courseID int64, enrollments []model.GroupEnrollment) error
+	DeleteGroupEnrollment(userID int64, groupID int64) error
+	CountMembers(groupID int64) (int, error)
+	EnrollOrWaitlist(userID int64, groupID int64) (bool, error)
+	PromoteFromWaitlist(groupID int64) ([]int64, error)
+	GetWaitlist(groupID int64) ([]model.User, error)
+	GetWaitlistPosition(userID int64, groupID int64) (int, error)
+	RemoveFromWaitlist(userID int64, groupID int64) error
+	RemoveFromWaitlistsInCourse(userID int64, courseID int64) error
 
 	EnrolledUsers(courseID int64, groupID int64, roleFilter []string,
 		filterFirstName string, filterLastName string, filterEmail string, filterSubject string,
@@ -153,6 +187,7 @@ type MaterialStore interface {
 	Update(p *model.Material) error
 	Delete(sheetID int64) error
 	MaterialsOfCourse(courseID int64, requiredRole int) ([]model.Material, error)
+	Reorder(courseID int64, materialIDs []int64) error
 	IdentifyCourseOfMaterial(sheetID int64) (*model.Course, error)
 	GetAll() ([]model.Material, error)
 }
@@ -161,6 +196,7 @@ type MaterialStore interface {
 type SubmissionStore interface {
 	Get(submissionID int64) (*model.Submission, error)
 	GetByUserAndTask(userID int64, taskID int64) (*model.Submission, error)
+	SubmissionsOfTask(taskID int64) ([]model.Submission, error)
 	Create(p *model.Submission) (*model.Submission, error)
 	GetFiltered(filterCourseID, filterGroupID, filterUserID, filterSheetID, filterTaskID int64) ([]model.Submission, error)
 }
@@ -182,16 +218,118 @@ type GradeStore interface {
 		privateExecutationState int,
 	) ([]model.Grade, error)
 	Get(id int64) (*model.Grade, error)
+	GetForUpdate(id int64) (*model.Grade, error)
 	GetForSubmission(id int64) (*model.Grade, error)
 	Update(p *model.Grade) error
 	IdentifyCourseOfGrade(gradeID int64) (*model.Course, error)
 	GetAllMissingGrades(courseID int64, tutorID int64, groupID int64) ([]model.MissingGrade, error)
 	Create(p *model.Grade) (*model.Grade, error)
 
-	UpdatePrivateTestInfo(gradeID int64, log string, status symbol.TestingResult) error
-	UpdatePublicTestInfo(gradeID int64, log string, status symbol.TestingResult) error
+	UpdatePrivateTestInfo(gradeID int64, log string, status symbol.TestingResult, history *model.GradeHistory) error
+	UpdatePublicTestInfo(gradeID int64, log string, status symbol.TestingResult, history *model.GradeHistory) error
 	IdentifyTaskOfGrade(gradeID int64) (*model.Task, error)
 	GetOverviewGrades(courseID int64, groupID int64) ([]model.OverviewGrade, error)
+
+	CreateHistory(p *model.GradeHistory) (*model.GradeHistory, error)
+	GetHistory(gradeID int64) ([]model.GradeHistory, error)
+	GetHistoryOfCourse(courseID int64) ([]model.GradeHistory, error)
+
+	GetGradingProgress(courseID int64, sheetID int64) ([]model.GradingProgress, error)
+	GetUnfinishedOfTutor(courseID int64, sheetID int64, tutorID int64) ([]model.Grade, error)
+
+	GetOfUserAndTask(userID int64, taskID int64) (*model.Grade, error)
+	GetExportStudents(courseID int64, groupID int64, userID int64) ([]model.GradeExportStudent, error)
+	GetExportPoints(courseID int64, userID int64) ([]model.GradeExportPoints, error)
+	UpdateWithHistory(p *model.Grade, history *model.GradeHistory) error
+	UpdateBatch(grades []model.Grade, histories []model.GradeHistory) error
+
+	GetSecondGrading(gradeID int64) (*model.SecondGrading, error)
+	SetSecondGrading(p *model.SecondGrading) error
+	UpdateSecondGrading(p *model.SecondGrading) error
+	GetSecondGradingsOfCourse(courseID int64) ([]model.SecondGrading, error)
+}
+
+// RegradeStore defines regrade request related database queries
+type RegradeStore interface {
+	Get(id int64) (*model.Regrade, error)
+	Create(p *model.Regrade) (*model.Regrade, error)
+	Update(p *model.Regrade) error
+	GetForGrade(gradeID int64) ([]model.Regrade, error)
+	GetFiltered(courseID int64, groupID int64, status int) ([]model.Regrade, error)
+	IdentifyCourseOfRegrade(regradeID int64) (*model.Course, error)
+}
+
+// FeedbackSnippetStore defines feedback snippet related database queries
+type FeedbackSnippetStore interface {
+	Get(id int64) (*model.FeedbackSnippet, error)
+	Create(p *model.FeedbackSnippet) (*model.FeedbackSnippet, error)
+	Update(p *model.FeedbackSnippet) error
+	Delete(id int64) error
+	GetForCourse(courseID int64, taskID int64) ([]model.FeedbackSnippet, error)
+	RecordUsage(snippetID int64, gradeID int64) error
+}
+
+// GroupSessionStore defines group session related database queries
+type GroupSessionStore interface {
+	Get(sessionID int64) (*model.GroupSession, error)
+	Create(p *model.GroupSession) (*model.GroupSession, error)
+	Update(p *model.GroupSession) error
+	Delete(sessionID int64) error
+	SessionsOfGroup(groupID int64) ([]model.GroupSession, error)
+	FindRoomClashes(p *model.GroupSession) ([]model.GroupSession, error)
+	GetCancellations(sessionID int64) ([]time.Time, error)
+	SetCancellations(sessionID int64, dates []time.Time) error
+}
+
+// AttendanceStore defines attendance related database queries
+type AttendanceStore interface {
+	GetForSession(sessionID int64, date time.Time) ([]model.Attendance, error)
+	GetOfGroup(groupID int64) ([]model.Attendance, error)
+	GetOfUserInCourse(userID int64, courseID int64) ([]model.Attendance, error)
+	CountsOfCourse(courseID int64, userID int64) ([]model.AttendanceCount, error)
+	SetMany(attendances []model.Attendance) error
+}
+
+// GroupSwapStore defines group swap related database queries
+type GroupSwapStore interface {
+	Get(id int64) (*model.GroupSwapRequest, error)
+	Create(p *model.GroupSwapRequest) (*model.GroupSwapRequest, error)
+	Update(p *model.GroupSwapRequest) error
+	GetOfCourse(courseID int64, status int) ([]model.GroupSwapRequest, error)
+	GetOfUserInCourse(userID int64, courseID int64) ([]model.GroupSwapRequest, error)
+	FindMatch(p *model.GroupSwapRequest) (*model.GroupSwapRequest, error)
+	Execute(a *model.GroupSwapRequest, b *model.GroupSwapRequest) error
+}
+
+// AnnouncementStore defines announcement related database queries
+type AnnouncementStore interface {
+	Get(announcementID int64) (*model.Announcement, error)
+	Create(p *model.Announcement) (*model.Announcement, error)
+	Update(p *model.Announcement) error
+	Delete(announcementID int64) error
+	AnnouncementsOfCourse(courseID int64) ([]model.Announcement, error)
+	FeedOfUser(courseID int64, userID int64, role int64) ([]model.Announcement, error)
+	PendingEmails() ([]model.Announcement, error)
+	ClaimEmails(announcementID int64) (bool, error)
+	Recipients(p *model.Announcement) ([]model.User, error)
+}
+
+// DiscussionStore defines discussion related database queries
+type DiscussionStore interface {
+	GetThread(threadID int64) (*model.DiscussionThread, error)
+	CreateThread(p *model.DiscussionThread) (*model.DiscussionThread, error)
+	UpdateThread(p *model.DiscussionThread) error
+	DeleteThread(threadID int64) error
+	ThreadsOfCourse(courseID int64, userID int64, staff bool, sheetID int64, taskID int64) ([]model.DiscussionThread, error)
+	GetPost(postID int64) (*model.DiscussionPost, error)
+	CreatePost(p *model.DiscussionPost) (*model.DiscussionPost, error)
+	UpdatePost(p *model.DiscussionPost) error
+	DeletePost(postID int64) error
+	PostsOfThread(threadID int64) ([]model.DiscussionPost, error)
+	Subscribe(courseID int64, threadID null.Int, userID int64) error
+	Unsubscribe(courseID int64, threadID null.Int, userID int64) error
+	IsSubscribed(courseID int64, threadID null.Int, userID int64) (bool, error)
+	Subscribers(courseID int64, threadID null.Int) ([]model.User, error)
 }
 
 // API provides application resources and handlers.
@@ -209,6 +347,16 @@ type API struct {
 	Grade      *GradeResource
 	Common     *CommonResource
 	Exam       *ExamResource
+	Regrade    *RegradeResource
+	Admission  *AdmissionResource
+	Snippet    *FeedbackSnippetResource
+	Session    *GroupSessionResource
+	Calendar   *CalendarResource
+	Attendance *AttendanceResource
+	GroupSwap  *GroupSwapResource
+
+	Announcement *AnnouncementResource
+	Discussion   *DiscussionResource
 }
 
 // Stores is the collection of stores. We use this struct to express a kind of
@@ -223,10 +371,20 @@ type Stores struct {
 	Material   MaterialStore
 	Grade      GradeStore
 	Exam       ExamStore
+	Regrade    RegradeStore
+	Snippet    FeedbackSnippetStore
+	Session    GroupSessionStore
+	Attendance AttendanceStore
+	GroupSwap  GroupSwapStore
+
+	Announcement AnnouncementStore
+	Discussion   DiscussionStore
+
+	db database.Queryer
 }
 
 // NewStores build all stores and connect them to a database.
-func NewStores(db *sqlx.DB) *Stores {
+func NewStores(db database.Queryer) *Stores {
 	return &Stores{
 		Course:     database.NewCourseStore(db),
 		User:       database.NewUserStore(db),
@@ -237,9 +395,27 @@ func NewStores(db *sqlx.DB) *Stores {
 		Material:   database.NewMaterialStore(db),
 		Grade:      database.NewGradeStore(db),
 		Exam:       database.NewExamStore(db),
+		Regrade:    database.NewRegradeStore(db),
+		Snippet:    database.NewFeedbackSnippetStore(db),
+		Session:    database.NewGroupSessionStore(db),
+		Attendance: database.NewAttendanceStore(db),
+		GroupSwap:  database.NewGroupSwapStore(db),
+
+		Announcement: database.NewAnnouncementStore(db),
+		Discussion:   database.NewDiscussionStore(db),
+
+		db: db,
 	}
 }
 
+// Transaction runs fn with stores which share a single database transaction.
+// Either all changes of fn are stored or none.
+func (s *Stores) Transaction(fn func(stores *Stores) error) error {
+	return database.Transaction(s.db, func(tx database.Queryer) error {
+		return fn(NewStores(tx))
+	})
+}
+
 // NewAPI configures and returns application API.
 func NewAPI(db *sqlx.DB, tokenAuth *authenticate.TokenAuth, sessionAuth *scs.Manager) (*API, error) {
 	stores := NewStores(db)
@@ -258,6 +434,16 @@ func NewAPI(db *sqlx.DB, tokenAuth *authenticate.TokenAuth, sessionAuth *scs.Man
 		Grade:      NewGradeResource(stores),
 		Common:     NewCommonResource(stores),
 		Exam:       NewExamResource(stores),
+		Regrade:    NewRegradeResource(stores),
+		Admission:  NewAdmissionResource(stores),
+		Snippet:    NewFeedbackSnippetResource(stores),
+		Session:    NewGroupSessionResource(stores),
+		Calendar:   NewCalendarResource(stores),
+		Attendance: NewAttendanceResource(stores),
+		GroupSwap:  NewGroupSwapResource(stores),
+
+		Announcement: NewAnnouncementResource(stores),
+		Discussion:   NewDiscussionResource(stores),
 	}
 	return api, nil
 }
@@ -0,0 +1,290 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// AttendanceResource specifies attendance management handler.
+type AttendanceResource struct {
+	Stores *Stores
+}
+
+// NewAttendanceResource create and returns a AttendanceResource.
+func NewAttendanceResource(stores *Stores) *AttendanceResource {
+	return &AttendanceResource{
+		Stores: stores,
+	}
+}
+
+// sessionDateFromURL reads the date (YYYY-MM-DD) from the URL and ensures the
+// session takes place at this date.
+func sessionDateFromURL(r *http.Request, session *model.GroupSession) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		return date, err
+	}
+
+	for _, sessionDate := range session.Dates() {
+		if sessionDate.Equal(date) {
+			return date, nil
+		}
+	}
+
+	return date, errors.New("the session does not take place at this date")
+}
+
+// IndexSessionHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}/attendances/{date}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// URLPARAM: date,string
+// METHOD: get
+// TAG: attendances
+// RESPONSE: 200,AttendanceResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  the attendance of all group members at a date (YYYY-MM-DD) of a session
+// DESCRIPTION:
+// Members without a record yet are listed as absent.
+func (rs *AttendanceResource) IndexSessionHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	if !isGroupTutor(r, rs.Stores, group) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	date, err := sessionDateFromURL(r, session)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	attendances, err := rs.Stores.Attendance.GetForSession(session.ID, date)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	members, err := rs.Stores.Group.GetMembers(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	recorded := make(map[int64]bool)
+	for _, attendance := range attendances {
+		recorded[attendance.UserID] = true
+	}
+
+	for _, member := range members {
+		if recorded[member.ID] {
+			continue
+		}
+		attendances = append(attendances, model.Attendance{
+			SessionID:         session.ID,
+			SessionDate:       date,
+			GroupID:           group.ID,
+			UserID:            member.ID,
+			UserFirstName:     member.FirstName,
+			UserLastName:      member.LastName,
+			UserEmail:         member.Email,
+			UserStudentNumber: member.StudentNumber,
+		})
+	}
+
+	if err := render.RenderList(w, r, newAttendanceListResponse(attendances)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// EditSessionHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}/attendances/{date}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// URLPARAM: date,string
+// METHOD: put
+// TAG: attendances
+// REQUEST: AttendanceRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  record the attendance of group members at a date (YYYY-MM-DD) of a session
+// DESCRIPTION:
+// Only the tutor of the group and admins can record the attendance. Students
+// not listed in the request keep their previous record.
+func (rs *AttendanceResource) EditSessionHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	if !isGroupTutor(r, rs.Stores, group) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	date, err := sessionDateFromURL(r, session)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	data := &AttendanceRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	members, err := rs.Stores.Group.GetMembers(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	isMember := make(map[int64]bool)
+	for _, member := range members {
+		isMember[member.ID] = true
+	}
+
+	attendances := []model.Attendance{}
+	for _, item := range data.Attendances {
+		if !isMember[item.UserID] {
+			render.Render(w, r, ErrBadRequestWithDetails(
+				fmt.Errorf("user %d is not a member of this group", item.UserID)))
+			return
+		}
+
+		attendances = append(attendances, model.Attendance{
+			SessionID:   session.ID,
+			SessionDate: date,
+			UserID:      item.UserID,
+			// presenting a solution requires being there
+			Present:    item.Present || item.Presented,
+			Presented:  item.Presented,
+			RecordedBy: null.IntFrom(accessClaims.LoginID),
+		})
+	}
+
+	if err := rs.Stores.Attendance.SetMany(attendances); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// IndexOwnHandler is public endpoint for
+// URL: /courses/{course_id}/attendances/own
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: attendances
+// RESPONSE: 200,AttendanceResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  the recorded attendance of the request identity in a course
+func (rs *AttendanceResource) IndexOwnHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	attendances, err := rs.Stores.Attendance.GetOfUserInCourse(accessClaims.LoginID, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newAttendanceListResponse(attendances)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// ExportGroupHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/attendances/export
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: get
+// TAG: attendances
+// RESPONSE: 200,CSVFile
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  export the attendance in all sessions of a group as csv
+func (rs *AttendanceResource) ExportGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	if !isGroupTutor(r, rs.Stores, group) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	attendances, err := rs.Stores.Attendance.GetOfGroup(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	table := [][]string{{"date", "session_id", "student_number", "last_name", "first_name", "email", "present", "presented"}}
+	for _, attendance := range attendances {
+		table = append(table, []string{
+			attendance.SessionDate.Format("2006-01-02"),
+			strconv.FormatInt(attendance.SessionID, 10),
+			attendance.UserStudentNumber,
+			attendance.UserLastName,
+			attendance.UserFirstName,
+			attendance.UserEmail,
+			strconv.FormatBool(attendance.Present),
+			strconv.FormatBool(attendance.Presented),
+		})
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"infomark-group%d-attendances.csv\"", group.ID))
+	w.Header().Set("Content-Type", "text/csv")
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(table); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+}
@@ -0,0 +1,73 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// AttendanceItem is the attendance of a single student.
+type AttendanceItem struct {
+	UserID    int64 `json:"user_id" example:"112"`
+	Present   bool  `json:"present" example:"true"`
+	Presented bool  `json:"presented" example:"false"`
+}
+
+// AttendanceRequest is the request payload to record the attendance at a
+// single date of a group session.
+type AttendanceRequest struct {
+	Attendances []AttendanceItem `json:"attendances"`
+}
+
+// Bind preprocesses a AttendanceRequest.
+func (body *AttendanceRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"attendances\" data")
+	}
+
+	return body.Validate()
+}
+
+// Validate validates a AttendanceRequest.
+func (body *AttendanceRequest) Validate() error {
+	seen := make(map[int64]bool)
+	for k := range body.Attendances {
+		item := &body.Attendances[k]
+
+		if err := validation.ValidateStruct(item,
+			validation.Field(
+				&item.UserID,
+				validation.Required,
+			),
+		); err != nil {
+			return err
+		}
+
+		if seen[item.UserID] {
+			return errors.New("user_id is listed twice")
+		}
+		seen[item.UserID] = true
+	}
+
+	return nil
+}
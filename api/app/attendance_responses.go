@@ -0,0 +1,71 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+)
+
+// AttendanceResponse is the response payload for the attendance of a student
+// at a single date of a group session.
+type AttendanceResponse struct {
+	SessionID         int64     `json:"session_id" example:"3"`
+	SessionDate       time.Time `json:"session_date" example:"auto"`
+	GroupID           int64     `json:"group_id" example:"1"`
+	UserID            int64     `json:"user_id" example:"112"`
+	UserFirstName     string    `json:"user_first_name" example:"Max"`
+	UserLastName      string    `json:"user_last_name" example:"Mustermensch"`
+	UserStudentNumber string    `json:"user_student_number" example:"0816"`
+	Present           bool      `json:"present" example:"true"`
+	Presented         bool      `json:"presented" example:"false"`
+}
+
+// Render post-processes a AttendanceResponse.
+func (body *AttendanceResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newAttendanceResponse creates a response from an Attendance model.
+func newAttendanceResponse(p *model.Attendance) *AttendanceResponse {
+	return &AttendanceResponse{
+		SessionID:         p.SessionID,
+		SessionDate:       p.SessionDate,
+		GroupID:           p.GroupID,
+		UserID:            p.UserID,
+		UserFirstName:     p.UserFirstName,
+		UserLastName:      p.UserLastName,
+		UserStudentNumber: p.UserStudentNumber,
+		Present:           p.Present,
+		Presented:         p.Presented,
+	}
+}
+
+// newAttendanceListResponse creates a response from a list of Attendance models.
+func newAttendanceListResponse(attendances []model.Attendance) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range attendances {
+		list = append(list, newAttendanceResponse(&attendances[k]))
+	}
+	return list
+}
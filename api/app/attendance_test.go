@@ -0,0 +1,156 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+)
+
+func TestAttendance(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	g.Describe("Attendance", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should record attendance and count it for the exam admission", func() {
+			group, err := stores.Group.Get(1)
+			g.Assert(err).Equal(nil)
+
+			members, err := stores.Group.GetMembers(group.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(members) > 0).IsTrue()
+
+			session, err := stores.Session.Create(&model.GroupSession{
+				GroupID:   group.ID,
+				Weekday:   2,
+				StartTime: "10:15",
+				EndTime:   "12:00",
+				Room:      "A104",
+				FirstDate: time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC),
+				LastDate:  time.Date(2019, 4, 30, 0, 0, 0, 0, time.UTC),
+			})
+			g.Assert(err).Equal(nil)
+
+			tutorJWT := tape.NewJWTRequest(group.TutorID, false)
+			otherTutorID := int64(2)
+			if group.TutorID == otherTutorID {
+				otherTutorID = 3
+			}
+			otherTutorJWT := tape.NewJWTRequest(otherTutorID, false)
+			memberJWT := tape.NewJWTRequest(members[0].ID, false)
+
+			url := fmt.Sprintf("/api/v1/courses/1/groups/%d/sessions/%d/attendances/2019-04-02", group.ID, session.ID)
+
+			w := tape.Get(url, memberJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get(url, otherTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// all members are absent by default
+			w = tape.Get(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			attendances := []AttendanceResponse{}
+			err = json.NewDecoder(w.Body).Decode(&attendances)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(attendances)).Equal(len(members))
+			for _, attendance := range attendances {
+				g.Assert(attendance.Present).IsFalse()
+			}
+
+			// the session does not take place on wednesdays
+			w = tape.Get(strings.Replace(url, "2019-04-02", "2019-04-03", 1), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// only members of the group
+			w = tape.Put(url, H{"attendances": []H{{"user_id": 1, "present": true}}}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			data := H{"attendances": []H{{"user_id": members[0].ID, "present": true, "presented": true}}}
+
+			w = tape.Put(url, data, otherTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put(url, data, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			// recording twice replaces the record
+			w = tape.Put(url, data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/attendances/own", memberJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			attendances = []AttendanceResponse{}
+			err = json.NewDecoder(w.Body).Decode(&attendances)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(attendances)).Equal(1)
+			g.Assert(attendances[0].SessionID).Equal(session.ID)
+			g.Assert(attendances[0].Present).IsTrue()
+			g.Assert(attendances[0].Presented).IsTrue()
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/groups/%d/attendances/export", group.ID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+			g.Assert(len(lines)).Equal(2)
+			g.Assert(strings.HasPrefix(lines[1], "2019-04-02,")).IsTrue()
+			g.Assert(strings.HasSuffix(lines[1], ",true,true")).IsTrue()
+
+			// attendance is required for the exam admission
+			_, err = tape.DB.Exec(`UPDATE courses SET required_attendances = 2 WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get("/api/v1/courses/1/admission", memberJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			admission := &AdmissionResponse{}
+			err = json.NewDecoder(w.Body).Decode(admission)
+			g.Assert(err).Equal(nil)
+			g.Assert(admission.Attended).Equal(1)
+			g.Assert(admission.Presented).Equal(1)
+			g.Assert(admission.RequiredAttendances).Equal(2)
+			g.Assert(admission.Admitted).IsFalse()
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
@@ -0,0 +1,250 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// CalendarResource specifies the personal calendar feed handler.
+type CalendarResource struct {
+	Stores *Stores
+}
+
+// NewCalendarResource create and returns a CalendarResource.
+func NewCalendarResource(stores *Stores) *CalendarResource {
+	return &CalendarResource{
+		Stores: stores,
+	}
+}
+
+// calendarURL is the address to subscribe to the calendar feed of a token.
+func calendarURL(token string) string {
+	return fmt.Sprintf("%s/api/v1/calendar/%s", configuration.Configuration.Server.ExternalURL(), token)
+}
+
+// ChangeTokenHandler is public endpoint for
+// URL: /account/calendar
+// METHOD: post
+// TAG: account
+// RESPONSE: 200,CalendarResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  create a new secret address of the personal calendar feed
+// DESCRIPTION:
+// Any previous address of the calendar feed stops working.
+func (rs *CalendarResource) ChangeTokenHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	user, err := rs.Stores.User.Get(accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	user.CalendarToken = null.StringFrom(auth.GenerateToken(32))
+	if err := rs.Stores.User.Update(user); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.Render(w, r, &CalendarResponse{URL: calendarURL(user.CalendarToken.String)}); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// GetFeedHandler is public endpoint for
+// URL: /calendar/{token}
+// URLPARAM: token,string
+// METHOD: get
+// TAG: account
+// RESPONSE: 200,ICSFile
+// RESPONSE: 404,NotFound
+// SUMMARY:  the personal calendar feed with group sessions, due dates and exams
+// DESCRIPTION:
+// The feed does not require a login, the secret token identifies the user.
+func (rs *CalendarResource) GetFeedHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	user, err := rs.Stores.User.FindByCalendarToken(token)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	events, err := rs.eventsOfUser(user)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", helper.CalendarContentType)
+	w.Header().Set("Content-Disposition", "inline; filename=\"infomark.ics\"")
+	if err := helper.WriteCalendar(w, "infomark", events); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+}
+
+// eventsOfUser collects all group sessions, sheet due dates and exams of all
+// courses a user is enrolled in.
+func (rs *CalendarResource) eventsOfUser(user *model.User) ([]helper.CalendarEvent, error) {
+	events := []helper.CalendarEvent{}
+
+	enrollments, err := rs.Stores.User.GetEnrollments(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, enrollment := range enrollments {
+		course, err := rs.Stores.Course.Get(enrollment.CourseID)
+		if err != nil {
+			return nil, err
+		}
+
+		role := authorize.CourseRole(enrollment.Role)
+
+		// sessions of the own group or the groups a tutor is responsible for
+		var groups []model.GroupWithTutor
+		if role == authorize.STUDENT {
+			groups, err = rs.Stores.Group.GetInCourseWithUser(user.ID, course.ID)
+		} else {
+			groups, err = rs.Stores.Group.GetOfTutor(user.ID, course.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, group := range groups {
+			sessionEvents, err := rs.sessionEventsOfGroup(course, &group.Group)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, sessionEvents...)
+		}
+
+		sheets, err := rs.Stores.Sheet.SheetsOfCourse(course.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sheet := range sheets {
+			if role == authorize.STUDENT && !PublicYet(sheet.PublishAt) {
+				continue
+			}
+			events = append(events, helper.CalendarEvent{
+				UID:     fmt.Sprintf("sheet-%d@infomark", sheet.ID),
+				Summary: fmt.Sprintf("%s: %s is due", course.Name, sheet.Name),
+				Start:   sheet.DueAt,
+			})
+		}
+
+		exams, err := rs.Stores.Exam.ExamsOfCourse(course.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, exam := range exams {
+			events = append(events, helper.CalendarEvent{
+				UID:         fmt.Sprintf("exam-%d@infomark", exam.ID),
+				Summary:     fmt.Sprintf("%s: %s", course.Name, exam.Name),
+				Description: exam.Description,
+				Start:       exam.ExamTime,
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// sessionEventsOfGroup turns the weekly sessions of a group into single events
+// leaving out the cancelled dates.
+func (rs *CalendarResource) sessionEventsOfGroup(course *model.Course, group *model.Group) ([]helper.CalendarEvent, error) {
+	events := []helper.CalendarEvent{}
+
+	sessions, err := rs.Stores.Session.SessionsOfGroup(group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		cancelledDates, err := rs.Stores.Session.GetCancellations(session.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		cancelled := make(map[string]bool)
+		for _, date := range cancelledDates {
+			cancelled[date.Format("2006-01-02")] = true
+		}
+
+		for _, date := range session.Dates() {
+			if cancelled[date.Format("2006-01-02")] {
+				continue
+			}
+
+			events = append(events, helper.CalendarEvent{
+				UID:      fmt.Sprintf("session-%d-%s@infomark", session.ID, date.Format("20060102")),
+				Summary:  fmt.Sprintf("%s: %s", course.Name, group.Description),
+				Location: session.Room,
+				URL:      session.OnlineURL.String,
+				Start:    atTimeOfDay(date, session.StartTime),
+				End:      atTimeOfDay(date, session.EndTime),
+				Floating: true,
+			})
+		}
+	}
+
+	return events, nil
+}
+
+// atTimeOfDay returns the date at the time of day given as HH:MM.
+func atTimeOfDay(date time.Time, timeOfDay string) time.Time {
+	parts := strings.SplitN(timeOfDay, ":", 2)
+	hour, _ := strconv.Atoi(parts[0])
+	minute := 0
+	if len(parts) > 1 {
+		minute, _ = strconv.Atoi(parts[1])
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, time.UTC)
+}
@@ -0,0 +1,129 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+)
+
+func TestCalendar(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	studentJWT := tape.NewJWTRequest(112, false)
+
+	g.Describe("Calendar", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should require access claims to create a feed", func() {
+			w := tape.Post("/api/v1/account/calendar", H{})
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+		})
+
+		g.It("Should serve the personal calendar feed", func() {
+			groups, err := stores.Group.GetInCourseWithUser(studentJWT.Claims.LoginID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(groups)).Equal(1)
+
+			session, err := stores.Session.Create(&model.GroupSession{
+				GroupID:   groups[0].ID,
+				Weekday:   2,
+				StartTime: "10:15",
+				EndTime:   "12:00",
+				Room:      "A104",
+				FirstDate: time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC),
+				LastDate:  time.Date(2019, 4, 30, 0, 0, 0, 0, time.UTC),
+			})
+			g.Assert(err).Equal(nil)
+
+			err = stores.Session.SetCancellations(session.ID, []time.Time{
+				time.Date(2019, 4, 16, 0, 0, 0, 0, time.UTC),
+			})
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/account/calendar", H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			calendar := &CalendarResponse{}
+			err = json.NewDecoder(w.Body).Decode(calendar)
+			g.Assert(err).Equal(nil)
+
+			token := calendar.URL[strings.LastIndex(calendar.URL, "/")+1:]
+			g.Assert(len(token) > 0).IsTrue()
+
+			// subscribing does not need a login
+			w = tape.Get(fmt.Sprintf("/api/v1/calendar/%s", token))
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar")).IsTrue()
+
+			feed := w.Body.String()
+			g.Assert(strings.HasPrefix(feed, "BEGIN:VCALENDAR")).IsTrue()
+
+			// tuesdays in april 2019 except the cancelled one
+			for _, day := range []string{"20190402", "20190409", "20190423", "20190430"} {
+				g.Assert(strings.Contains(feed, fmt.Sprintf("UID:session-%d-%s@infomark", session.ID, day))).IsTrue()
+			}
+			g.Assert(strings.Contains(feed, fmt.Sprintf("UID:session-%d-20190416@infomark", session.ID))).IsFalse()
+			g.Assert(strings.Contains(feed, "LOCATION:A104")).IsTrue()
+
+			sheets, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			for _, sheet := range sheets {
+				uid := fmt.Sprintf("UID:sheet-%d@infomark", sheet.ID)
+				g.Assert(strings.Contains(feed, uid)).Equal(PublicYet(sheet.PublishAt))
+			}
+
+			exams, err := stores.Exam.ExamsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			for _, exam := range exams {
+				g.Assert(strings.Contains(feed, fmt.Sprintf("UID:exam-%d@infomark", exam.ID))).IsTrue()
+			}
+
+			// a new address replaces the old one
+			w = tape.Post("/api/v1/account/calendar", H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Get(fmt.Sprintf("/api/v1/calendar/%s", token))
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
@@ -20,6 +20,9 @@
 package app
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -121,6 +124,62 @@ func PublicYet(t time.Time) bool {
 	return NowUTC().Sub(t) > 0
 }
 
+// GradesReleasedYet tests if students are allowed to see the grades of a sheet
+func GradesReleasedYet(sheet *model.Sheet) bool {
+	if sheet.GradesWithheld {
+		return false
+	}
+	if sheet.GradesReleaseAt.Valid {
+		return PublicYet(sheet.GradesReleaseAt.Time)
+	}
+	return true
+}
+
+// BlindGradingActive tests if tutors should only see pseudonyms of students
+// in a sheet. The identity is revealed once the grades are released.
+func BlindGradingActive(sheet *model.Sheet) bool {
+	return sheet.BlindGrading && !GradesReleasedYet(sheet)
+}
+
+// blindSheetsOfCourse collects all sheets of a course in which the identity of
+// the students must be hidden from the request identity.
+func blindSheetsOfCourse(stores *Stores, courseID int64, givenRole authorize.CourseRole) (map[int64]bool, error) {
+	blindSheets := make(map[int64]bool)
+	if givenRole != authorize.TUTOR {
+		return blindSheets, nil
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range sheets {
+		if BlindGradingActive(&sheets[k]) {
+			blindSheets[sheets[k].ID] = true
+		}
+	}
+	return blindSheets, nil
+}
+
+// userFilterRevealsIdentity tests if filtering by a student would reveal
+// which pseudonym belongs to the student in a sheet graded blindly.
+func userFilterRevealsIdentity(blindSheets map[int64]bool, filterUserID int64, filterSheetID int64) bool {
+	if filterUserID == 0 || len(blindSheets) == 0 {
+		return false
+	}
+	return filterSheetID == 0 || blindSheets[filterSheetID]
+}
+
+// Pseudonym derives a stable alias of a student within a course which does not
+// reveal the identity. The same student gets different aliases in different
+// courses.
+func Pseudonym(courseID int64, userID int64) string {
+	mac := hmac.New(sha256.New, []byte(configuration.Configuration.Server.Authentication.JWT.Secret))
+	fmt.Fprintf(mac, "%d:%d", courseID, userID)
+	return fmt.Sprintf("anon-%s", hex.EncodeToString(mac.Sum(nil))[:10])
+}
+
 // OverTime tests if the deadline is missed (alias for publicyet)
 func OverTime(t time.Time) bool {
 	return NowUTC().Sub(t) > 0
@@ -25,6 +25,8 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
@@ -99,6 +101,18 @@ func (rs *CourseResource) CreateHandler(w http.ResponseWriter, r *http.Request)
 	course.BeginsAt = data.BeginsAt
 	course.EndsAt = data.EndsAt
 	course.RequiredPercentage = data.RequiredPercentage
+	course.RegradeWindowDays = data.RegradeWindowDays
+	course.GroupSelfEnrollment = data.GroupSelfEnrollment
+	course.WaitlistNotification = data.WaitlistNotification
+	course.RequiredAttendances = data.RequiredAttendances
+	course.RequiredPresentations = data.RequiredPresentations
+	course.GroupSwapApproval = data.GroupSwapApproval
+	course.EnrollmentKey = data.EnrollmentKey
+	course.EnrollmentBeginsAt = data.EnrollmentBeginsAt
+	course.EnrollmentEndsAt = data.EnrollmentEndsAt
+	course.MaxStudents = data.MaxStudents
+	course.EnrollmentApproval = data.EnrollmentApproval
+	course.EnrollmentAllowlist = data.EnrollmentAllowlist
 
 	// create course entry in database
 	newCourse, err := rs.Stores.Course.Create(course)
@@ -110,7 +124,7 @@ func (rs *CourseResource) CreateHandler(w http.ResponseWriter, r *http.Request)
 	render.Status(r, http.StatusCreated)
 
 	// return course information of created entry
-	if err := render.Render(w, r, rs.newCourseResponse(newCourse)); err != nil {
+	if err := render.Render(w, r, rs.newCourseAdminResponse(newCourse)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -135,8 +149,13 @@ func (rs *CourseResource) GetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response := rs.newCourseResponse(course)
+	if givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole); givenRole == authorize.ADMIN {
+		response = rs.newCourseAdminResponse(course)
+	}
+
 	// render JSON response
-	if err := render.Render(w, r, rs.newCourseResponse(course)); err != nil {
+	if err := render.Render(w, r, response); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -171,6 +190,18 @@ func (rs *CourseResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	course.BeginsAt = data.BeginsAt
 	course.EndsAt = data.EndsAt
 	course.RequiredPercentage = data.RequiredPercentage
+	course.RegradeWindowDays = data.RegradeWindowDays
+	course.GroupSelfEnrollment = data.GroupSelfEnrollment
+	course.WaitlistNotification = data.WaitlistNotification
+	course.RequiredAttendances = data.RequiredAttendances
+	course.RequiredPresentations = data.RequiredPresentations
+	course.GroupSwapApproval = data.GroupSwapApproval
+	course.EnrollmentKey = data.EnrollmentKey
+	course.EnrollmentBeginsAt = data.EnrollmentBeginsAt
+	course.EnrollmentEndsAt = data.EnrollmentEndsAt
+	course.MaxStudents = data.MaxStudents
+	course.EnrollmentApproval = data.EnrollmentApproval
+	course.EnrollmentAllowlist = data.EnrollmentAllowlist
 
 	// update database entry
 	if err := rs.Stores.Course.Update(course); err != nil {
@@ -208,6 +239,201 @@ func (rs *CourseResource) DeleteHandler(w http.ResponseWriter, r *http.Request)
 	render.Status(r, http.StatusNoContent)
 }
 
+// CloneHandler is public endpoint for
+// URL: /courses/{course_id}/clone
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: courses
+// REQUEST: CourseCloneRequest
+// RESPONSE: 201,CourseResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  copy a course including sheets, tasks, materials, exams, feedback snippets and groups into a new course
+// DESCRIPTION:
+// All dates are moved by "offset_days" or such that the new course begins at
+// "begins_at". Groups are copied with their weekly sessions but without members
+// and cancelled session dates. Students are not enrolled.
+func (rs *CourseResource) CloneHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &CourseCloneRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	newCourse, err := CloneCourse(rs.Stores, course, data.Name, data.Offset(course.BeginsAt))
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, rs.newCourseAdminResponse(newCourse)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// ExportArchiveHandler is public endpoint for
+// URL: /courses/{course_id}/archive
+// URLPARAM: course_id,integer
+// QUERYPARAM: submissions,bool
+// QUERYPARAM: anonymize,bool
+// METHOD: get
+// TAG: courses
+// RESPONSE: 200,ZipFile
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  export the course as a portable archive
+// DESCRIPTION:
+// The zip file contains a versioned "manifest.json" together with all sheet,
+// test and material files. Setting submissions=true adds all submissions,
+// grades and group members. Setting anonymize=true replaces students by
+// pseudonyms.
+func (rs *CourseResource) ExportArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	withSubmissions := helper.StringFromURL(r, "submissions", "false") == "true"
+	anonymize := helper.StringFromURL(r, "anonymize", "false") == "true"
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"infomark-course%d-archive.zip\"", course.ID))
+
+	if err := ExportCourseArchive(rs.Stores, course, w, withSubmissions, anonymize); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+}
+
+// ImportArchiveHandler is public endpoint for
+// URL: /courses/import
+// METHOD: post
+// TAG: courses
+// REQUEST: Zipfile
+// RESPONSE: 201,CourseResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  create a new course from a course archive
+// DESCRIPTION:
+// Users are matched by their email address. Unknown users are created without
+// a password. Nothing is imported if the archive is invalid or any step fails.
+func (rs *CourseResource) ImportArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	file, header, err := r.FormFile("file_data")
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	defer file.Close()
+
+	course, err := ImportCourseArchive(rs.Stores, file, header.Size)
+	if err != nil {
+		var archiveErr *courseArchiveError
+		if errors.As(err, &archiveErr) {
+			render.Render(w, r, ErrBadRequestWithDetails(err))
+			return
+		}
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, rs.newCourseAdminResponse(course)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// ImportUsersHandler is public endpoint for
+// URL: /courses/{course_id}/enrollments/import
+// URLPARAM: course_id,integer
+// QUERYPARAM: dry_run,bool
+// METHOD: post
+// TAG: enrollments
+// REQUEST: Csvfile
+// RESPONSE: 200,UserImportEntryResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  bulk-import and enroll users from a csv file
+// DESCRIPTION:
+// The csv file is uploaded as "file_data" and requires the columns email and name
+// (or first_name and last_name). The columns student_number, subject, semester,
+// role and group are optional. Missing accounts are created and invited by email
+// to set a password. If any line has a conflict, nothing is imported and all lines
+// are returned with status 400. Setting dry_run=true only returns the report.
+func (rs *CourseResource) ImportUsersHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	dryRun := helper.StringFromURL(r, "dry_run", "false") == "true"
+
+	file, _, err := r.FormFile("file_data")
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	defer file.Close()
+
+	rows, err := ParseUserImport(file)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	entries, valid, err := PlanUserImport(rs.Stores, course.ID, rows)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if !valid {
+		render.Status(r, http.StatusBadRequest)
+		if err := render.RenderList(w, r, newUserImportEntryListResponse(entries)); err != nil {
+			render.Render(w, r, ErrRender(err))
+		}
+		return
+	}
+
+	if !dryRun {
+		created, err := ApplyUserImport(rs.Stores, course.ID, entries)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		// the import has been committed, all invites are queued at once
+		invites := []*email.Email{}
+		for k := range created {
+			msg, err := NewUserInviteEmail(course, &created[k])
+			if err != nil {
+				render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+				return
+			}
+			invites = append(invites, msg)
+		}
+		for _, msg := range invites {
+			email.OutgoingEmailsChannel <- msg
+		}
+	}
+
+	if err := render.RenderList(w, r, newUserImportEntryListResponse(entries)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
 // IndexEnrollmentsHandler is public endpoint for
 // URL: /courses/{course_id}/enrollments
 // URLPARAM: course_id,integer
@@ -348,7 +574,7 @@ func (rs *CourseResource) DeleteUserEnrollmentHandler(w http.ResponseWriter, r *
 		return
 	}
 
-	if err := rs.Stores.Course.Disenroll(course.ID, user.ID); err != nil {
+	if err := disenrollFromCourse(rs.Stores, course, user.ID); err != nil {
 		render.Render(w, r, ErrBadRequestWithDetails(err))
 		return
 	}
@@ -395,12 +621,18 @@ func (rs *CourseResource) ChangeRole(w http.ResponseWriter, r *http.Request) {
 // URLPARAM: course_id,integer
 // METHOD: post
 // TAG: enrollments
-// REQUEST: Empty
-// RESPONSE: 204,NoContent
+// REQUEST: EnrollRequest
+// RESPONSE: 201,EnrollmentResponse
+// RESPONSE: 202,EnrollmentRequestResponse
 // RESPONSE: 400,BadRequest
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  enroll a user into a course
+// DESCRIPTION:
+// Depending on the settings of the course, students need the enrollment key,
+// must enroll within the enrollment window, must match the allowlist and the
+// course must not be full. If the enrollment needs an approval, the user is
+// put into the queue and the status code is 202.
 func (rs *CourseResource) EnrollHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
@@ -410,12 +642,67 @@ func (rs *CourseResource) EnrollHandler(w http.ResponseWriter, r *http.Request)
 		role = int64(2)
 	}
 
-	// update database entry
-	if err := rs.Stores.Course.Enroll(course.ID, accessClaims.LoginID, role); err != nil {
+	if !accessClaims.Root {
+		data := &EnrollRequest{}
+		// the body is optional as long as there is no enrollment key
+		if r.ContentLength > 0 {
+			if err := render.Bind(r, data); err != nil {
+				render.Render(w, r, ErrBadRequestWithDetails(err))
+				return
+			}
+		}
+
+		user, err := rs.Stores.User.Get(accessClaims.LoginID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		if err := rs.ensureEnrollmentAllowed(course, user, data.EnrollmentKey); err != nil {
+			render.Render(w, r, ErrBadRequestWithDetails(err))
+			return
+		}
+	}
+
+	approval := !accessClaims.Root && course.EnrollmentApproval
+	err := rs.Stores.Transaction(func(tx *Stores) error {
+		// roots do not take the seat of a student
+		if !accessClaims.Root {
+			if err := ensureStudentSeat(tx, course); err != nil {
+				return err
+			}
+		}
+
+		if approval {
+			return tx.Course.CreateEnrollmentRequest(course.ID, accessClaims.LoginID)
+		}
+		return tx.Course.Enroll(course.ID, accessClaims.LoginID, role)
+	})
+	if err == errCourseFull {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	if err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
 
+	if approval {
+		request, err := rs.Stores.Course.GetEnrollmentRequest(course.ID, accessClaims.LoginID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		render.Status(r, http.StatusAccepted)
+
+		if err := render.Render(w, r, newEnrollmentRequestResponse(request)); err != nil {
+			render.Render(w, r, ErrRender(err))
+			return
+		}
+		return
+	}
+
 	userEnrollment, err := rs.Stores.Course.GetUserEnrollment(course.ID, accessClaims.LoginID)
 	if err != nil {
 		render.Render(w, r, ErrBadRequestWithDetails(err))
@@ -431,6 +718,186 @@ func (rs *CourseResource) EnrollHandler(w http.ResponseWriter, r *http.Request)
 
 }
 
+// ensureEnrollmentAllowed checks the enrollment settings of a course for a
+// user who wants to enroll as a student. The maximum number of students is
+// checked by ensureStudentSeat.
+func (rs *CourseResource) ensureEnrollmentAllowed(course *model.Course, user *model.User, key string) error {
+	now := NowUTC()
+	if course.EnrollmentBeginsAt.Valid && now.Before(course.EnrollmentBeginsAt.Time) {
+		return fmt.Errorf("the enrollment opens at %s", course.EnrollmentBeginsAt.Time.Format(time.RFC3339))
+	}
+	if course.EnrollmentEndsAt.Valid && now.After(course.EnrollmentEndsAt.Time) {
+		return fmt.Errorf("the enrollment closed at %s", course.EnrollmentEndsAt.Time.Format(time.RFC3339))
+	}
+
+	if !EnrollmentAllowlisted(course, user) {
+		return errors.New("your email address or student number is not allowed to enroll in this course")
+	}
+
+	if course.EnrollmentKey != "" && key != course.EnrollmentKey {
+		return errors.New("the enrollment key is wrong")
+	}
+
+	return nil
+}
+
+var errCourseFull = errors.New("the course has reached the maximum number of students")
+
+// ensureStudentSeat checks the maximum number of students of a course. It
+// locks the course, so the check and the following enrollment have to run in
+// the same transaction.
+func ensureStudentSeat(stores *Stores, course *model.Course) error {
+	if course.MaxStudents <= 0 {
+		return nil
+	}
+
+	if err := stores.Course.Lock(course.ID); err != nil {
+		return err
+	}
+
+	count, err := stores.Course.CountStudents(course.ID)
+	if err != nil {
+		return err
+	}
+	if count >= course.MaxStudents {
+		return errCourseFull
+	}
+	return nil
+}
+
+// EnrollmentAllowlisted tests if the email domain or the student number of the
+// user is on the allowlist of the course. An empty allowlist accepts everyone.
+func EnrollmentAllowlisted(course *model.Course, user *model.User) bool {
+	if len(course.EnrollmentAllowlist) == 0 {
+		return true
+	}
+
+	userEmail := strings.ToLower(user.Email)
+	for _, entry := range course.EnrollmentAllowlist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.HasPrefix(entry, "@") {
+			if strings.HasSuffix(userEmail, strings.ToLower(entry)) {
+				return true
+			}
+		} else if user.StudentNumber != "" && entry == user.StudentNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexEnrollmentRequestsHandler is public endpoint for
+// URL: /courses/{course_id}/enrollment_requests
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: enrollments
+// RESPONSE: 200,EnrollmentRequestResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all users waiting for the approval of their enrollment
+func (rs *CourseResource) IndexEnrollmentRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	requests, err := rs.Stores.Course.GetEnrollmentRequests(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newEnrollmentRequestListResponse(requests)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// ApproveEnrollmentRequestHandler is public endpoint for
+// URL: /courses/{course_id}/enrollment_requests/{user_id}/approve
+// URLPARAM: course_id,integer
+// URLPARAM: user_id,integer
+// METHOD: post
+// TAG: enrollments
+// RESPONSE: 201,EnrollmentResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  enroll a waiting user as a student
+// DESCRIPTION:
+// The maximum number of students is still enforced.
+func (rs *CourseResource) ApproveEnrollmentRequestHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	user := r.Context().Value(symbol.CtxKeyUser).(*model.User)
+
+	if _, err := rs.Stores.Course.GetEnrollmentRequest(course.ID, user.ID); err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	err := rs.Stores.Transaction(func(tx *Stores) error {
+		if err := ensureStudentSeat(tx, course); err != nil {
+			return err
+		}
+
+		if err := tx.Course.Enroll(course.ID, user.ID, int64(authorize.STUDENT)); err != nil {
+			return err
+		}
+
+		return tx.Course.DeleteEnrollmentRequest(course.ID, user.ID)
+	})
+	if err == errCourseFull {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	userEnrollment, err := rs.Stores.Course.GetUserEnrollment(course.ID, user.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newEnrollmentResponse(userEnrollment)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// RejectEnrollmentRequestHandler is public endpoint for
+// URL: /courses/{course_id}/enrollment_requests/{user_id}
+// URLPARAM: course_id,integer
+// URLPARAM: user_id,integer
+// METHOD: delete
+// TAG: enrollments
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  remove a user from the approval queue without enrolling
+func (rs *CourseResource) RejectEnrollmentRequestHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	user := r.Context().Value(symbol.CtxKeyUser).(*model.User)
+
+	if _, err := rs.Stores.Course.GetEnrollmentRequest(course.ID, user.ID); err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	if err := rs.Stores.Course.DeleteEnrollmentRequest(course.ID, user.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
 // DisenrollHandler is public endpoint for
 // URL: /courses/{course_id}/enrollments
 // URLPARAM: course_id,integer
@@ -453,7 +920,7 @@ func (rs *CourseResource) DisenrollHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	// update database entry
-	if err := rs.Stores.Course.Disenroll(course.ID, accessClaims.LoginID); err != nil {
+	if err := disenrollFromCourse(rs.Stores, course, accessClaims.LoginID); err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
@@ -461,6 +928,27 @@ func (rs *CourseResource) DisenrollHandler(w http.ResponseWriter, r *http.Reques
 	render.Status(r, http.StatusNoContent)
 }
 
+// disenrollFromCourse removes a user from a course including the group seat and
+// hands a freed seat to the next student on the waitlist of that group.
+func disenrollFromCourse(stores *Stores, course *model.Course, userID int64) error {
+	enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(userID, course.ID)
+	hadSeat := err == nil
+
+	if err := stores.Course.Disenroll(course.ID, userID); err != nil {
+		return err
+	}
+
+	if !hadSeat {
+		return nil
+	}
+
+	group, err := stores.Group.Get(enrollment.GroupID)
+	if err != nil {
+		return err
+	}
+	return promoteFromWaitlist(stores, course, group)
+}
+
 // SendEmailHandler is public endpoint for
 // URL: /courses/{course_id}/emails
 // URLPARAM: course_id,integer
@@ -536,19 +1024,64 @@ func (rs *CourseResource) SendEmailHandler(w http.ResponseWriter, r *http.Reques
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  get all points for the request identity
+// DESCRIPTION:
+// For students each sheet additionally states whether the points missed the
+// minimum percentage required for the exam admission.
 func (rs *CourseResource) PointsHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
 	sheetPoints, err := rs.Stores.Course.PointsForUser(accessClaims.LoginID, course.ID)
 	if err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
 
-	// resp := &SheetPointsResponse{SheetPoints: sheetPoints}
+	minPercentages := make(map[int64]int)
+	missedSheets := make(map[int64]bool)
 
-	if err := render.RenderList(w, r, newSheetPointsListResponse(sheetPoints)); err != nil {
+	if givenRole == authorize.STUDENT {
+		sheets, err := rs.Stores.Sheet.SheetsOfCourse(course.ID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		// students should not see any manual grading before the release
+		released := make(map[int64]bool)
+		for k := range sheets {
+			released[sheets[k].ID] = GradesReleasedYet(&sheets[k])
+			minPercentages[sheets[k].ID] = sheets[k].MinPercentage
+		}
+
+		for k := range sheetPoints {
+			if !released[int64(sheetPoints[k].SheetID)] {
+				sheetPoints[k].AquiredPoints = 0
+				sheetPoints[k].AchievablePoints = 0
+			}
+		}
+
+		admission, err := AdmissionOfUser(rs.Stores, course, accessClaims.LoginID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+		for _, sheetID := range admission.MissedSheetIDs {
+			missedSheets[sheetID] = true
+		}
+	}
+
+	list := []render.Renderer{}
+	for k := range sheetPoints {
+		resp := newSheetPointsResponse(&sheetPoints[k])
+		resp.MinPercentage = minPercentages[int64(sheetPoints[k].SheetID)]
+		resp.MissedMinPercentage = missedSheets[int64(sheetPoints[k].SheetID)]
+		list = append(list, resp)
+	}
+
+	if err := render.RenderList(w, r, list); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -602,6 +1135,35 @@ func (rs *CourseResource) BidsHandler(w http.ResponseWriter, r *http.Request) {
 	render.Status(r, http.StatusOK)
 }
 
+// PseudonymsHandler is public endpoint for
+// URL: /courses/{course_id}/pseudonyms
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: courses
+// RESPONSE: 200,PseudonymResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  the mapping between students and their pseudonyms in sheets with blind grading
+func (rs *CourseResource) PseudonymsHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	enrolledUsers, err := rs.Stores.Course.EnrolledUsers(course.ID,
+		[]string{"0"}, "%%", "%%", "%%", "%%", "%%",
+	)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newPseudonymListResponse(enrolledUsers, course.ID)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
 // .............................................................................
 
 // Context middleware is used to load an Course object from
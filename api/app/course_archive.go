@@ -0,0 +1,861 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// CourseArchiveVersion is the version of the bundle format written by
+// ExportCourseArchive. Importing rejects archives of other versions.
+const CourseArchiveVersion = 1
+
+// courseArchiveManifest is the file name of the manifest within the bundle.
+const courseArchiveManifest = "manifest.json"
+
+// courseArchiveError is returned for archives which cannot be imported because
+// of their content, in contrast to failures of the database or file system.
+type courseArchiveError struct {
+	err error
+}
+
+func (e *courseArchiveError) Error() string {
+	return e.err.Error()
+}
+
+// invalidArchive creates an error about the content of an archive.
+func invalidArchive(format string, a ...interface{}) error {
+	return &courseArchiveError{err: fmt.Errorf(format, a...)}
+}
+
+// A course archive is a zip file containing "manifest.json" and all uploaded
+// files of the course. IDs in the manifest are the ones of the exporting
+// instance and are only used to link entries within the archive.
+
+// CourseArchive is the manifest of a course bundle.
+type CourseArchive struct {
+	Version     int                       `json:"version"`
+	ExportedAt  time.Time                 `json:"exported_at"`
+	Anonymized  bool                      `json:"anonymized"`
+	Course      CourseArchiveCourse       `json:"course"`
+	Users       []CourseArchiveUser       `json:"users"`
+	Sheets      []CourseArchiveSheet      `json:"sheets"`
+	Materials   []CourseArchiveMaterial   `json:"materials"`
+	Exams       []CourseArchiveExam       `json:"exams"`
+	Groups      []CourseArchiveGroup      `json:"groups"`
+	Submissions []CourseArchiveSubmission `json:"submissions"`
+}
+
+// CourseArchiveCourse holds the settings of the course.
+type CourseArchiveCourse struct {
+	Name                  string    `json:"name"`
+	Description           string    `json:"description"`
+	BeginsAt              time.Time `json:"begins_at"`
+	EndsAt                time.Time `json:"ends_at"`
+	RequiredPercentage    int       `json:"required_percentage"`
+	RegradeWindowDays     int       `json:"regrade_window_days"`
+	GroupSelfEnrollment   bool      `json:"group_self_enrollment"`
+	WaitlistNotification  bool      `json:"waitlist_notification"`
+	RequiredAttendances   int       `json:"required_attendances"`
+	RequiredPresentations int       `json:"required_presentations"`
+	GroupSwapApproval     bool      `json:"group_swap_approval"`
+	EnrollmentKey         string    `json:"enrollment_key"`
+	EnrollmentBeginsAt    null.Time `json:"enrollment_begins_at"`
+	EnrollmentEndsAt      null.Time `json:"enrollment_ends_at"`
+	MaxStudents           int       `json:"max_students"`
+	EnrollmentApproval    bool      `json:"enrollment_approval"`
+	EnrollmentAllowlist   []string  `json:"enrollment_allowlist"`
+}
+
+// CourseArchiveUser is an account referenced in the archive. Users are matched
+// by email on import.
+type CourseArchiveUser struct {
+	ID            int64  `json:"id"`
+	Enrolled      bool   `json:"enrolled"`
+	Role          int64  `json:"role"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Email         string `json:"email"`
+	StudentNumber string `json:"student_number"`
+	Semester      int    `json:"semester"`
+	Subject       string `json:"subject"`
+	Language      string `json:"language"`
+}
+
+// CourseArchiveSheet is an exercise sheet including its tasks.
+type CourseArchiveSheet struct {
+	ID                  int64               `json:"id"`
+	Name                string              `json:"name"`
+	PublishAt           time.Time           `json:"publish_at"`
+	DueAt               time.Time           `json:"due_at"`
+	GradesReleaseAt     null.Time           `json:"grades_release_at"`
+	GradesWithheld      bool                `json:"grades_withheld"`
+	GradingStrategy     int                 `json:"grading_strategy"`
+	BlindGrading        bool                `json:"blind_grading"`
+	MinPercentage       int                 `json:"min_percentage"`
+	GradesReleaseNotify bool                `json:"grades_release_notify"`
+	File                string              `json:"file"`
+	Tasks               []CourseArchiveTask `json:"tasks"`
+}
+
+// CourseArchiveTask is a task including the docker settings and test files.
+type CourseArchiveTask struct {
+	ID                 int64       `json:"id"`
+	Name               string      `json:"name"`
+	MaxPoints          int         `json:"max_points"`
+	PublicDockerImage  null.String `json:"public_docker_image"`
+	PrivateDockerImage null.String `json:"private_docker_image"`
+	GraderID           null.Int    `json:"grader_id"`
+	IsBonus            bool        `json:"is_bonus"`
+	BonusPoints        int         `json:"bonus_points"`
+	DoubleGrading      bool        `json:"double_grading"`
+	ReconcileThreshold int         `json:"reconcile_threshold"`
+	PublicTestFile     string      `json:"public_test_file"`
+	PrivateTestFile    string      `json:"private_test_file"`
+}
+
+// CourseArchiveMaterial is a slide or any other material.
+type CourseArchiveMaterial struct {
+	Name         string    `json:"name"`
+	Kind         int       `json:"kind"`
+	Filename     string    `json:"filename"`
+	PublishAt    time.Time `json:"publish_at"`
+	LectureAt    time.Time `json:"lecture_at"`
+	RequiredRole int       `json:"required_role"`
+	File         string    `json:"file"`
+}
+
+// CourseArchiveExam is an exam of the course (without enrollments).
+type CourseArchiveExam struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ExamTime    time.Time `json:"exam_time"`
+}
+
+// CourseArchiveGroup is an exercise group. Members are only exported together
+// with the submissions.
+type CourseArchiveGroup struct {
+	TutorID     int64   `json:"tutor_id"`
+	CoTutorIDs  []int64 `json:"co_tutor_ids"`
+	Description string  `json:"description"`
+	Capacity    int     `json:"capacity"`
+	MemberIDs   []int64 `json:"member_ids"`
+}
+
+// CourseArchiveSubmission is a submission of a student with its grade.
+type CourseArchiveSubmission struct {
+	UserID int64               `json:"user_id"`
+	TaskID int64               `json:"task_id"`
+	File   string              `json:"file"`
+	Grade  *CourseArchiveGrade `json:"grade"`
+}
+
+// CourseArchiveGrade is the grade of a submission.
+type CourseArchiveGrade struct {
+	TutorID               int64    `json:"tutor_id"`
+	AssignedTutorID       null.Int `json:"assigned_tutor_id"`
+	AcquiredPoints        int      `json:"acquired_points"`
+	Feedback              string   `json:"feedback"`
+	FeedbackFile          string   `json:"feedback_file"`
+	PublicExecutionState  int      `json:"public_execution_state"`
+	PrivateExecutionState int      `json:"private_execution_state"`
+	PublicTestLog         string   `json:"public_test_log"`
+	PrivateTestLog        string   `json:"private_test_log"`
+	PublicTestStatus      int      `json:"public_test_status"`
+	PrivateTestStatus     int      `json:"private_test_status"`
+}
+
+// courseArchiveWriter collects the manifest while files are written to the zip.
+type courseArchiveWriter struct {
+	stores    *Stores
+	course    *model.Course
+	zw        *zip.Writer
+	archive   CourseArchive
+	users     map[int64]bool
+	anonymize bool
+}
+
+// addUser adds an account to the manifest unless it is already listed.
+// Students are replaced by their pseudonym if requested.
+func (aw *courseArchiveWriter) addUser(userID int64) error {
+	// by definition user with id 1 is the system, which exists in every instance
+	if userID == 1 || aw.users[userID] {
+		return nil
+	}
+
+	user, err := aw.stores.User.Get(userID)
+	if err != nil {
+		return err
+	}
+
+	entry := CourseArchiveUser{
+		ID:            user.ID,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         user.Email,
+		StudentNumber: user.StudentNumber,
+		Semester:      user.Semester,
+		Subject:       user.Subject,
+		Language:      user.Language,
+	}
+
+	role, err := aw.stores.Course.RoleInCourse(user.ID, aw.course.ID)
+	if err != nil {
+		return err
+	}
+	if role != authorize.NOCOURSEROLE {
+		entry.Enrolled = true
+		entry.Role = int64(role)
+	}
+
+	if aw.anonymize && (!entry.Enrolled || role == authorize.STUDENT) {
+		pseudonym := Pseudonym(aw.course.ID, user.ID)
+		entry.FirstName = "Anonymous"
+		entry.LastName = pseudonym
+		entry.Email = fmt.Sprintf("%s@anonymous.invalid", pseudonym)
+		entry.StudentNumber = ""
+		entry.Subject = ""
+		entry.Semester = 1
+	}
+
+	aw.users[userID] = true
+	aw.archive.Users = append(aw.archive.Users, entry)
+	return nil
+}
+
+// addFile copies an uploaded file into the archive.
+func (aw *courseArchiveWriter) addFile(name string, hnd *helper.FileHandle) (string, error) {
+	return addZipFile(aw.zw, name, hnd)
+}
+
+// addZipFile copies an uploaded file into a zip. The extension is taken from
+// the stored file. It returns the name within the zip or an empty string if
+// there is no such file.
+func addZipFile(zw *zip.Writer, name string, hnd *helper.FileHandle) (string, error) {
+	if !hnd.Exists() {
+		return "", nil
+	}
+
+	src := hnd.Path()
+	name = name + path.Ext(src)
+
+	dst, err := zw.Create(name)
+	if err != nil {
+		return "", err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	if _, err := io.Copy(dst, in); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ExportCourseArchive writes a course bundle. Submissions, grades and group
+// members are only included if requested and students can be replaced by
+// pseudonyms.
+func ExportCourseArchive(stores *Stores, course *model.Course, w io.Writer, withSubmissions bool, anonymize bool) error {
+	aw := &courseArchiveWriter{
+		stores:    stores,
+		course:    course,
+		zw:        zip.NewWriter(w),
+		users:     make(map[int64]bool),
+		anonymize: anonymize,
+		archive: CourseArchive{
+			Version:    CourseArchiveVersion,
+			ExportedAt: NowUTC(),
+			Anonymized: anonymize,
+			Course: CourseArchiveCourse{
+				Name:                  course.Name,
+				Description:           course.Description,
+				BeginsAt:              course.BeginsAt,
+				EndsAt:                course.EndsAt,
+				RequiredPercentage:    course.RequiredPercentage,
+				RegradeWindowDays:     course.RegradeWindowDays,
+				GroupSelfEnrollment:   course.GroupSelfEnrollment,
+				WaitlistNotification:  course.WaitlistNotification,
+				RequiredAttendances:   course.RequiredAttendances,
+				RequiredPresentations: course.RequiredPresentations,
+				GroupSwapApproval:     course.GroupSwapApproval,
+				EnrollmentKey:         course.EnrollmentKey,
+				EnrollmentBeginsAt:    course.EnrollmentBeginsAt,
+				EnrollmentEndsAt:      course.EnrollmentEndsAt,
+				MaxStudents:           course.MaxStudents,
+				EnrollmentApproval:    course.EnrollmentApproval,
+				EnrollmentAllowlist:   course.EnrollmentAllowlist,
+			},
+			Users:       []CourseArchiveUser{},
+			Sheets:      []CourseArchiveSheet{},
+			Materials:   []CourseArchiveMaterial{},
+			Exams:       []CourseArchiveExam{},
+			Groups:      []CourseArchiveGroup{},
+			Submissions: []CourseArchiveSubmission{},
+		},
+	}
+
+	roles := []string{"1", "2"}
+	if withSubmissions {
+		roles = append(roles, "0")
+	}
+	enrolled, err := stores.Course.EnrolledUsers(course.ID, roles, "%%", "%%", "%%", "%%", "%%")
+	if err != nil {
+		return err
+	}
+	for _, user := range enrolled {
+		if err := aw.addUser(user.ID); err != nil {
+			return err
+		}
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(course.ID)
+	if err != nil {
+		return err
+	}
+	for _, sheet := range sheets {
+		entry := CourseArchiveSheet{
+			ID:                  sheet.ID,
+			Name:                sheet.Name,
+			PublishAt:           sheet.PublishAt,
+			DueAt:               sheet.DueAt,
+			GradesReleaseAt:     sheet.GradesReleaseAt,
+			GradesWithheld:      sheet.GradesWithheld,
+			GradingStrategy:     sheet.GradingStrategy,
+			BlindGrading:        sheet.BlindGrading,
+			MinPercentage:       sheet.MinPercentage,
+			GradesReleaseNotify: sheet.GradesReleaseNotify,
+			Tasks:               []CourseArchiveTask{},
+		}
+		if entry.File, err = aw.addFile(fmt.Sprintf("sheets/%d", sheet.ID), helper.NewSheetFileHandle(sheet.ID)); err != nil {
+			return err
+		}
+
+		tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			taskEntry := CourseArchiveTask{
+				ID:                 task.ID,
+				Name:               task.Name,
+				MaxPoints:          task.MaxPoints,
+				PublicDockerImage:  task.PublicDockerImage,
+				PrivateDockerImage: task.PrivateDockerImage,
+				GraderID:           task.GraderID,
+				IsBonus:            task.IsBonus,
+				BonusPoints:        task.BonusPoints,
+				DoubleGrading:      task.DoubleGrading,
+				ReconcileThreshold: task.ReconcileThreshold,
+			}
+			if task.GraderID.Valid {
+				if err := aw.addUser(task.GraderID.Int64); err != nil {
+					return err
+				}
+			}
+			if taskEntry.PublicTestFile, err = aw.addFile(fmt.Sprintf("tasks/%d-public", task.ID), helper.NewPublicTestFileHandle(task.ID)); err != nil {
+				return err
+			}
+			if taskEntry.PrivateTestFile, err = aw.addFile(fmt.Sprintf("tasks/%d-private", task.ID), helper.NewPrivateTestFileHandle(task.ID)); err != nil {
+				return err
+			}
+			entry.Tasks = append(entry.Tasks, taskEntry)
+
+			if !withSubmissions {
+				continue
+			}
+
+			submissions, err := stores.Submission.SubmissionsOfTask(task.ID)
+			if err != nil {
+				return err
+			}
+			for _, submission := range submissions {
+				if err := aw.addUser(submission.UserID); err != nil {
+					return err
+				}
+
+				submissionEntry := CourseArchiveSubmission{
+					UserID: submission.UserID,
+					TaskID: task.ID,
+				}
+				if submissionEntry.File, err = aw.addFile(fmt.Sprintf("submissions/%d", submission.ID), helper.NewSubmissionFileHandle(submission.ID)); err != nil {
+					return err
+				}
+
+				if grade, err := stores.Grade.GetForSubmission(submission.ID); err == nil {
+					if err := aw.addUser(grade.TutorID); err != nil {
+						return err
+					}
+					if grade.AssignedTutorID.Valid {
+						if err := aw.addUser(grade.AssignedTutorID.Int64); err != nil {
+							return err
+						}
+					}
+					submissionEntry.Grade = &CourseArchiveGrade{
+						TutorID:               grade.TutorID,
+						AssignedTutorID:       grade.AssignedTutorID,
+						AcquiredPoints:        grade.AcquiredPoints,
+						Feedback:              grade.Feedback,
+						PublicExecutionState:  grade.PublicExecutionState,
+						PrivateExecutionState: grade.PrivateExecutionState,
+						PublicTestLog:         grade.PublicTestLog,
+						PrivateTestLog:        grade.PrivateTestLog,
+						PublicTestStatus:      grade.PublicTestStatus,
+						PrivateTestStatus:     grade.PrivateTestStatus,
+					}
+					if submissionEntry.Grade.FeedbackFile, err = aw.addFile(fmt.Sprintf("feedback/%d", grade.ID), helper.NewGradeFeedbackFileHandle(grade.ID)); err != nil {
+						return err
+					}
+				}
+
+				aw.archive.Submissions = append(aw.archive.Submissions, submissionEntry)
+			}
+		}
+
+		aw.archive.Sheets = append(aw.archive.Sheets, entry)
+	}
+
+	materials, err := stores.Material.MaterialsOfCourse(course.ID, int(authorize.ADMIN))
+	if err != nil {
+		return err
+	}
+	for _, material := range materials {
+		entry := CourseArchiveMaterial{
+			Name:         material.Name,
+			Kind:         material.Kind,
+			Filename:     material.Filename,
+			PublishAt:    material.PublishAt,
+			LectureAt:    material.LectureAt,
+			RequiredRole: material.RequiredRole,
+		}
+		if entry.File, err = aw.addFile(fmt.Sprintf("materials/%d", material.ID), helper.NewMaterialFileHandle(material.ID)); err != nil {
+			return err
+		}
+		aw.archive.Materials = append(aw.archive.Materials, entry)
+	}
+
+	exams, err := stores.Exam.ExamsOfCourse(course.ID)
+	if err != nil {
+		return err
+	}
+	for _, exam := range exams {
+		aw.archive.Exams = append(aw.archive.Exams, CourseArchiveExam{
+			Name:        exam.Name,
+			Description: exam.Description,
+			ExamTime:    exam.ExamTime,
+		})
+	}
+
+	groups, err := stores.Group.GroupsOfCourse(course.ID)
+	if err != nil {
+		return err
+	}
+	for _, group := range groups {
+		entry := CourseArchiveGroup{
+			TutorID:     group.TutorID,
+			CoTutorIDs:  []int64{},
+			Description: group.Description,
+			Capacity:    group.Capacity,
+			MemberIDs:   []int64{},
+		}
+		if err := aw.addUser(group.TutorID); err != nil {
+			return err
+		}
+
+		coTutors, err := stores.Group.GetCoTutors(group.ID)
+		if err != nil {
+			return err
+		}
+		for _, tutor := range coTutors {
+			if err := aw.addUser(tutor.ID); err != nil {
+				return err
+			}
+			entry.CoTutorIDs = append(entry.CoTutorIDs, tutor.ID)
+		}
+
+		if withSubmissions {
+			members, err := stores.Group.GetMembers(group.ID)
+			if err != nil {
+				return err
+			}
+			for _, member := range members {
+				if err := aw.addUser(member.ID); err != nil {
+					return err
+				}
+				entry.MemberIDs = append(entry.MemberIDs, member.ID)
+			}
+		}
+
+		aw.archive.Groups = append(aw.archive.Groups, entry)
+	}
+
+	manifest, err := aw.zw.Create(courseArchiveManifest)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(manifest)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(&aw.archive); err != nil {
+		return err
+	}
+
+	return aw.zw.Close()
+}
+
+// extractArchiveFile stores a file from the archive at the location of the
+// handle and records it within the written files. Empty names are skipped.
+func extractArchiveFile(files map[string]*zip.File, name string, dst *helper.FileHandle, written *fileCopies) error {
+	if name == "" {
+		return nil
+	}
+
+	file, ok := files[name]
+	if !ok {
+		return invalidArchive("file %s is missing in the archive", name)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return &courseArchiveError{err: err}
+	}
+	defer src.Close()
+
+	*written = append(*written, dst)
+	return dst.WriteFromReader(src, strings.TrimPrefix(path.Ext(name), "."))
+}
+
+// ImportCourseArchive creates a new course from a course bundle. Users are
+// matched by their email address, unknown users get an account without a
+// password. All IDs are remapped to new entries. Nothing is imported if any
+// step fails.
+func ImportCourseArchive(stores *Stores, r io.ReaderAt, size int64) (*model.Course, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, &courseArchiveError{err: err}
+	}
+
+	files := make(map[string]*zip.File)
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+
+	manifestFile, ok := files[courseArchiveManifest]
+	if !ok {
+		return nil, invalidArchive("the archive does not contain a manifest")
+	}
+	manifest, err := manifestFile.Open()
+	if err != nil {
+		return nil, &courseArchiveError{err: err}
+	}
+	archive := &CourseArchive{}
+	err = json.NewDecoder(manifest).Decode(archive)
+	manifest.Close()
+	if err != nil {
+		return nil, &courseArchiveError{err: err}
+	}
+
+	if archive.Version != CourseArchiveVersion {
+		return nil, invalidArchive("unsupported archive version %d (expected %d)", archive.Version, CourseArchiveVersion)
+	}
+
+	var course *model.Course
+	written := fileCopies{}
+
+	err = stores.Transaction(func(tx *Stores) error {
+		var err error
+		course, err = importCourseArchive(tx, archive, files, &written)
+		return err
+	})
+	if err != nil {
+		written.deleteAll()
+		return nil, err
+	}
+
+	return course, nil
+}
+
+// importCourseArchive runs all steps of ImportCourseArchive and records all
+// extracted files.
+func importCourseArchive(stores *Stores, archive *CourseArchive, files map[string]*zip.File, written *fileCopies) (*model.Course, error) {
+	course, err := stores.Course.Create(&model.Course{
+		Name:                  archive.Course.Name,
+		Description:           archive.Course.Description,
+		BeginsAt:              archive.Course.BeginsAt,
+		EndsAt:                archive.Course.EndsAt,
+		RequiredPercentage:    archive.Course.RequiredPercentage,
+		RegradeWindowDays:     archive.Course.RegradeWindowDays,
+		GroupSelfEnrollment:   archive.Course.GroupSelfEnrollment,
+		WaitlistNotification:  archive.Course.WaitlistNotification,
+		RequiredAttendances:   archive.Course.RequiredAttendances,
+		RequiredPresentations: archive.Course.RequiredPresentations,
+		GroupSwapApproval:     archive.Course.GroupSwapApproval,
+		EnrollmentKey:         archive.Course.EnrollmentKey,
+		EnrollmentBeginsAt:    archive.Course.EnrollmentBeginsAt,
+		EnrollmentEndsAt:      archive.Course.EnrollmentEndsAt,
+		MaxStudents:           archive.Course.MaxStudents,
+		EnrollmentApproval:    archive.Course.EnrollmentApproval,
+		EnrollmentAllowlist:   archive.Course.EnrollmentAllowlist,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// IDs of the archive -> IDs in this instance
+	userIDs := make(map[int64]int64)
+	taskIDs := make(map[int64]int64)
+
+	mapUser := func(id int64) (int64, error) {
+		// by definition user with id 1 is the system, which exists in every instance
+		if id == 1 {
+			return 1, nil
+		}
+		newID, ok := userIDs[id]
+		if !ok {
+			return 0, invalidArchive("user %d is missing in the archive", id)
+		}
+		return newID, nil
+	}
+
+	for _, entry := range archive.Users {
+		user, err := stores.User.FindByEmail(entry.Email)
+		if err != nil {
+			language := entry.Language
+			if language == "" {
+				language = "en"
+			}
+			user, err = stores.User.Create(&model.User{
+				FirstName:     entry.FirstName,
+				LastName:      entry.LastName,
+				Email:         entry.Email,
+				StudentNumber: entry.StudentNumber,
+				Semester:      entry.Semester,
+				Subject:       entry.Subject,
+				Language:      language,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		userIDs[entry.ID] = user.ID
+
+		if entry.Enrolled {
+			if err := stores.Course.Enroll(course.ID, user.ID, entry.Role); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, entry := range archive.Sheets {
+		sheet, err := stores.Sheet.Create(&model.Sheet{
+			Name:                entry.Name,
+			PublishAt:           entry.PublishAt,
+			DueAt:               entry.DueAt,
+			GradesReleaseAt:     entry.GradesReleaseAt,
+			GradesWithheld:      entry.GradesWithheld,
+			GradingStrategy:     entry.GradingStrategy,
+			BlindGrading:        entry.BlindGrading,
+			MinPercentage:       entry.MinPercentage,
+			GradesReleaseNotify: entry.GradesReleaseNotify,
+		}, course.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := extractArchiveFile(files, entry.File, helper.NewSheetFileHandle(sheet.ID), written); err != nil {
+			return nil, err
+		}
+
+		for _, taskEntry := range entry.Tasks {
+			task := &model.Task{
+				Name:               taskEntry.Name,
+				MaxPoints:          taskEntry.MaxPoints,
+				PublicDockerImage:  taskEntry.PublicDockerImage,
+				PrivateDockerImage: taskEntry.PrivateDockerImage,
+				IsBonus:            taskEntry.IsBonus,
+				BonusPoints:        taskEntry.BonusPoints,
+				DoubleGrading:      taskEntry.DoubleGrading,
+				ReconcileThreshold: taskEntry.ReconcileThreshold,
+			}
+			if taskEntry.GraderID.Valid {
+				graderID, err := mapUser(taskEntry.GraderID.Int64)
+				if err != nil {
+					return nil, err
+				}
+				task.GraderID = null.IntFrom(graderID)
+			}
+
+			task, err = stores.Task.Create(task, sheet.ID)
+			if err != nil {
+				return nil, err
+			}
+			taskIDs[taskEntry.ID] = task.ID
+
+			if err := extractArchiveFile(files, taskEntry.PublicTestFile, helper.NewPublicTestFileHandle(task.ID), written); err != nil {
+				return nil, err
+			}
+			if err := extractArchiveFile(files, taskEntry.PrivateTestFile, helper.NewPrivateTestFileHandle(task.ID), written); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, entry := range archive.Materials {
+		material, err := stores.Material.Create(&model.Material{
+			Name:         entry.Name,
+			Kind:         entry.Kind,
+			Filename:     entry.Filename,
+			PublishAt:    entry.PublishAt,
+			LectureAt:    entry.LectureAt,
+			RequiredRole: entry.RequiredRole,
+		}, course.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := extractArchiveFile(files, entry.File, helper.NewMaterialFileHandle(material.ID), written); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range archive.Exams {
+		if _, err := stores.Exam.Create(&model.Exam{
+			Name:        entry.Name,
+			Description: entry.Description,
+			ExamTime:    entry.ExamTime,
+			CourseID:    course.ID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range archive.Groups {
+		tutorID, err := mapUser(entry.TutorID)
+		if err != nil {
+			return nil, err
+		}
+
+		group, err := stores.Group.Create(&model.Group{
+			TutorID:     tutorID,
+			CourseID:    course.ID,
+			Description: entry.Description,
+			Capacity:    entry.Capacity,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		coTutorIDs := []int64{}
+		for _, id := range entry.CoTutorIDs {
+			coTutorID, err := mapUser(id)
+			if err != nil {
+				return nil, err
+			}
+			coTutorIDs = append(coTutorIDs, coTutorID)
+		}
+		if err := stores.Group.SetCoTutors(group.ID, coTutorIDs); err != nil {
+			return nil, err
+		}
+
+		for _, id := range entry.MemberIDs {
+			memberID, err := mapUser(id)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := stores.Group.CreateGroupEnrollmentOfUserInCourse(&model.GroupEnrollment{
+				UserID:  memberID,
+				GroupID: group.ID,
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, entry := range archive.Submissions {
+		userID, err := mapUser(entry.UserID)
+		if err != nil {
+			return nil, err
+		}
+		taskID, ok := taskIDs[entry.TaskID]
+		if !ok {
+			return nil, invalidArchive("task %d is missing in the archive", entry.TaskID)
+		}
+
+		submission, err := stores.Submission.Create(&model.Submission{
+			UserID: userID,
+			TaskID: taskID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := extractArchiveFile(files, entry.File, helper.NewSubmissionFileHandle(submission.ID), written); err != nil {
+			return nil, err
+		}
+
+		if entry.Grade == nil {
+			continue
+		}
+		tutorID, err := mapUser(entry.Grade.TutorID)
+		if err != nil {
+			return nil, err
+		}
+		assignedTutorID := null.Int{}
+		if entry.Grade.AssignedTutorID.Valid {
+			id, err := mapUser(entry.Grade.AssignedTutorID.Int64)
+			if err != nil {
+				return nil, err
+			}
+			assignedTutorID = null.IntFrom(id)
+		}
+		grade, err := stores.Grade.Create(&model.Grade{
+			SubmissionID:          submission.ID,
+			TutorID:               tutorID,
+			AssignedTutorID:       assignedTutorID,
+			AcquiredPoints:        entry.Grade.AcquiredPoints,
+			Feedback:              entry.Grade.Feedback,
+			PublicExecutionState:  entry.Grade.PublicExecutionState,
+			PrivateExecutionState: entry.Grade.PrivateExecutionState,
+			PublicTestLog:         entry.Grade.PublicTestLog,
+			PrivateTestLog:        entry.Grade.PrivateTestLog,
+			PublicTestStatus:      entry.Grade.PublicTestStatus,
+			PrivateTestStatus:     entry.Grade.PrivateTestStatus,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := extractArchiveFile(files, entry.Grade.FeedbackFile, helper.NewGradeFeedbackFileHandle(grade.ID), written); err != nil {
+			return nil, err
+		}
+	}
+
+	return course, nil
+}
@@ -0,0 +1,269 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"time"
+
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// shiftNullTime moves a nullable timestamp by the given offset.
+func shiftNullTime(t null.Time, offset time.Duration) null.Time {
+	if !t.Valid {
+		return t
+	}
+	return null.TimeFrom(t.Time.Add(offset))
+}
+
+// fileCopies keeps track of all files written during a database transaction,
+// such that they can be removed again if the transaction fails.
+type fileCopies []*helper.FileHandle
+
+// copyIfExists duplicates an uploaded file if there is one.
+func (c *fileCopies) copyIfExists(src *helper.FileHandle, dst *helper.FileHandle) error {
+	if !src.Exists() {
+		return nil
+	}
+	*c = append(*c, dst)
+	return src.CopyTo(dst)
+}
+
+// deleteAll removes all written files.
+func (c fileCopies) deleteAll() {
+	for _, hnd := range c {
+		hnd.Delete()
+	}
+}
+
+// CloneCourse deep-copies a course into a new course with the given name. All
+// dates are shifted by the given offset. Sheets, tasks, materials, exams,
+// feedback snippets and groups (without members) with their weekly sessions
+// are copied together with their files. Cancelled session dates are not copied.
+// Tutors and admins keep their role in the new course, students are not
+// enrolled. Nothing is copied if any step fails.
+func CloneCourse(stores *Stores, source *model.Course, name string, offset time.Duration) (*model.Course, error) {
+	var newCourse *model.Course
+	files := fileCopies{}
+
+	err := stores.Transaction(func(tx *Stores) error {
+		var err error
+		newCourse, err = cloneCourse(tx, source, name, offset, &files)
+		return err
+	})
+	if err != nil {
+		files.deleteAll()
+		return nil, err
+	}
+
+	return newCourse, nil
+}
+
+// cloneCourse runs all steps of CloneCourse and records all copied files.
+func cloneCourse(stores *Stores, source *model.Course, name string, offset time.Duration, files *fileCopies) (*model.Course, error) {
+
+	course := *source
+	course.ID = 0
+	course.CreatedAt = time.Time{}
+	course.UpdatedAt = time.Time{}
+	course.Name = name
+	course.BeginsAt = source.BeginsAt.Add(offset)
+	course.EndsAt = source.EndsAt.Add(offset)
+	course.EnrollmentBeginsAt = shiftNullTime(source.EnrollmentBeginsAt, offset)
+	course.EnrollmentEndsAt = shiftNullTime(source.EnrollmentEndsAt, offset)
+
+	newCourse, err := stores.Course.Create(&course)
+	if err != nil {
+		return nil, err
+	}
+
+	staff, err := stores.Course.EnrolledUsers(source.ID,
+		[]string{"1", "2"}, "%%", "%%", "%%", "%%", "%%",
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range staff {
+		if err := stores.Course.Enroll(newCourse.ID, user.ID, user.Role); err != nil {
+			return nil, err
+		}
+	}
+
+	// ids of the copied tasks by the ids of their originals
+	newTaskIDs := make(map[int64]int64)
+
+	sheets, err := stores.Sheet.SheetsOfCourse(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sheet := range sheets {
+		tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		sourceSheetID := sheet.ID
+		sheet.ID = 0
+		sheet.CreatedAt = time.Time{}
+		sheet.UpdatedAt = time.Time{}
+		sheet.PublishAt = sheet.PublishAt.Add(offset)
+		sheet.DueAt = sheet.DueAt.Add(offset)
+		sheet.GradesReleaseAt = shiftNullTime(sheet.GradesReleaseAt, offset)
+
+		newSheet, err := stores.Sheet.Create(&sheet, newCourse.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := files.copyIfExists(helper.NewSheetFileHandle(sourceSheetID),
+			helper.NewSheetFileHandle(newSheet.ID)); err != nil {
+			return nil, err
+		}
+
+		for _, task := range tasks {
+			sourceTaskID := task.ID
+			task.ID = 0
+			task.CreatedAt = time.Time{}
+			task.UpdatedAt = time.Time{}
+			// the grader of the last semester is most likely gone
+			task.GraderID = null.Int{}
+
+			newTask, err := stores.Task.Create(&task, newSheet.ID)
+			if err != nil {
+				return nil, err
+			}
+			newTaskIDs[sourceTaskID] = newTask.ID
+			if err := files.copyIfExists(helper.NewPublicTestFileHandle(sourceTaskID),
+				helper.NewPublicTestFileHandle(newTask.ID)); err != nil {
+				return nil, err
+			}
+			if err := files.copyIfExists(helper.NewPrivateTestFileHandle(sourceTaskID),
+				helper.NewPrivateTestFileHandle(newTask.ID)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	materials, err := stores.Material.MaterialsOfCourse(source.ID, int(authorize.ADMIN))
+	if err != nil {
+		return nil, err
+	}
+	for _, material := range materials {
+		sourceMaterialID := material.ID
+		material.ID = 0
+		material.CreatedAt = time.Time{}
+		material.UpdatedAt = time.Time{}
+		material.PublishAt = material.PublishAt.Add(offset)
+		material.LectureAt = material.LectureAt.Add(offset)
+
+		newMaterial, err := stores.Material.Create(&material, newCourse.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := files.copyIfExists(helper.NewMaterialFileHandle(sourceMaterialID),
+			helper.NewMaterialFileHandle(newMaterial.ID)); err != nil {
+			return nil, err
+		}
+	}
+
+	exams, err := stores.Exam.ExamsOfCourse(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, exam := range exams {
+		exam.ID = 0
+		exam.CreatedAt = time.Time{}
+		exam.UpdatedAt = time.Time{}
+		exam.CourseID = newCourse.ID
+		exam.ExamTime = exam.ExamTime.Add(offset)
+
+		if _, err := stores.Exam.Create(&exam); err != nil {
+			return nil, err
+		}
+	}
+
+	groups, err := stores.Group.GroupsOfCourse(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		coTutors, err := stores.Group.GetCoTutors(group.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		newGroup, err := stores.Group.Create(&model.Group{
+			TutorID:     group.TutorID,
+			CourseID:    newCourse.ID,
+			Description: group.Description,
+			Capacity:    group.Capacity,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		coTutorIDs := []int64{}
+		for _, tutor := range coTutors {
+			coTutorIDs = append(coTutorIDs, tutor.ID)
+		}
+		if err := stores.Group.SetCoTutors(newGroup.ID, coTutorIDs); err != nil {
+			return nil, err
+		}
+
+		sessions, err := stores.Session.SessionsOfGroup(group.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range sessions {
+			session.ID = 0
+			session.CreatedAt = time.Time{}
+			session.UpdatedAt = time.Time{}
+			session.GroupID = newGroup.ID
+			session.FirstDate = session.FirstDate.Add(offset)
+			session.LastDate = session.LastDate.Add(offset)
+
+			if _, err := stores.Session.Create(&session); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	snippets, err := stores.Snippet.GetForCourse(source.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, snippet := range snippets {
+		snippet.ID = 0
+		snippet.CreatedAt = time.Time{}
+		snippet.UpdatedAt = time.Time{}
+		snippet.CourseID = newCourse.ID
+		if snippet.TaskID.Valid {
+			snippet.TaskID = null.IntFrom(newTaskIDs[snippet.TaskID.Int64])
+		}
+
+		if _, err := stores.Snippet.Create(&snippet); err != nil {
+			return nil, err
+		}
+	}
+
+	return newCourse, nil
+}
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // CourseRequest is the request payload for course management.
@@ -34,6 +35,22 @@ type CourseRequest struct {
 	BeginsAt           time.Time `json:"begins_at" example:"auto"`
 	EndsAt             time.Time `json:"ends_at" example:"auto"`
 	RequiredPercentage int       `json:"required_percentage" example:"80"`
+	RegradeWindowDays  int       `json:"regrade_window_days" example:"7"`
+
+	GroupSelfEnrollment  bool `json:"group_self_enrollment" example:"false"`
+	WaitlistNotification bool `json:"waitlist_notification" example:"true"`
+
+	RequiredAttendances   int `json:"required_attendances" example:"10"`
+	RequiredPresentations int `json:"required_presentations" example:"2"`
+
+	GroupSwapApproval bool `json:"group_swap_approval" example:"false"`
+
+	EnrollmentKey       string    `json:"enrollment_key" example:"secret"`
+	EnrollmentBeginsAt  null.Time `json:"enrollment_begins_at" example:"auto"`
+	EnrollmentEndsAt    null.Time `json:"enrollment_ends_at" example:"auto"`
+	MaxStudents         int       `json:"max_students" example:"300" minval:"0"`
+	EnrollmentApproval  bool      `json:"enrollment_approval" example:"false"`
+	EnrollmentAllowlist []string  `json:"enrollment_allowlist" example:"@uni-tuebingen.de"`
 }
 
 // Bind preprocesses a CourseRequest.
@@ -52,6 +69,11 @@ func (body *CourseRequest) Validate() error {
 		return errors.New("ends_at should be later than begins_at")
 	}
 
+	if body.EnrollmentBeginsAt.Valid && body.EnrollmentEndsAt.Valid &&
+		body.EnrollmentEndsAt.Time.Before(body.EnrollmentBeginsAt.Time) {
+		return errors.New("enrollment_ends_at should be later than enrollment_begins_at")
+	}
+
 	return validation.ValidateStruct(body,
 		validation.Field(
 			&body.Name,
@@ -73,6 +95,22 @@ func (body *CourseRequest) Validate() error {
 			&body.RequiredPercentage,
 			validation.Min(0),
 		),
+		validation.Field(
+			&body.RegradeWindowDays,
+			validation.Min(0),
+		),
+		validation.Field(
+			&body.RequiredAttendances,
+			validation.Min(0),
+		),
+		validation.Field(
+			&body.RequiredPresentations,
+			validation.Min(0),
+		),
+		validation.Field(
+			&body.MaxStudents,
+			validation.Min(0),
+		),
 	)
 }
 
@@ -83,3 +121,52 @@ type ChangeRoleInCourseRequest struct {
 func (body *ChangeRoleInCourseRequest) Bind(r *http.Request) error {
 	return nil
 }
+
+// CourseCloneRequest is the request payload to copy a course into a new
+// semester. Either "begins_at" or "offset_days" defines how dates are shifted.
+type CourseCloneRequest struct {
+	Name       string     `json:"name" example:"Info 2 (summer term)"`
+	BeginsAt   *time.Time `json:"begins_at" example:"auto"`
+	OffsetDays int        `json:"offset_days" example:"182"`
+}
+
+// Bind preprocesses a CourseCloneRequest.
+func (body *CourseCloneRequest) Bind(r *http.Request) error {
+
+	if body == nil {
+		return errors.New("missing \"clone\" data")
+	}
+
+	return body.Validate()
+}
+
+func (body *CourseCloneRequest) Validate() error {
+	if body.BeginsAt != nil && body.OffsetDays != 0 {
+		return errors.New("use either begins_at or offset_days")
+	}
+
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Name,
+			validation.Required,
+		),
+	)
+}
+
+// Offset computes how far all dates of the given course must be moved.
+func (body *CourseCloneRequest) Offset(beginsAt time.Time) time.Duration {
+	if body.BeginsAt != nil {
+		return body.BeginsAt.Sub(beginsAt)
+	}
+	return time.Duration(body.OffsetDays) * 24 * time.Hour
+}
+
+// EnrollRequest is the (optional) request payload to enroll into a course.
+type EnrollRequest struct {
+	EnrollmentKey string `json:"enrollment_key" example:"secret"`
+}
+
+// Bind preprocesses an EnrollRequest.
+func (body *EnrollRequest) Bind(r *http.Request) error {
+	return nil
+}
@@ -36,6 +36,24 @@ type CourseResponse struct {
 	BeginsAt           time.Time `json:"begins_at" example:"auto"`
 	EndsAt             time.Time `json:"ends_at" example:"auto"`
 	RequiredPercentage int       `json:"required_percentage" example:"80"`
+	RegradeWindowDays  int       `json:"regrade_window_days" example:"7"`
+
+	GroupSelfEnrollment  bool `json:"group_self_enrollment" example:"false"`
+	WaitlistNotification bool `json:"waitlist_notification" example:"true"`
+
+	RequiredAttendances   int `json:"required_attendances" example:"10"`
+	RequiredPresentations int `json:"required_presentations" example:"2"`
+
+	GroupSwapApproval bool `json:"group_swap_approval" example:"false"`
+
+	EnrollmentBeginsAt    null.Time `json:"enrollment_begins_at" example:"auto"`
+	EnrollmentEndsAt      null.Time `json:"enrollment_ends_at" example:"auto"`
+	MaxStudents           int       `json:"max_students" example:"300"`
+	EnrollmentApproval    bool      `json:"enrollment_approval" example:"false"`
+	EnrollmentKeyRequired bool      `json:"enrollment_key_required" example:"true"`
+	// only visible for admins of the course
+	EnrollmentKey       string   `json:"enrollment_key,omitempty" example:"secret"`
+	EnrollmentAllowlist []string `json:"enrollment_allowlist,omitempty" example:"@uni-tuebingen.de"`
 }
 
 // Render post-processes a CourseResponse.
@@ -52,9 +70,33 @@ func (rs *CourseResource) newCourseResponse(p *model.Course) *CourseResponse {
 		BeginsAt:           p.BeginsAt,
 		EndsAt:             p.EndsAt,
 		RequiredPercentage: p.RequiredPercentage,
+		RegradeWindowDays:  p.RegradeWindowDays,
+
+		GroupSelfEnrollment:  p.GroupSelfEnrollment,
+		WaitlistNotification: p.WaitlistNotification,
+
+		RequiredAttendances:   p.RequiredAttendances,
+		RequiredPresentations: p.RequiredPresentations,
+
+		GroupSwapApproval: p.GroupSwapApproval,
+
+		EnrollmentBeginsAt:    p.EnrollmentBeginsAt,
+		EnrollmentEndsAt:      p.EnrollmentEndsAt,
+		MaxStudents:           p.MaxStudents,
+		EnrollmentApproval:    p.EnrollmentApproval,
+		EnrollmentKeyRequired: p.EnrollmentKey != "",
 	}
 }
 
+// newCourseAdminResponse creates a response including the enrollment settings
+// which are hidden from students.
+func (rs *CourseResource) newCourseAdminResponse(p *model.Course) *CourseResponse {
+	response := rs.newCourseResponse(p)
+	response.EnrollmentKey = p.EnrollmentKey
+	response.EnrollmentAllowlist = p.EnrollmentAllowlist
+	return response
+}
+
 // newCourseListResponse creates a response from a list of course models.
 func (rs *CourseResource) newCourseListResponse(courses []model.Course) []render.Renderer {
 	list := []render.Renderer{}
@@ -70,6 +112,10 @@ type SheetPointsResponse struct {
 	AchievablePoints int `json:"achievable_points" example:"42"`
 	MaxPoints        int `json:"max_points" example:"90"`
 	SheetID          int `json:"sheet_id" example:"2"`
+	// the exam admission requires this percentage of the max points of the sheet
+	MinPercentage int `json:"min_percentage" example:"30"`
+	// whether the student missed the min percentage of a sheet with released grades
+	MissedMinPercentage bool `json:"missed_min_percentage" example:"false"`
 }
 
 // Render postprocesses a SheetPointsResponse before marshalling to JSON.
@@ -86,16 +132,6 @@ func newSheetPointsResponse(p *model.SheetPoints) *SheetPointsResponse {
 	}
 }
 
-// newCourseListResponse creates a response from a list of course models.
-func newSheetPointsListResponse(collection []model.SheetPoints) []render.Renderer {
-	list := []render.Renderer{}
-	for k := range collection {
-		list = append(list, newSheetPointsResponse(&collection[k]))
-	}
-
-	return list
-}
-
 // .............................................................................
 type GroupBidsResponse struct {
 	ID      int64 `json:"id" example:"512"`
@@ -190,3 +226,135 @@ func newEnrollmentListResponse(enrollments []model.UserCourse) []render.Renderer
 
 	return list
 }
+
+// PseudonymResponse is the response payload to resolve the pseudonym of a
+// student used during blind grading.
+type PseudonymResponse struct {
+	Pseudonym string `json:"pseudonym" example:"anon-3f9a0c12d4"`
+	User      *struct {
+		ID            int64  `json:"id" example:"13"`
+		FirstName     string `json:"first_name" example:"Max"`
+		LastName      string `json:"last_name" example:"Mustermensch"`
+		Email         string `json:"email" example:"test@uni-tuebingen.de"`
+		StudentNumber string `json:"student_number" example:"0816"`
+	} `json:"user"`
+}
+
+// Render post-processes a PseudonymResponse.
+func (body *PseudonymResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newPseudonymResponse creates a response from an enrollment.
+func newPseudonymResponse(p *model.UserCourse, courseID int64) *PseudonymResponse {
+	user := struct {
+		ID            int64  `json:"id" example:"13"`
+		FirstName     string `json:"first_name" example:"Max"`
+		LastName      string `json:"last_name" example:"Mustermensch"`
+		Email         string `json:"email" example:"test@uni-tuebingen.de"`
+		StudentNumber string `json:"student_number" example:"0816"`
+	}{
+		ID:            p.ID,
+		FirstName:     p.FirstName,
+		LastName:      p.LastName,
+		Email:         p.Email,
+		StudentNumber: p.StudentNumber,
+	}
+
+	return &PseudonymResponse{
+		Pseudonym: Pseudonym(courseID, p.ID),
+		User:      &user,
+	}
+}
+
+// newPseudonymListResponse creates a response from a list of enrollments.
+func newPseudonymListResponse(enrollments []model.UserCourse, courseID int64) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range enrollments {
+		list = append(list, newPseudonymResponse(&enrollments[k], courseID))
+	}
+
+	return list
+}
+
+// EnrollmentRequestResponse is a user waiting for the approval of the
+// enrollment.
+type EnrollmentRequestResponse struct {
+	CreatedAt         time.Time `json:"created_at" example:"auto"`
+	UserID            int64     `json:"user_id" example:"112"`
+	UserFirstName     string    `json:"user_first_name" example:"Max"`
+	UserLastName      string    `json:"user_last_name" example:"Mustermensch"`
+	UserEmail         string    `json:"user_email" example:"test@uni-tuebingen.de"`
+	UserStudentNumber string    `json:"user_student_number" example:"0816"`
+}
+
+// Render post-processes an EnrollmentRequestResponse.
+func (body *EnrollmentRequestResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newEnrollmentRequestResponse creates a response from an EnrollmentRequest model.
+func newEnrollmentRequestResponse(p *model.EnrollmentRequest) *EnrollmentRequestResponse {
+	return &EnrollmentRequestResponse{
+		CreatedAt:         p.CreatedAt,
+		UserID:            p.UserID,
+		UserFirstName:     p.UserFirstName,
+		UserLastName:      p.UserLastName,
+		UserEmail:         p.UserEmail,
+		UserStudentNumber: p.UserStudentNumber,
+	}
+}
+
+// newEnrollmentRequestListResponse creates a response from a list of EnrollmentRequest models.
+func newEnrollmentRequestListResponse(requests []model.EnrollmentRequest) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range requests {
+		list = append(list, newEnrollmentRequestResponse(&requests[k]))
+	}
+	return list
+}
+
+// UserImportEntryResponse is the response payload describing how a line of a
+// user import is applied.
+type UserImportEntryResponse struct {
+	Line          int    `json:"line" example:"2"`
+	Action        string `json:"action" example:"create"`
+	UserID        int64  `json:"user_id" example:"112"`
+	FirstName     string `json:"first_name" example:"Max"`
+	LastName      string `json:"last_name" example:"Mustermensch"`
+	Email         string `json:"email" example:"test@uni-tuebingen.de"`
+	StudentNumber string `json:"student_number" example:"0816"`
+	Role          int    `json:"role" example:"0"`
+	GroupID       int64  `json:"group_id" example:"1"`
+	Conflict      string `json:"conflict" example:"email duplicates line 2"`
+}
+
+// Render post-processes a UserImportEntryResponse.
+func (body *UserImportEntryResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newUserImportEntryResponse creates a response from a planned import of a line.
+func newUserImportEntryResponse(p *UserImportEntry) *UserImportEntryResponse {
+	return &UserImportEntryResponse{
+		Line:          p.Line,
+		Action:        p.Action,
+		UserID:        p.UserID,
+		FirstName:     p.FirstName,
+		LastName:      p.LastName,
+		Email:         p.Email,
+		StudentNumber: p.StudentNumber,
+		Role:          int(p.Role),
+		GroupID:       p.GroupID,
+		Conflict:      p.Conflict,
+	}
+}
+
+// newUserImportEntryListResponse creates a response from a list of planned imports.
+func newUserImportEntryListResponse(entries []UserImportEntry) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range entries {
+		list = append(list, newUserImportEntryResponse(&entries[k]))
+	}
+	return list
+}
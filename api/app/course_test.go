@@ -20,15 +20,23 @@
 package app
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/franela/goblin"
 	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/email"
 	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
 )
 
 func DBGetInt(tape *Tape, stmt string, param1 int64) (int, error) {
@@ -591,6 +599,533 @@ func TestCourse(t *testing.T) {
 			g.Assert(w.Code).Equal(http.StatusForbidden)
 		})
 
+		g.It("Should clone a course into a new semester", func() {
+			source, err := stores.Course.Get(1)
+			g.Assert(err).Equal(nil)
+
+			sheetsBefore, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			materialsBefore, err := stores.Material.MaterialsOfCourse(1, int(authorize.ADMIN))
+			g.Assert(err).Equal(nil)
+			examsBefore, err := stores.Exam.ExamsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			groupsBefore, err := stores.Group.GroupsOfCourse(1)
+			g.Assert(err).Equal(nil)
+
+			firstDate := time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)
+			_, err = stores.Session.Create(&model.GroupSession{
+				GroupID:   groupsBefore[0].ID,
+				Weekday:   1,
+				StartTime: "10:00",
+				EndTime:   "12:00",
+				Room:      "E1 3",
+				FirstDate: firstDate,
+				LastDate:  firstDate.Add(12 * 7 * 24 * time.Hour),
+			})
+			g.Assert(err).Equal(nil)
+
+			tasksOfFirstSheet, err := stores.Task.TasksOfSheet(sheetsBefore[0].ID)
+			g.Assert(err).Equal(nil)
+			_, err = stores.Snippet.Create(&model.FeedbackSnippet{
+				CourseID: 1,
+				TaskID:   null.IntFrom(tasksOfFirstSheet[0].ID),
+				Text:     "missing null check",
+			})
+			g.Assert(err).Equal(nil)
+
+			hnd := helper.NewSheetFileHandle(sheetsBefore[0].ID)
+			src := fmt.Sprintf("%s/empty.zip", configuration.Configuration.Server.Debugging.Fixtures)
+			_, err = copyFile(src, hnd.Path())
+			g.Assert(err).Equal(nil)
+			defer hnd.Delete()
+
+			entrySent := H{"name": "Info 2 (next term)", "offset_days": 7}
+
+			w := tape.Post("/api/v1/courses/1/clone", entrySent, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/clone", H{"name": "x", "offset_days": 7, "begins_at": time.Now()}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/clone", entrySent, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			courseReturn := &CourseResponse{}
+			err = json.NewDecoder(w.Body).Decode(&courseReturn)
+			g.Assert(err).Equal(nil)
+			g.Assert(courseReturn.Name).Equal("Info 2 (next term)")
+			g.Assert(courseReturn.BeginsAt.Equal(source.BeginsAt.Add(7 * 24 * time.Hour))).IsTrue()
+
+			sheetsAfter, err := stores.Sheet.SheetsOfCourse(courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(sheetsAfter)).Equal(len(sheetsBefore))
+			for k := range sheetsAfter {
+				g.Assert(sheetsAfter[k].DueAt.Equal(sheetsBefore[k].DueAt.Add(7 * 24 * time.Hour))).IsTrue()
+
+				tasksBefore, err := stores.Task.TasksOfSheet(sheetsBefore[k].ID)
+				g.Assert(err).Equal(nil)
+				tasksAfter, err := stores.Task.TasksOfSheet(sheetsAfter[k].ID)
+				g.Assert(err).Equal(nil)
+				g.Assert(len(tasksAfter)).Equal(len(tasksBefore))
+			}
+
+			copied := helper.NewSheetFileHandle(sheetsAfter[0].ID)
+			g.Assert(copied.Exists()).IsTrue()
+			defer copied.Delete()
+
+			materialsAfter, err := stores.Material.MaterialsOfCourse(courseReturn.ID, int(authorize.ADMIN))
+			g.Assert(err).Equal(nil)
+			g.Assert(len(materialsAfter)).Equal(len(materialsBefore))
+
+			examsAfter, err := stores.Exam.ExamsOfCourse(courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(examsAfter)).Equal(len(examsBefore))
+
+			groupsAfter, err := stores.Group.GroupsOfCourse(courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(groupsAfter)).Equal(len(groupsBefore))
+			for _, group := range groupsAfter {
+				members, err := stores.Group.GetMembers(group.ID)
+				g.Assert(err).Equal(nil)
+				g.Assert(len(members)).Equal(0)
+			}
+
+			sessionsAfter := []model.GroupSession{}
+			for _, group := range groupsAfter {
+				sessions, err := stores.Session.SessionsOfGroup(group.ID)
+				g.Assert(err).Equal(nil)
+				sessionsAfter = append(sessionsAfter, sessions...)
+			}
+			g.Assert(len(sessionsAfter)).Equal(1)
+			g.Assert(sessionsAfter[0].FirstDate.Equal(firstDate.Add(7 * 24 * time.Hour))).IsTrue()
+
+			tasksOfCopiedSheet, err := stores.Task.TasksOfSheet(sheetsAfter[0].ID)
+			g.Assert(err).Equal(nil)
+			snippetsAfter, err := stores.Snippet.GetForCourse(courseReturn.ID, 0)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(snippetsAfter)).Equal(1)
+			g.Assert(snippetsAfter[0].TaskID).Equal(null.IntFrom(tasksOfCopiedSheet[0].ID))
+
+			// staff is enrolled, students are not
+			role, err := stores.Course.RoleInCourse(2, courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(role).Equal(authorize.TUTOR)
+			role, err = stores.Course.RoleInCourse(112, courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(role).Equal(authorize.NOCOURSEROLE)
+		})
+
+		g.It("Should not clone anything if a step fails", func() {
+			coursesBefore, err := stores.Course.GetAll()
+			g.Assert(err).Equal(nil)
+
+			sheetsBefore, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+
+			hnd := helper.NewSheetFileHandle(sheetsBefore[0].ID)
+			src := fmt.Sprintf("%s/empty.zip", configuration.Configuration.Server.Debugging.Fixtures)
+			_, err = copyFile(src, hnd.Path())
+			g.Assert(err).Equal(nil)
+			defer hnd.Delete()
+
+			// groups are copied after all files
+			_, err = tape.DB.Exec(`
+CREATE FUNCTION fail_insert() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'failed on purpose';
+END $$ LANGUAGE plpgsql;
+CREATE TRIGGER fail_groups BEFORE INSERT ON groups FOR EACH ROW EXECUTE PROCEDURE fail_insert();`)
+			g.Assert(err).Equal(nil)
+			defer tape.DB.Exec("DROP TRIGGER fail_groups ON groups; DROP FUNCTION fail_insert();")
+
+			lastSheetID := int64(0)
+			err = tape.DB.Get(&lastSheetID, "SELECT last_value FROM sheets_id_seq")
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/clone", H{"name": "Info 2 (next term)", "offset_days": 7}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusInternalServerError)
+
+			coursesAfter, err := stores.Course.GetAll()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(coursesAfter)).Equal(len(coursesBefore))
+
+			// the file of the first cloned sheet has been removed again
+			g.Assert(helper.NewSheetFileHandle(lastSheetID + 1).Exists()).IsFalse()
+		})
+
+		g.It("Should export and import a course archive", func() {
+			// an ungraded grade with an assigned tutor and a feedback file
+			grade, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			grade.TutorID = 1
+			grade.AssignedTutorID = null.IntFrom(2)
+			g.Assert(stores.Grade.Update(grade)).Equal(nil)
+
+			feedbackFile := helper.NewGradeFeedbackFileHandle(grade.ID)
+			_, err = copyFile(fmt.Sprintf("%s/empty.pdf", configuration.Configuration.Server.Debugging.Fixtures), feedbackFile.Path())
+			g.Assert(err).Equal(nil)
+			defer feedbackFile.Delete()
+
+			ungradedBefore, err := DBGetInt(tape, `
+SELECT count(*) FROM grades g
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE sc.course_id = $1 AND g.tutor_id = 1`, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(ungradedBefore > 0).IsTrue()
+
+			w := tape.Get("/api/v1/courses/1/archive?submissions=true&anonymize=true", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/archive?submissions=true&anonymize=true", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(w.Header().Get("Content-Type")).Equal("application/zip")
+
+			body := w.Body.Bytes()
+			zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+			g.Assert(err).Equal(nil)
+
+			archive := &CourseArchive{}
+			for _, file := range zr.File {
+				if file.Name == "manifest.json" {
+					rc, err := file.Open()
+					g.Assert(err).Equal(nil)
+					err = json.NewDecoder(rc).Decode(archive)
+					g.Assert(err).Equal(nil)
+					rc.Close()
+				}
+			}
+			g.Assert(archive.Version).Equal(CourseArchiveVersion)
+			g.Assert(archive.Anonymized).IsTrue()
+
+			sheetsBefore, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(archive.Sheets)).Equal(len(sheetsBefore))
+			g.Assert(len(archive.Submissions) > 0).IsTrue()
+
+			student, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+			for _, user := range archive.Users {
+				g.Assert(user.Email != student.Email).IsTrue()
+				// the system exists in every instance
+				g.Assert(user.ID != int64(1)).IsTrue()
+			}
+
+			feedbackFiles := 0
+			for _, submission := range archive.Submissions {
+				if submission.Grade != nil && submission.Grade.FeedbackFile != "" {
+					feedbackFiles++
+				}
+			}
+			g.Assert(feedbackFiles > 0).IsTrue()
+
+			path := "/tmp/infomark-course-archive.zip"
+			err = ioutil.WriteFile(path, body, 0644)
+			g.Assert(err).Equal(nil)
+
+			w, err = tape.Upload("/api/v1/courses/import", path, "application/zip", tutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w, err = tape.Upload("/api/v1/courses/import", path, "application/zip", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			courseReturn := &CourseResponse{}
+			err = json.NewDecoder(w.Body).Decode(&courseReturn)
+			g.Assert(err).Equal(nil)
+			g.Assert(courseReturn.ID != int64(1)).IsTrue()
+
+			sheetsAfter, err := stores.Sheet.SheetsOfCourse(courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(sheetsAfter)).Equal(len(sheetsBefore))
+
+			submissions, err := DBGetInt(tape, `
+SELECT count(*) FROM submissions s
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE sc.course_id = $1`, courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(submissions).Equal(len(archive.Submissions))
+
+			// ungraded grades stay ungraded
+			ungradedAfter, err := DBGetInt(tape, `
+SELECT count(*) FROM grades g
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE sc.course_id = $1 AND g.tutor_id = 1`, courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(ungradedAfter).Equal(ungradedBefore)
+
+			importedGradeIDs := []int64{}
+			err = tape.DB.Select(&importedGradeIDs, `
+SELECT g.id FROM grades g
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE sc.course_id = $1 AND g.assigned_tutor_id = 2`, courseReturn.ID)
+			g.Assert(err).Equal(nil)
+
+			importedFeedbackFiles := 0
+			for _, id := range importedGradeIDs {
+				hnd := helper.NewGradeFeedbackFileHandle(id)
+				if hnd.Exists() {
+					importedFeedbackFiles++
+					defer hnd.Delete()
+				}
+			}
+			g.Assert(importedFeedbackFiles).Equal(feedbackFiles)
+
+			// anonymized students are not matched to the real accounts
+			role, err := stores.Course.RoleInCourse(112, courseReturn.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(role).Equal(authorize.NOCOURSEROLE)
+		})
+
+		g.It("Should not import anything from an invalid course archive", func() {
+			coursesBefore, err := stores.Course.GetAll()
+			g.Assert(err).Equal(nil)
+
+			// the tutor of the group is missing in the archive
+			archive := &CourseArchive{
+				Version: CourseArchiveVersion,
+				Course:  CourseArchiveCourse{Name: "Broken", BeginsAt: time.Now(), EndsAt: time.Now()},
+				Users: []CourseArchiveUser{
+					{ID: 1, Enrolled: true, Role: 0, FirstName: "New", LastName: "Student", Email: "new-student@uni-tuebingen.de"},
+				},
+				Groups: []CourseArchiveGroup{
+					{TutorID: 2, Description: "Group without tutor"},
+				},
+			}
+
+			buf := &bytes.Buffer{}
+			zw := zip.NewWriter(buf)
+			manifest, err := zw.Create("manifest.json")
+			g.Assert(err).Equal(nil)
+			g.Assert(json.NewEncoder(manifest).Encode(archive)).Equal(nil)
+			g.Assert(zw.Close()).Equal(nil)
+
+			path := "/tmp/infomark-broken-course-archive.zip"
+			err = ioutil.WriteFile(path, buf.Bytes(), 0644)
+			g.Assert(err).Equal(nil)
+			defer os.Remove(path)
+
+			w, err := tape.Upload("/api/v1/courses/import", path, "application/zip", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			coursesAfter, err := stores.Course.GetAll()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(coursesAfter)).Equal(len(coursesBefore))
+
+			// no orphaned accounts are left behind
+			_, err = stores.User.FindByEmail("new-student@uni-tuebingen.de")
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.It("Should enforce the enrollment settings of a course", func() {
+			student, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+
+			_, err = tape.DB.Exec(`DELETE FROM user_course WHERE course_id = 1 AND user_id = 112`)
+			g.Assert(err).Equal(nil)
+
+			// enrollment key
+			_, err = tape.DB.Exec(`UPDATE courses SET enrollment_key = 'secret' WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Get("/api/v1/courses/1", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			courseReturn := &CourseResponse{}
+			err = json.NewDecoder(w.Body).Decode(&courseReturn)
+			g.Assert(err).Equal(nil)
+			g.Assert(courseReturn.EnrollmentKeyRequired).IsTrue()
+			g.Assert(courseReturn.EnrollmentKey).Equal("secret")
+
+			w = tape.Post("/api/v1/courses/1/enrollments", helper.H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			w = tape.Post("/api/v1/courses/1/enrollments", helper.H{"enrollment_key": "guess"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// enrollment window
+			_, err = tape.DB.Exec(`UPDATE courses SET enrollment_ends_at = now() - interval '1 day' WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+			w = tape.Post("/api/v1/courses/1/enrollments", helper.H{"enrollment_key": "secret"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			_, err = tape.DB.Exec(`UPDATE courses SET enrollment_ends_at = NULL WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+
+			// allowlist
+			_, err = tape.DB.Exec(`UPDATE courses SET enrollment_allowlist = '{"@nowhere.invalid"}' WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+			w = tape.Post("/api/v1/courses/1/enrollments", helper.H{"enrollment_key": "secret"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			_, err = tape.DB.Exec(`UPDATE courses SET enrollment_allowlist = $1 WHERE id = 1`,
+				fmt.Sprintf("{\"@nowhere.invalid\",\"%s\"}", student.StudentNumber))
+			g.Assert(err).Equal(nil)
+
+			// maximum number of students
+			_, err = tape.DB.Exec(`UPDATE courses SET max_students = 1 WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+			w = tape.Post("/api/v1/courses/1/enrollments", helper.H{"enrollment_key": "secret"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			_, err = tape.DB.Exec(`UPDATE courses SET max_students = 0 WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+
+			// approval queue
+			_, err = tape.DB.Exec(`UPDATE courses SET enrollment_approval = true WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+			w = tape.Post("/api/v1/courses/1/enrollments", helper.H{"enrollment_key": "secret"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusAccepted)
+
+			role, err := stores.Course.RoleInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(role).Equal(authorize.NOCOURSEROLE)
+
+			w = tape.Get("/api/v1/courses/1/enrollment_requests", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/enrollment_requests", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			requests := []EnrollmentRequestResponse{}
+			err = json.NewDecoder(w.Body).Decode(&requests)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(requests)).Equal(1)
+			g.Assert(requests[0].UserID).Equal(int64(112))
+
+			w = tape.Post("/api/v1/courses/1/enrollment_requests/112/approve", helper.H{}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			role, err = stores.Course.RoleInCourse(112, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(role).Equal(authorize.STUDENT)
+
+			w = tape.Delete("/api/v1/courses/1/enrollment_requests/112", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			// students never see the enrollment key
+			w = tape.Get("/api/v1/courses/1", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			courseReturn = &CourseResponse{}
+			err = json.NewDecoder(w.Body).Decode(&courseReturn)
+			g.Assert(err).Equal(nil)
+			g.Assert(courseReturn.EnrollmentKey).Equal("")
+		})
+
+		g.It("Should import and enroll users from csv", func() {
+			student, err := stores.User.Get(112)
+			g.Assert(err).Equal(nil)
+
+			filename := "/tmp/infomark-user-import.csv"
+			defer os.Remove(filename)
+
+			// duplicate email
+			content := "name,email,student_number,subject,semester,role,group\n" +
+				"Ada Lovelace,ada@uni-tuebingen.de,4711,informatics,1,student,2\n" +
+				"Ada King,Ada@uni-tuebingen.de,4712,informatics,1,student,\n"
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			w, err := tape.Upload("/api/v1/courses/1/enrollments/import?dry_run=true", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			entriesActual := []UserImportEntryResponse{}
+			err = json.NewDecoder(w.Body).Decode(&entriesActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(entriesActual)).Equal(2)
+			g.Assert(entriesActual[0].Conflict).Equal("")
+			g.Assert(entriesActual[1].Conflict).Equal("email duplicates line 2")
+
+			content = "name,email,student_number,subject,semester,role,group\n" +
+				"Ada Lovelace,ada@uni-tuebingen.de,4711,informatics,1,student,2\n" +
+				fmt.Sprintf("%s,%s,%s,%s,%d,student,1\n", student.FullName(), student.Email,
+					student.StudentNumber, student.Subject, student.Semester)
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			w, err = tape.Upload("/api/v1/courses/1/enrollments/import", filename, "text/csv", studentJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w, err = tape.Upload("/api/v1/courses/1/enrollments/import?dry_run=true", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			entriesActual = []UserImportEntryResponse{}
+			err = json.NewDecoder(w.Body).Decode(&entriesActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(entriesActual)).Equal(2)
+			g.Assert(entriesActual[0].Action).Equal(UserImportCreate)
+			g.Assert(entriesActual[0].FirstName).Equal("Ada")
+			g.Assert(entriesActual[0].LastName).Equal("Lovelace")
+			g.Assert(entriesActual[0].GroupID).Equal(int64(2))
+			g.Assert(entriesActual[1].Action).Equal(UserImportKeep)
+			g.Assert(entriesActual[1].UserID).Equal(student.ID)
+
+			_, err = stores.User.FindByEmail("ada@uni-tuebingen.de")
+			g.Assert(err != nil).IsTrue()
+
+			w, err = tape.Upload("/api/v1/courses/1/enrollments/import", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			ada, err := stores.User.FindByEmail("ada@uni-tuebingen.de")
+			g.Assert(err).Equal(nil)
+			g.Assert(ada.StudentNumber).Equal("4711")
+			g.Assert(ada.ResetPasswordToken.Valid).IsTrue()
+			g.Assert(ada.EncryptedPassword).Equal("")
+
+			role, err := stores.Course.RoleInCourse(ada.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(role).Equal(authorize.STUDENT)
+
+			enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(ada.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(int64(2))
+
+			enrollment, err = stores.Group.GetGroupEnrollmentOfUserInCourse(student.ID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(int64(1))
+
+			// the same file again does not create any account
+			w, err = tape.Upload("/api/v1/courses/1/enrollments/import?dry_run=true", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			entriesActual = []UserImportEntryResponse{}
+			err = json.NewDecoder(w.Body).Decode(&entriesActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(entriesActual[0].Action).Equal(UserImportKeep)
+			g.Assert(entriesActual[0].UserID).Equal(ada.ID)
+		})
+
+		g.It("Should not import any user if a line fails", func() {
+			filename := "/tmp/infomark-user-import.csv"
+			defer os.Remove(filename)
+
+			content := "name,email,student_number,subject,semester,role,group\n" +
+				"Ada Lovelace,ada@uni-tuebingen.de,4711,informatics,1,student,2\n"
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			// the group enrollment is written after the account has been created
+			_, err := tape.DB.Exec(`
+CREATE FUNCTION fail_insert() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'failed on purpose';
+END $$ LANGUAGE plpgsql;
+CREATE TRIGGER fail_user_group BEFORE INSERT ON user_group FOR EACH ROW EXECUTE PROCEDURE fail_insert();`)
+			g.Assert(err).Equal(nil)
+			defer tape.DB.Exec("DROP TRIGGER fail_user_group ON user_group; DROP FUNCTION fail_insert();")
+
+			w, err := tape.Upload("/api/v1/courses/1/enrollments/import", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusInternalServerError)
+
+			_, err = stores.User.FindByEmail("ada@uni-tuebingen.de")
+			g.Assert(err != nil).IsTrue()
+		})
+
 		g.AfterEach(func() {
 			tape.AfterEach()
 		})
@@ -0,0 +1,739 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// DiscussionResource specifies discussion management handler.
+type DiscussionResource struct {
+	Stores *Stores
+}
+
+// NewDiscussionResource create and returns a DiscussionResource.
+func NewDiscussionResource(stores *Stores) *DiscussionResource {
+	return &DiscussionResource{
+		Stores: stores,
+	}
+}
+
+// isDiscussionStaff tests whether the identity is a tutor or an admin of the course.
+func isDiscussionStaff(r *http.Request) bool {
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	return givenRole == authorize.TUTOR || givenRole == authorize.ADMIN
+}
+
+// applyRequest copies the request into the thread and makes sure the sheet
+// and the task belong to the course and, unless staff is set, are published.
+// The sheet of a task is set automatically.
+func (rs *DiscussionResource) applyRequest(thread *model.DiscussionThread, data *DiscussionThreadRequest, staff bool) error {
+	thread.SheetID = data.SheetID
+	thread.TaskID = data.TaskID
+
+	if data.TaskID.Valid {
+		course, err := rs.Stores.Task.IdentifyCourseOfTask(data.TaskID.Int64)
+		if err != nil || course.ID != thread.CourseID {
+			return errors.New("task does not belong to the course")
+		}
+		sheet, err := rs.Stores.Task.IdentifySheetOfTask(data.TaskID.Int64)
+		if err != nil {
+			return err
+		}
+		thread.SheetID = null.IntFrom(sheet.ID)
+	} else if data.SheetID.Valid {
+		course, err := rs.Stores.Sheet.IdentifyCourseOfSheet(data.SheetID.Int64)
+		if err != nil || course.ID != thread.CourseID {
+			return errors.New("sheet does not belong to the course")
+		}
+	}
+
+	if !staff && thread.SheetID.Valid {
+		visible, err := rs.sheetVisible(thread.SheetID.Int64)
+		if err != nil || !visible {
+			return errors.New("sheet is not published yet")
+		}
+	}
+
+	thread.Title = data.Title
+	thread.Body = data.Body
+	thread.Private = data.Private
+	thread.Anonymous = data.Anonymous
+	return nil
+}
+
+// sheetVisible tests whether students can see threads about a sheet.
+func (rs *DiscussionResource) sheetVisible(sheetID int64) (bool, error) {
+	sheet, err := rs.Stores.Sheet.Get(sheetID)
+	if err != nil {
+		return false, err
+	}
+	return PublicYet(sheet.PublishAt), nil
+}
+
+// notifySubscribers emails everybody subscribed to the course (for a new
+// thread) or to the thread (for a new answer) except the author. Private
+// threads are only sent to the staff and the author of the thread.
+func (rs *DiscussionResource) notifySubscribers(course *model.Course, thread *model.DiscussionThread, subscription null.Int, authorID int64, anonymous bool, body string) error {
+	subscribers, err := rs.Stores.Discussion.Subscribers(course.ID, subscription)
+	if err != nil {
+		return err
+	}
+
+	author, err := rs.Stores.User.Get(authorID)
+	if err != nil {
+		return err
+	}
+
+	kind := "answer"
+	if !subscription.Valid {
+		kind = "question"
+	}
+
+	for _, subscriber := range subscribers {
+		if subscriber.ID == authorID {
+			continue
+		}
+
+		role, err := rs.Stores.Course.RoleInCourse(subscriber.ID, course.ID)
+		if err != nil {
+			return err
+		}
+		staff := role == authorize.TUTOR || role == authorize.ADMIN
+		if role == authorize.NOCOURSEROLE || (thread.Private && !staff && subscriber.ID != thread.AuthorID) {
+			continue
+		}
+
+		_, firstName, lastName := discussionAuthor(author.ID, author.FirstName, author.LastName,
+			anonymous, subscriber.ID, staff)
+
+		msg, err := email.NewEmailFromTemplate(
+			configuration.Configuration.Server.Email.From,
+			subscriber.Email,
+			fmt.Sprintf("[%s] %s", course.Name, thread.Title),
+			email.DiscussionTemplateEN,
+			map[string]string{
+				"first_name":     subscriber.FirstName,
+				"last_name":      subscriber.LastName,
+				"course_name":    course.Name,
+				"kind":           kind,
+				"author_name":    fmt.Sprintf("%s %s", firstName, lastName),
+				"title":          thread.Title,
+				"body":           body,
+				"discussion_url": fmt.Sprintf("%s/#/course/%d/discussions/%d", configuration.Configuration.Server.ExternalURL(), course.ID, thread.ID),
+			})
+		if err != nil {
+			return err
+		}
+		email.OutgoingEmailsChannel <- msg
+	}
+
+	return nil
+}
+
+// IndexHandler is public endpoint for
+// URL: /courses/{course_id}/discussions
+// URLPARAM: course_id,integer
+// QUERYPARAM: sheet_id,integer
+// QUERYPARAM: task_id,integer
+// METHOD: get
+// TAG: discussions
+// RESPONSE: 200,DiscussionThreadResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all discussion threads of a course
+// DESCRIPTION:
+// Private threads of other students are not listed. Threads about unpublished
+// sheets are only listed for tutors and admins. The authors of anonymous
+// threads are hidden from students.
+func (rs *DiscussionResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	staff := isDiscussionStaff(r)
+
+	filterSheetID := helper.Int64FromURL(r, "sheet_id", 0)
+	filterTaskID := helper.Int64FromURL(r, "task_id", 0)
+
+	threads, err := rs.Stores.Discussion.ThreadsOfCourse(course.ID, accessClaims.LoginID, staff, filterSheetID, filterTaskID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newDiscussionThreadListResponse(threads, accessClaims.LoginID, staff)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateHandler is public endpoint for
+// URL: /courses/{course_id}/discussions
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: discussions
+// REQUEST: DiscussionThreadRequest
+// RESPONSE: 201,DiscussionThreadResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  ask a new question in a course
+// DESCRIPTION:
+// A thread can be about the course, a sheet or a task. The author is subscribed
+// to the thread automatically. Everybody subscribed to the discussions of the
+// course is notified by email.
+func (rs *DiscussionResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	staff := isDiscussionStaff(r)
+
+	data := &DiscussionThreadRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	thread := &model.DiscussionThread{
+		CourseID: course.ID,
+		AuthorID: accessClaims.LoginID,
+	}
+
+	if err := rs.applyRequest(thread, data, isDiscussionStaff(r)); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	newThread, err := rs.Stores.Discussion.CreateThread(thread)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := rs.Stores.Discussion.Subscribe(course.ID, null.IntFrom(newThread.ID), accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := rs.notifySubscribers(course, newThread, null.Int{}, newThread.AuthorID, newThread.Anonymous, newThread.Body); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newDiscussionThreadResponse(newThread, []model.DiscussionPost{}, true, accessClaims.LoginID, staff)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// GetHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// METHOD: get
+// TAG: discussions
+// RESPONSE: 200,DiscussionThreadResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get a discussion thread including all answers
+// DESCRIPTION:
+// Answers endorsed by a tutor or an admin come first.
+func (rs *DiscussionResource) GetHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	thread := r.Context().Value(symbol.CtxKeyThread).(*model.DiscussionThread)
+
+	posts, err := rs.Stores.Discussion.PostsOfThread(thread.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	subscribed, err := rs.Stores.Discussion.IsSubscribed(thread.CourseID, null.IntFrom(thread.ID), accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err := render.Render(w, r, newDiscussionThreadResponse(thread, posts, subscribed, accessClaims.LoginID, isDiscussionStaff(r))); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// EditHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// METHOD: put
+// TAG: discussions
+// REQUEST: DiscussionThreadRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  update a discussion thread
+// DESCRIPTION:
+// Only the author, tutors and admins can update a thread.
+func (rs *DiscussionResource) EditHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	thread := r.Context().Value(symbol.CtxKeyThread).(*model.DiscussionThread)
+
+	if thread.AuthorID != accessClaims.LoginID && !isDiscussionStaff(r) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	data := &DiscussionThreadRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.applyRequest(thread, data, isDiscussionStaff(r)); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.Stores.Discussion.UpdateThread(thread); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeleteHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// METHOD: delete
+// TAG: discussions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  delete a discussion thread including all answers
+// DESCRIPTION:
+// Only the author, tutors and admins can delete a thread.
+func (rs *DiscussionResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	thread := r.Context().Value(symbol.CtxKeyThread).(*model.DiscussionThread)
+
+	if thread.AuthorID != accessClaims.LoginID && !isDiscussionStaff(r) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	if err := rs.Stores.Discussion.DeleteThread(thread.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// CreatePostHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}/posts
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// METHOD: post
+// TAG: discussions
+// REQUEST: DiscussionPostRequest
+// RESPONSE: 201,DiscussionPostResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  answer a discussion thread
+// DESCRIPTION:
+// The author of the answer is subscribed to the thread automatically. Everybody
+// subscribed to the thread is notified by email.
+func (rs *DiscussionResource) CreatePostHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	thread := r.Context().Value(symbol.CtxKeyThread).(*model.DiscussionThread)
+
+	data := &DiscussionPostRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	newPost, err := rs.Stores.Discussion.CreatePost(&model.DiscussionPost{
+		ThreadID:  thread.ID,
+		AuthorID:  accessClaims.LoginID,
+		Body:      data.Body,
+		Anonymous: data.Anonymous,
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := rs.notifySubscribers(course, thread, null.IntFrom(thread.ID), newPost.AuthorID, newPost.Anonymous, newPost.Body); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := rs.Stores.Discussion.Subscribe(course.ID, null.IntFrom(thread.ID), accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newDiscussionPostResponse(newPost, accessClaims.LoginID, isDiscussionStaff(r))); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// EditPostHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}/posts/{post_id}
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// URLPARAM: post_id,integer
+// METHOD: put
+// TAG: discussions
+// REQUEST: DiscussionPostRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  update an answer of a discussion thread
+// DESCRIPTION:
+// Only the author, tutors and admins can update an answer. Changes of students
+// remove the endorsement.
+func (rs *DiscussionResource) EditPostHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	post := r.Context().Value(symbol.CtxKeyPost).(*model.DiscussionPost)
+
+	if post.AuthorID != accessClaims.LoginID && !isDiscussionStaff(r) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	data := &DiscussionPostRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	post.Body = data.Body
+	post.Anonymous = data.Anonymous
+
+	// the staff has only endorsed the previous answer
+	if !isDiscussionStaff(r) {
+		post.Endorsed = false
+	}
+
+	if err := rs.Stores.Discussion.UpdatePost(post); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeletePostHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}/posts/{post_id}
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// URLPARAM: post_id,integer
+// METHOD: delete
+// TAG: discussions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  delete an answer of a discussion thread
+// DESCRIPTION:
+// Only the author, tutors and admins can delete an answer.
+func (rs *DiscussionResource) DeletePostHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	post := r.Context().Value(symbol.CtxKeyPost).(*model.DiscussionPost)
+
+	if post.AuthorID != accessClaims.LoginID && !isDiscussionStaff(r) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	if err := rs.Stores.Discussion.DeletePost(post.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// EndorsePostHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}/posts/{post_id}/endorse
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// URLPARAM: post_id,integer
+// METHOD: post
+// TAG: discussions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  mark an answer as correct
+func (rs *DiscussionResource) EndorsePostHandler(w http.ResponseWriter, r *http.Request) {
+	post := r.Context().Value(symbol.CtxKeyPost).(*model.DiscussionPost)
+
+	post.Endorsed = true
+	if err := rs.Stores.Discussion.UpdatePost(post); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// UnendorsePostHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}/posts/{post_id}/endorse
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// URLPARAM: post_id,integer
+// METHOD: delete
+// TAG: discussions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  remove the endorsement of an answer
+func (rs *DiscussionResource) UnendorsePostHandler(w http.ResponseWriter, r *http.Request) {
+	post := r.Context().Value(symbol.CtxKeyPost).(*model.DiscussionPost)
+
+	post.Endorsed = false
+	if err := rs.Stores.Discussion.UpdatePost(post); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// SubscribeCourseHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/subscription
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: discussions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get notified by email about new threads in a course
+func (rs *DiscussionResource) SubscribeCourseHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	if err := rs.Stores.Discussion.Subscribe(course.ID, null.Int{}, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// UnsubscribeCourseHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/subscription
+// URLPARAM: course_id,integer
+// METHOD: delete
+// TAG: discussions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  stop notifications about new threads in a course
+func (rs *DiscussionResource) UnsubscribeCourseHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	if err := rs.Stores.Discussion.Unsubscribe(course.ID, null.Int{}, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// SubscribeHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}/subscription
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// METHOD: post
+// TAG: discussions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get notified by email about new answers in a thread
+func (rs *DiscussionResource) SubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	thread := r.Context().Value(symbol.CtxKeyThread).(*model.DiscussionThread)
+
+	if err := rs.Stores.Discussion.Subscribe(thread.CourseID, null.IntFrom(thread.ID), accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// UnsubscribeHandler is public endpoint for
+// URL: /courses/{course_id}/discussions/{thread_id}/subscription
+// URLPARAM: course_id,integer
+// URLPARAM: thread_id,integer
+// METHOD: delete
+// TAG: discussions
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  stop notifications about new answers in a thread
+func (rs *DiscussionResource) UnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	thread := r.Context().Value(symbol.CtxKeyThread).(*model.DiscussionThread)
+
+	if err := rs.Stores.Discussion.Unsubscribe(thread.CourseID, null.IntFrom(thread.ID), accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// .............................................................................
+
+// Context middleware is used to load a DiscussionThread object from
+// the URL parameter `thread_id` passed through as the request. In case
+// the DiscussionThread could not be found, we stop here and return a 404.
+// Private threads of other users are not found for students.
+func (rs *DiscussionResource) Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+		course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+		var threadID int64
+		var err error
+
+		// try to get id from URL
+		if threadID, err = strconv.ParseInt(chi.URLParam(r, "thread_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific thread in database
+		thread, err := rs.Stores.Discussion.GetThread(threadID)
+		if err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		if thread.CourseID != course.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		if thread.Private && thread.AuthorID != accessClaims.LoginID && !isDiscussionStaff(r) {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		if thread.SheetID.Valid && !isDiscussionStaff(r) {
+			visible, err := rs.sheetVisible(thread.SheetID.Int64)
+			if err != nil {
+				render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+				return
+			}
+			if !visible {
+				render.Render(w, r, ErrNotFound)
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), symbol.CtxKeyThread, thread)
+
+		// serve next
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// PostContext middleware is used to load a DiscussionPost object from
+// the URL parameter `post_id` passed through as the request. In case
+// the DiscussionPost could not be found, we stop here and return a 404.
+// We do NOT check whether the identity is authorized to get this DiscussionPost.
+func (rs *DiscussionResource) PostContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		thread := r.Context().Value(symbol.CtxKeyThread).(*model.DiscussionThread)
+
+		var postID int64
+		var err error
+
+		// try to get id from URL
+		if postID, err = strconv.ParseInt(chi.URLParam(r, "post_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific post in database
+		post, err := rs.Stores.Discussion.GetPost(postID)
+		if err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		if post.ThreadID != thread.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), symbol.CtxKeyPost, post)
+
+		// serve next
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
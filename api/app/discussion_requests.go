@@ -0,0 +1,86 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// DiscussionThreadRequest is the request payload for discussion threads.
+type DiscussionThreadRequest struct {
+	Title     string   `json:"title" example:"Is the input sorted?"`
+	Body      string   `json:"body" example:"Can we assume that the input of **task 2** is sorted?"`
+	SheetID   null.Int `json:"sheet_id" example:"1" required:"false"`
+	TaskID    null.Int `json:"task_id" example:"2" required:"false"`
+	Private   bool     `json:"private" example:"false"`
+	Anonymous bool     `json:"anonymous" example:"true"`
+}
+
+// Bind preprocesses a DiscussionThreadRequest.
+func (body *DiscussionThreadRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"thread\" data")
+	}
+
+	body.Title = strings.TrimSpace(body.Title)
+
+	return body.Validate()
+}
+
+// Validate validates a DiscussionThreadRequest.
+func (body *DiscussionThreadRequest) Validate() error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Title,
+			validation.Required,
+		),
+	)
+}
+
+// DiscussionPostRequest is the request payload for answers in a discussion thread.
+type DiscussionPostRequest struct {
+	Body      string `json:"body" example:"Yes, it is sorted in ascending order."`
+	Anonymous bool   `json:"anonymous" example:"false"`
+}
+
+// Bind preprocesses a DiscussionPostRequest.
+func (body *DiscussionPostRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"post\" data")
+	}
+
+	body.Body = strings.TrimSpace(body.Body)
+
+	return body.Validate()
+}
+
+// Validate validates a DiscussionPostRequest.
+func (body *DiscussionPostRequest) Validate() error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Body,
+			validation.Required,
+		),
+	)
+}
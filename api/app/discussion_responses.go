@@ -0,0 +1,147 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// discussionAuthor hides the author of an anonymous thread or answer from
+// other students. The staff of the course can always see the author.
+func discussionAuthor(authorID int64, firstName string, lastName string, anonymous bool, viewerID int64, staff bool) (int64, string, string) {
+	if anonymous && !staff && authorID != viewerID {
+		return 0, "Anonymous", ""
+	}
+	return authorID, firstName, lastName
+}
+
+// DiscussionPostResponse is the response payload for answers in a discussion thread.
+type DiscussionPostResponse struct {
+	ID              int64     `json:"id" example:"1"`
+	CreatedAt       time.Time `json:"created_at" example:"auto"`
+	UpdatedAt       time.Time `json:"updated_at" example:"auto"`
+	ThreadID        int64     `json:"thread_id" example:"1"`
+	AuthorID        int64     `json:"author_id" example:"2"`
+	AuthorFirstName string    `json:"author_first_name" example:"Max"`
+	AuthorLastName  string    `json:"author_last_name" example:"Mustermensch"`
+	Body            string    `json:"body" example:"Yes, it is sorted in ascending order."`
+	Anonymous       bool      `json:"anonymous" example:"false"`
+	Endorsed        bool      `json:"endorsed" example:"true"`
+	Own             bool      `json:"own" example:"false"`
+}
+
+// Render post-processes a DiscussionPostResponse.
+func (body *DiscussionPostResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newDiscussionPostResponse creates a response from a DiscussionPost model as
+// seen by the given user.
+func newDiscussionPostResponse(p *model.DiscussionPost, viewerID int64, staff bool) *DiscussionPostResponse {
+	authorID, firstName, lastName := discussionAuthor(p.AuthorID, p.AuthorFirstName, p.AuthorLastName,
+		p.Anonymous, viewerID, staff)
+
+	return &DiscussionPostResponse{
+		ID:              p.ID,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+		ThreadID:        p.ThreadID,
+		AuthorID:        authorID,
+		AuthorFirstName: firstName,
+		AuthorLastName:  lastName,
+		Body:            p.Body,
+		Anonymous:       p.Anonymous,
+		Endorsed:        p.Endorsed,
+		Own:             p.AuthorID == viewerID,
+	}
+}
+
+// DiscussionThreadResponse is the response payload for discussion threads.
+// The answers are only included for a single thread.
+type DiscussionThreadResponse struct {
+	ID              int64                    `json:"id" example:"1"`
+	CreatedAt       time.Time                `json:"created_at" example:"auto"`
+	UpdatedAt       time.Time                `json:"updated_at" example:"auto"`
+	CourseID        int64                    `json:"course_id" example:"1"`
+	SheetID         null.Int                 `json:"sheet_id" example:"1"`
+	TaskID          null.Int                 `json:"task_id" example:"2"`
+	AuthorID        int64                    `json:"author_id" example:"112"`
+	AuthorFirstName string                   `json:"author_first_name" example:"Max"`
+	AuthorLastName  string                   `json:"author_last_name" example:"Mustermensch"`
+	Title           string                   `json:"title" example:"Is the input sorted?"`
+	Body            string                   `json:"body" example:"Can we assume that the input of **task 2** is sorted?"`
+	Private         bool                     `json:"private" example:"false"`
+	Anonymous       bool                     `json:"anonymous" example:"true"`
+	PostCount       int                      `json:"post_count" example:"3"`
+	Own             bool                     `json:"own" example:"true"`
+	Subscribed      bool                     `json:"subscribed" example:"true"`
+	Posts           []DiscussionPostResponse `json:"posts,omitempty"`
+}
+
+// Render post-processes a DiscussionThreadResponse.
+func (body *DiscussionThreadResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newDiscussionThreadResponse creates a response from a DiscussionThread model
+// as seen by the given user.
+func newDiscussionThreadResponse(p *model.DiscussionThread, posts []model.DiscussionPost, subscribed bool, viewerID int64, staff bool) *DiscussionThreadResponse {
+	authorID, firstName, lastName := discussionAuthor(p.AuthorID, p.AuthorFirstName, p.AuthorLastName,
+		p.Anonymous, viewerID, staff)
+
+	postResponses := []DiscussionPostResponse{}
+	for k := range posts {
+		postResponses = append(postResponses, *newDiscussionPostResponse(&posts[k], viewerID, staff))
+	}
+
+	return &DiscussionThreadResponse{
+		ID:              p.ID,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+		CourseID:        p.CourseID,
+		SheetID:         p.SheetID,
+		TaskID:          p.TaskID,
+		AuthorID:        authorID,
+		AuthorFirstName: firstName,
+		AuthorLastName:  lastName,
+		Title:           p.Title,
+		Body:            p.Body,
+		Private:         p.Private,
+		Anonymous:       p.Anonymous,
+		PostCount:       p.PostCount,
+		Own:             p.AuthorID == viewerID,
+		Subscribed:      subscribed,
+		Posts:           postResponses,
+	}
+}
+
+// newDiscussionThreadListResponse creates a response from a list of
+// DiscussionThread models as seen by the given user.
+func newDiscussionThreadListResponse(threads []model.DiscussionThread, viewerID int64, staff bool) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range threads {
+		list = append(list, newDiscussionThreadResponse(&threads[k], nil, false, viewerID, staff))
+	}
+	return list
+}
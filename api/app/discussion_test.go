@@ -0,0 +1,293 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	null "gopkg.in/guregu/null.v3"
+)
+
+func TestDiscussion(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+	go email.BackgroundSend(email.OutgoingEmailsChannel)
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	studentJWT := tape.NewJWTRequest(112, false)
+	otherStudentJWT := tape.NewJWTRequest(113, false)
+	tutorJWT := tape.NewJWTRequest(2, false)
+
+	g.Describe("Discussion", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		createThread := func(data H, jwt JWTRequest) *DiscussionThreadResponse {
+			w := tape.Post("/api/v1/courses/1/discussions", data, jwt)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			thread := &DiscussionThreadResponse{}
+			err := json.NewDecoder(w.Body).Decode(thread)
+			g.Assert(err).Equal(nil)
+			return thread
+		}
+
+		listThreads := func(url string, jwt JWTRequest) []DiscussionThreadResponse {
+			w := tape.Get(url, jwt)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			threads := []DiscussionThreadResponse{}
+			err := json.NewDecoder(w.Body).Decode(&threads)
+			g.Assert(err).Equal(nil)
+			return threads
+		}
+
+		getThread := func(threadID int64, jwt JWTRequest) *DiscussionThreadResponse {
+			w := tape.Get(fmt.Sprintf("/api/v1/courses/1/discussions/%d", threadID), jwt)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			thread := &DiscussionThreadResponse{}
+			err := json.NewDecoder(w.Body).Decode(thread)
+			g.Assert(err).Equal(nil)
+			return thread
+		}
+
+		g.It("Should attach threads to tasks and hide anonymous authors from peers", func() {
+			sheets, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			tasks, err := stores.Task.TasksOfSheet(sheets[0].ID)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/discussions", H{"body": "no title"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			otherSheets, err := stores.Sheet.SheetsOfCourse(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(otherSheets) > 0).IsTrue()
+			w = tape.Post("/api/v1/courses/1/discussions", H{"title": "wrong", "sheet_id": otherSheets[0].ID}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			thread := createThread(H{
+				"title":     "Is the input sorted?",
+				"body":      "Can we assume that the input is sorted?",
+				"task_id":   tasks[0].ID,
+				"anonymous": true,
+			}, studentJWT)
+			g.Assert(thread.AuthorID).Equal(int64(112))
+			g.Assert(thread.SheetID).Equal(null.IntFrom(sheets[0].ID))
+			g.Assert(thread.TaskID).Equal(null.IntFrom(tasks[0].ID))
+			g.Assert(thread.Own).IsTrue()
+			g.Assert(thread.Subscribed).IsTrue()
+
+			createThread(H{"title": "About the course"}, otherStudentJWT)
+
+			threads := listThreads(fmt.Sprintf("/api/v1/courses/1/discussions?task_id=%d", tasks[0].ID), otherStudentJWT)
+			g.Assert(len(threads)).Equal(1)
+			g.Assert(threads[0].ID).Equal(thread.ID)
+			g.Assert(threads[0].AuthorID).Equal(int64(0))
+			g.Assert(threads[0].AuthorFirstName).Equal("Anonymous")
+			g.Assert(threads[0].Own).IsFalse()
+
+			threads = listThreads("/api/v1/courses/1/discussions", otherStudentJWT)
+			g.Assert(len(threads)).Equal(2)
+
+			// the staff can always see the author
+			threadTutor := getThread(thread.ID, tutorJWT)
+			g.Assert(threadTutor.AuthorID).Equal(int64(112))
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/discussions/%d", thread.ID), H{"title": "changed"}, otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/discussions/%d", thread.ID), H{
+				"title":   "Is the input of task 1 sorted?",
+				"task_id": tasks[0].ID,
+			}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			threadAfter, err := stores.Discussion.GetThread(thread.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(threadAfter.Title).Equal("Is the input of task 1 sorted?")
+			g.Assert(threadAfter.Anonymous).IsFalse()
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/discussions/%d", thread.ID), otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/discussions/%d", thread.ID), studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			_, err = stores.Discussion.GetThread(thread.ID)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("Should only show private threads to the author and the staff", func() {
+			thread := createThread(H{"title": "My grade", "private": true}, studentJWT)
+
+			threads := listThreads("/api/v1/courses/1/discussions", otherStudentJWT)
+			g.Assert(len(threads)).Equal(0)
+
+			w := tape.Get(fmt.Sprintf("/api/v1/courses/1/discussions/%d", thread.ID), otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts", thread.ID), H{"body": "me too"}, otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			threads = listThreads("/api/v1/courses/1/discussions", tutorJWT)
+			g.Assert(len(threads)).Equal(1)
+
+			threads = listThreads("/api/v1/courses/1/discussions", studentJWT)
+			g.Assert(len(threads)).Equal(1)
+		})
+
+		g.It("Should answer, endorse and subscribe", func() {
+			thread := createThread(H{"title": "Deadline?"}, studentJWT)
+
+			w := tape.Post(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts", thread.ID), H{"body": ""}, otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts", thread.ID),
+				H{"body": "Friday, I guess", "anonymous": true}, otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			studentPost := &DiscussionPostResponse{}
+			err := json.NewDecoder(w.Body).Decode(studentPost)
+			g.Assert(err).Equal(nil)
+			g.Assert(studentPost.Own).IsTrue()
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts", thread.ID),
+				H{"body": "Monday at noon"}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			tutorPost := &DiscussionPostResponse{}
+			err = json.NewDecoder(w.Body).Decode(tutorPost)
+			g.Assert(err).Equal(nil)
+
+			// everybody who answered is subscribed
+			subscribers, err := stores.Discussion.Subscribers(1, null.IntFrom(thread.ID))
+			g.Assert(err).Equal(nil)
+			g.Assert(len(subscribers)).Equal(3)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts/%d/endorse", thread.ID, tutorPost.ID), H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts/%d/endorse", thread.ID, tutorPost.ID), H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			threadStudent := getThread(thread.ID, studentJWT)
+			g.Assert(threadStudent.PostCount).Equal(2)
+			g.Assert(len(threadStudent.Posts)).Equal(2)
+			g.Assert(threadStudent.Posts[0].ID).Equal(tutorPost.ID)
+			g.Assert(threadStudent.Posts[0].Endorsed).IsTrue()
+			g.Assert(threadStudent.Posts[1].AuthorID).Equal(int64(0))
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts/%d/endorse", thread.ID, tutorPost.ID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts/%d/endorse", thread.ID, studentPost.ID), H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts/%d", thread.ID, tutorPost.ID),
+				H{"body": "changed"}, otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts/%d", thread.ID, studentPost.ID),
+				H{"body": "Monday, sorry"}, otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			postAfter, err := stores.Discussion.GetPost(studentPost.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(postAfter.Body).Equal("Monday, sorry")
+			g.Assert(postAfter.Anonymous).IsFalse()
+			// the endorsement does not survive an edit by the student
+			g.Assert(postAfter.Endorsed).IsFalse()
+
+			// the staff can remove answers
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/discussions/%d/posts/%d", thread.ID, studentPost.ID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/discussions/%d/subscription", thread.ID), otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			subscribed, err := stores.Discussion.IsSubscribed(1, null.IntFrom(thread.ID), 113)
+			g.Assert(err).Equal(nil)
+			g.Assert(subscribed).IsFalse()
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/discussions/%d/subscription", thread.ID), H{}, otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			subscribed, err = stores.Discussion.IsSubscribed(1, null.IntFrom(thread.ID), 113)
+			g.Assert(err).Equal(nil)
+			g.Assert(subscribed).IsTrue()
+
+			// subscriptions to new threads of the course
+			w = tape.Post("/api/v1/courses/1/discussions/subscription", H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+			w = tape.Post("/api/v1/courses/1/discussions/subscription", H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			subscribers, err = stores.Discussion.Subscribers(1, null.Int{})
+			g.Assert(err).Equal(nil)
+			g.Assert(len(subscribers)).Equal(1)
+			g.Assert(subscribers[0].ID).Equal(int64(2))
+
+			createThread(H{"title": "Another question"}, studentJWT)
+
+			w = tape.Delete("/api/v1/courses/1/discussions/subscription", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			subscribed, err = stores.Discussion.IsSubscribed(1, null.Int{}, 2)
+			g.Assert(err).Equal(nil)
+			g.Assert(subscribed).IsFalse()
+		})
+
+		g.It("Should hide threads of unpublished sheets from students", func() {
+			sheets, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+
+			thread := createThread(H{"title": "About the sheet", "sheet_id": sheets[0].ID}, studentJWT)
+
+			_, err = tape.DB.Exec("UPDATE sheets SET publish_at = now() + interval '1 day' WHERE id = $1;", sheets[0].ID)
+			g.Assert(err).Equal(nil)
+
+			threads := listThreads("/api/v1/courses/1/discussions", otherStudentJWT)
+			g.Assert(len(threads)).Equal(0)
+
+			w := tape.Get(fmt.Sprintf("/api/v1/courses/1/discussions/%d", thread.ID), studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			w = tape.Post("/api/v1/courses/1/discussions", H{"title": "early", "sheet_id": sheets[0].ID}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			threads = listThreads("/api/v1/courses/1/discussions", tutorJWT)
+			g.Assert(len(threads)).Equal(1)
+			getThread(thread.ID, tutorJWT)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
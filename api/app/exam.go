@@ -208,7 +208,10 @@ func (rs *ExamResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  enroll a user into a exam
+// DESCRIPTION:
+// Only students which are admitted to the exams of the course can enroll.
 func (rs *ExamResource) EnrollExamHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 	exam := r.Context().Value(symbol.CtxKeyExam).(*model.Exam)
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
@@ -218,6 +221,17 @@ func (rs *ExamResource) EnrollExamHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	admission, err := AdmissionOfUser(rs.Stores, course, accessClaims.LoginID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if !admission.Admitted {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("you are not admitted to the exams of this course")))
+		return
+	}
+
 	// update database entry
 	if err := rs.Stores.Exam.Enroll(exam.ID, accessClaims.LoginID); err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
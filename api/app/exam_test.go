@@ -26,6 +26,7 @@ import (
 	"github.com/franela/goblin"
 	"github.com/infomark-org/infomark/api/helper"
 	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
 )
 
 func TestExam(t *testing.T) {
@@ -240,6 +241,23 @@ func TestExam(t *testing.T) {
 			w = tape.Post("/api/v1/courses/1/exams/1/enrollments", helper.H{}, tutorJWT)
 			g.Assert(w.Code).Equal(http.StatusBadRequest)
 
+			_, err = tape.DB.Exec("UPDATE courses SET required_percentage = 0 WHERE id = 1;")
+			g.Assert(err).Equal(nil)
+
+			// students without admission cannot enroll
+			err = stores.Course.SetAdmissionOverride(&model.AdmissionOverride{
+				CourseID: 1,
+				UserID:   studentJWT.Claims.LoginID,
+				Admitted: false,
+			})
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post("/api/v1/courses/1/exams/1/enrollments", helper.H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			err = stores.Course.DeleteAdmissionOverride(1, studentJWT.Claims.LoginID)
+			g.Assert(err).Equal(nil)
+
 			w = tape.Post("/api/v1/courses/1/exams/1/enrollments", helper.H{}, studentJWT)
 			g.Assert(w.Code).Equal(http.StatusCreated)
 
@@ -0,0 +1,294 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// FeedbackSnippetResource specifies feedback snippet management handler.
+type FeedbackSnippetResource struct {
+	Stores *Stores
+}
+
+// NewFeedbackSnippetResource create and returns a FeedbackSnippetResource.
+func NewFeedbackSnippetResource(stores *Stores) *FeedbackSnippetResource {
+	return &FeedbackSnippetResource{
+		Stores: stores,
+	}
+}
+
+// validateTaskOfSnippet tests if a snippet can be bound to the given task.
+func (rs *FeedbackSnippetResource) validateTaskOfSnippet(courseID int64, taskID null.Int) error {
+	if !taskID.Valid {
+		return nil
+	}
+
+	course, err := rs.Stores.Task.IdentifyCourseOfTask(taskID.Int64)
+	if err != nil || course.ID != courseID {
+		return errors.New("task does not belong to this course")
+	}
+	return nil
+}
+
+// mayChangeSnippet tests if the request identity is the author of the snippet
+// or an admin of the course.
+func mayChangeSnippet(r *http.Request, snippet *model.FeedbackSnippet) bool {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	if givenRole == authorize.ADMIN {
+		return true
+	}
+	return snippet.AuthorID.Valid && snippet.AuthorID.Int64 == accessClaims.LoginID
+}
+
+// IndexHandler is public endpoint for
+// URL: /courses/{course_id}/snippets
+// URLPARAM: course_id,integer
+// QUERYPARAM: task_id,integer
+// METHOD: get
+// TAG: snippets
+// RESPONSE: 200,FeedbackSnippetResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  feedback snippets of a course, most used first
+// DESCRIPTION:
+// With a task filter only the snippets of this task and the snippets for the
+// entire course are listed.
+func (rs *FeedbackSnippetResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	filterTaskID := helper.Int64FromURL(r, "task_id", 0)
+
+	snippets, err := rs.Stores.Snippet.GetForCourse(course.ID, filterTaskID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newFeedbackSnippetListResponse(snippets)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateHandler is public endpoint for
+// URL: /courses/{course_id}/snippets
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: snippets
+// REQUEST: FeedbackSnippetRequest
+// RESPONSE: 200,FeedbackSnippetResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  create a new feedback snippet
+// DESCRIPTION:
+// Snippets are shared with all tutors of the course. Without a task id the
+// snippet can be used for every task.
+func (rs *FeedbackSnippetResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	data := &FeedbackSnippetRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.validateTaskOfSnippet(course.ID, data.TaskID); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	snippet, err := rs.Stores.Snippet.Create(&model.FeedbackSnippet{
+		CourseID:    course.ID,
+		TaskID:      data.TaskID,
+		AuthorID:    null.IntFrom(accessClaims.LoginID),
+		Text:        data.Text,
+		PointsDelta: data.PointsDelta,
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newFeedbackSnippetResponse(snippet)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// GetHandler is public endpoint for
+// URL: /courses/{course_id}/snippets/{snippet_id}
+// URLPARAM: course_id,integer
+// URLPARAM: snippet_id,integer
+// METHOD: get
+// TAG: snippets
+// RESPONSE: 200,FeedbackSnippetResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get a specific feedback snippet
+func (rs *FeedbackSnippetResource) GetHandler(w http.ResponseWriter, r *http.Request) {
+	snippet := r.Context().Value(symbol.CtxKeySnippet).(*model.FeedbackSnippet)
+
+	// render JSON response
+	if err := render.Render(w, r, newFeedbackSnippetResponse(snippet)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// EditHandler is public endpoint for
+// URL: /courses/{course_id}/snippets/{snippet_id}
+// URLPARAM: course_id,integer
+// URLPARAM: snippet_id,integer
+// METHOD: put
+// TAG: snippets
+// REQUEST: FeedbackSnippetRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  update a feedback snippet
+// DESCRIPTION:
+// Only the author of the snippet and admins of the course can change it.
+func (rs *FeedbackSnippetResource) EditHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	snippet := r.Context().Value(symbol.CtxKeySnippet).(*model.FeedbackSnippet)
+
+	if !mayChangeSnippet(r, snippet) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	data := &FeedbackSnippetRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.validateTaskOfSnippet(course.ID, data.TaskID); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	snippet.TaskID = data.TaskID
+	snippet.Text = data.Text
+	snippet.PointsDelta = data.PointsDelta
+
+	if err := rs.Stores.Snippet.Update(snippet); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeleteHandler is public endpoint for
+// URL: /courses/{course_id}/snippets/{snippet_id}
+// URLPARAM: course_id,integer
+// URLPARAM: snippet_id,integer
+// METHOD: delete
+// TAG: snippets
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  delete a feedback snippet
+// DESCRIPTION:
+// Only the author of the snippet and admins of the course can delete it.
+func (rs *FeedbackSnippetResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	snippet := r.Context().Value(symbol.CtxKeySnippet).(*model.FeedbackSnippet)
+
+	if !mayChangeSnippet(r, snippet) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	if err := rs.Stores.Snippet.Delete(snippet.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// .............................................................................
+
+// Context middleware is used to load a FeedbackSnippet object from
+// the URL parameter `snippet_id` passed through as the request. In case
+// the FeedbackSnippet could not be found, we stop here and return a 404.
+// We do NOT check whether the identity is authorized to get this FeedbackSnippet.
+func (rs *FeedbackSnippetResource) Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+		var snippetID int64
+		var err error
+
+		// try to get id from URL
+		if snippetID, err = strconv.ParseInt(chi.URLParam(r, "snippet_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific snippet in database
+		snippet, err := rs.Stores.Snippet.Get(snippetID)
+		if err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		if snippet.CourseID != course.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), symbol.CtxKeySnippet, snippet)
+
+		// serve next
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,64 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// FeedbackSnippetResponse is the response payload for feedback snippets.
+type FeedbackSnippetResponse struct {
+	ID          int64    `json:"id" example:"1"`
+	TaskID      null.Int `json:"task_id" example:"2"`
+	AuthorID    null.Int `json:"author_id" example:"12"`
+	Text        string   `json:"text" example:"missing null check"`
+	PointsDelta int      `json:"points_delta" example:"-1"`
+	UsageCount  int      `json:"usage_count" example:"17"`
+}
+
+// Render post-processes a FeedbackSnippetResponse.
+func (body *FeedbackSnippetResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newFeedbackSnippetResponse creates a response from a FeedbackSnippet model.
+func newFeedbackSnippetResponse(p *model.FeedbackSnippet) *FeedbackSnippetResponse {
+	return &FeedbackSnippetResponse{
+		ID:          p.ID,
+		TaskID:      p.TaskID,
+		AuthorID:    p.AuthorID,
+		Text:        p.Text,
+		PointsDelta: p.PointsDelta,
+		UsageCount:  p.UsageCount,
+	}
+}
+
+// newFeedbackSnippetListResponse creates a response from a list of FeedbackSnippet models.
+func newFeedbackSnippetListResponse(snippets []model.FeedbackSnippet) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range snippets {
+		list = append(list, newFeedbackSnippetResponse(&snippets[k]))
+	}
+	return list
+}
@@ -0,0 +1,222 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise materials and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestFeedbackSnippet(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	studentJWT := tape.NewJWTRequest(112, false)
+	tutorJWT := tape.NewJWTRequest(2, false)
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	g.Describe("FeedbackSnippet", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+			_ = stores
+		})
+
+		g.It("Should require at least a tutor", func() {
+			w := tape.Get("/api/v1/courses/1/snippets", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/snippets", H{
+				"text": "missing null check",
+			}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
+		g.It("Should create, use and count snippets", func() {
+			task, err := stores.Grade.IdentifyTaskOfGrade(1)
+			g.Assert(err).Equal(nil)
+
+			sheet, err := stores.Task.IdentifySheetOfTask(task.ID)
+			g.Assert(err).Equal(nil)
+
+			tasks, err := stores.Task.TasksOfSheet(sheet.ID)
+			g.Assert(err).Equal(nil)
+
+			otherTaskID := int64(0)
+			for _, el := range tasks {
+				if el.ID != task.ID {
+					otherTaskID = el.ID
+				}
+			}
+			g.Assert(otherTaskID != 0).IsTrue()
+
+			w := tape.Post("/api/v1/courses/1/snippets", H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			courseSnippet := &FeedbackSnippetResponse{}
+			w = tape.Post("/api/v1/courses/1/snippets", H{
+				"text":         "-1 for style",
+				"points_delta": -1,
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			err = json.NewDecoder(w.Body).Decode(courseSnippet)
+			g.Assert(err).Equal(nil)
+			g.Assert(courseSnippet.TaskID.Valid).IsFalse()
+			g.Assert(courseSnippet.PointsDelta).Equal(-1)
+			g.Assert(courseSnippet.AuthorID.Int64).Equal(int64(2))
+
+			taskSnippet := &FeedbackSnippetResponse{}
+			w = tape.Post("/api/v1/courses/1/snippets", H{
+				"text":    "missing null check",
+				"task_id": task.ID,
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			err = json.NewDecoder(w.Body).Decode(taskSnippet)
+			g.Assert(err).Equal(nil)
+
+			otherSnippet := &FeedbackSnippetResponse{}
+			w = tape.Post("/api/v1/courses/1/snippets", H{
+				"text":    "wrong loop bound",
+				"task_id": otherTaskID,
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			err = json.NewDecoder(w.Body).Decode(otherSnippet)
+			g.Assert(err).Equal(nil)
+
+			// tasks of other courses cannot be used
+			w = tape.Post("/api/v1/courses/1/snippets", H{
+				"text":    "wrong loop bound",
+				"task_id": 999999,
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			snippets := []FeedbackSnippetResponse{}
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/snippets?task_id=%d", task.ID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&snippets)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(snippets)).Equal(2)
+
+			// snippets of other tasks are refused
+			w = tape.Put("/api/v1/courses/1/grades/1", H{
+				"acquired_points": 0,
+				"feedback":        "wrong loop bound",
+				"snippet_ids":     []int64{otherSnippet.ID},
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// using a snippet twice in the same grade counts only once
+			for i := 0; i < 2; i++ {
+				w = tape.Put("/api/v1/courses/1/grades/1", H{
+					"acquired_points": 0,
+					"feedback":        "missing null check",
+					"snippet_ids":     []int64{taskSnippet.ID},
+				}, tutorJWT)
+				g.Assert(w.Code).Equal(http.StatusNoContent)
+			}
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/snippets?task_id=%d", task.ID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&snippets)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(snippets)).Equal(2)
+			g.Assert(snippets[0].ID).Equal(taskSnippet.ID)
+			g.Assert(snippets[0].UsageCount).Equal(1)
+			g.Assert(snippets[1].UsageCount).Equal(0)
+
+			w = tape.Get("/api/v1/courses/1/snippets", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&snippets)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(snippets)).Equal(3)
+
+			// the grade is not changed when the usage cannot be stored
+			_, err = tape.DB.Exec(`
+CREATE FUNCTION fail_insert() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'failed on purpose';
+END $$ LANGUAGE plpgsql;
+CREATE TRIGGER fail_usages BEFORE INSERT ON feedback_snippet_usages FOR EACH ROW EXECUTE PROCEDURE fail_insert();`)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Put("/api/v1/courses/1/grades/1", H{
+				"acquired_points": 0,
+				"feedback":        "-1 for style",
+				"snippet_ids":     []int64{courseSnippet.ID},
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusInternalServerError)
+
+			_, err = tape.DB.Exec("DROP TRIGGER fail_usages ON feedback_snippet_usages; DROP FUNCTION fail_insert();")
+			g.Assert(err).Equal(nil)
+
+			gradeAfter, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.Feedback).Equal("missing null check")
+		})
+
+		g.It("Should only allow authors and admins to change snippets", func() {
+			snippet := &FeedbackSnippetResponse{}
+			w := tape.Post("/api/v1/courses/1/snippets", H{
+				"text": "-1 for style",
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+			err := json.NewDecoder(w.Body).Decode(snippet)
+			g.Assert(err).Equal(nil)
+
+			url := fmt.Sprintf("/api/v1/courses/1/snippets/%d", snippet.ID)
+
+			w = tape.Put(url, H{"text": "-2 for style"}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Delete(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put(url, H{"text": "-2 for style", "points_delta": -2}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			entry, err := stores.Snippet.Get(snippet.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(entry.Text).Equal("-2 for style")
+			g.Assert(entry.PointsDelta).Equal(-2)
+
+			w = tape.Delete(url, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
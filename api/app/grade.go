@@ -21,9 +21,11 @@ package app
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"net/http"
+	"path/filepath"
 	"strconv"
 
 	"github.com/go-chi/chi"
@@ -33,6 +35,7 @@ import (
 	"github.com/infomark-org/infomark/auth/authorize"
 	"github.com/infomark-org/infomark/model"
 	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // GradeResource specifies Grade management handler.
@@ -60,6 +63,7 @@ func NewGradeResource(stores *Stores) *GradeResource {
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  edit a grade
 func (rs *GradeResource) EditHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 
 	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
@@ -76,18 +80,46 @@ func (rs *GradeResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if data.AcquiredPoints > task.MaxPoints {
-		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("acquired points is larger than max-points %v is more than %v", data.AcquiredPoints, task.MaxPoints)))
+	if data.AcquiredPoints > task.MaxAcquirablePoints() {
+		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("acquired points is larger than max-points %v is more than %v", data.AcquiredPoints, task.MaxAcquirablePoints())))
+		return
+	}
+
+	if err := checkGradingIndependence(rs.Stores, task, currentGrade, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
 		return
 	}
 
+	for _, snippetID := range data.SnippetIDs {
+		snippet, err := rs.Stores.Snippet.Get(snippetID)
+		if err != nil || snippet.CourseID != course.ID || (snippet.TaskID.Valid && snippet.TaskID.Int64 != task.ID) {
+			render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("feedback snippet %v cannot be used for this task", snippetID)))
+			return
+		}
+	}
+
+	previousGrade := *currentGrade
+
 	currentGrade.Feedback = data.Feedback
 	currentGrade.AcquiredPoints = data.AcquiredPoints
 
 	currentGrade.TutorID = accessClaims.LoginID
 
-	// update database entry
-	if err := rs.Stores.Grade.Update(currentGrade); err != nil {
+	// update database entry together with the audit trail and the used snippets
+	err = rs.Stores.Transaction(func(tx *Stores) error {
+		if err := tx.Grade.UpdateWithHistory(currentGrade, model.NewGradeHistory(
+			&previousGrade, currentGrade, accessClaims.LoginID, symbol.GradeChangeManual)); err != nil {
+			return err
+		}
+
+		for _, snippetID := range data.SnippetIDs {
+			if err := tx.Snippet.RecordUsage(snippetID, currentGrade.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
@@ -106,9 +138,33 @@ func (rs *GradeResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  get a grade
+// DESCRIPTION:
+// Tutors only see a pseudonym of the student when the sheet uses blind grading
+// and the grades are not released yet. For tasks with two independent gradings
+// other tutors than the first grader only see the points and feedback of the
+// first grading once they have given the second grading.
 func (rs *GradeResource) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	blindSheets, err := blindSheetsOfCourse(rs.Stores, course.ID, givenRole)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if blindSheets[currentGrade.SheetID] {
+		hideIdentity(currentGrade, course.ID)
+	}
+
+	grades := []model.Grade{*currentGrade}
+	if err := hideFirstGradings(rs.Stores, course.ID, grades, givenRole, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	currentGrade = &grades[0]
 
 	// return Material information of created entry
 	if err := render.Render(w, r, newGradeResponse(currentGrade, course.ID)); err != nil {
@@ -119,6 +175,35 @@ func (rs *GradeResource) GetByIDHandler(w http.ResponseWriter, r *http.Request)
 	render.Status(r, http.StatusNoContent)
 }
 
+// HistoryHandler is public endpoint for
+// URL: /courses/{course_id}/grades/{grade_id}/history
+// URLPARAM: course_id,integer
+// URLPARAM: grade_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,GradeHistoryResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get all recorded changes of a grade
+func (rs *GradeResource) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+
+	entries, err := rs.Stores.Grade.GetHistory(currentGrade.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newGradeHistoryListResponse(entries)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
 // PublicResultEditHandler is public endpoint for
 // URL: /courses/{course_id}/grades/{grade_id}/public_result
 // URLPARAM: course_id,integer
@@ -132,6 +217,7 @@ func (rs *GradeResource) GetByIDHandler(w http.ResponseWriter, r *http.Request)
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  update information for grade from background worker
 func (rs *GradeResource) PublicResultEditHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 
 	data := &GradeFromWorkerRequest{}
 	// parse JSON request into struct
@@ -185,8 +271,12 @@ func (rs *GradeResource) PublicResultEditHandler(w http.ResponseWriter, r *http.
 
 	render.Status(r, http.StatusNoContent)
 
+	updatedGrade := *currentGrade
+	updatedGrade.PublicTestStatus = int(data.Status)
+
 	// update database entry
-	if err := rs.Stores.Grade.UpdatePublicTestInfo(currentGrade.ID, data.Log, data.Status); err != nil {
+	if err := rs.Stores.Grade.UpdatePublicTestInfo(currentGrade.ID, data.Log, data.Status, model.NewGradeHistory(
+		currentGrade, &updatedGrade, accessClaims.LoginID, symbol.GradeChangePublicTest)); err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
@@ -206,6 +296,7 @@ func (rs *GradeResource) PublicResultEditHandler(w http.ResponseWriter, r *http.
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  update information for grade from background worker
 func (rs *GradeResource) PrivateResultEditHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 
 	data := &GradeFromWorkerRequest{}
 	// parse JSON request into struct
@@ -264,8 +355,12 @@ func (rs *GradeResource) PrivateResultEditHandler(w http.ResponseWriter, r *http
 
 	render.Status(r, http.StatusNoContent)
 
+	updatedGrade := *currentGrade
+	updatedGrade.PrivateTestStatus = int(data.Status)
+
 	// update database entry
-	if err := rs.Stores.Grade.UpdatePrivateTestInfo(currentGrade.ID, data.Log, data.Status); err != nil {
+	if err := rs.Stores.Grade.UpdatePrivateTestInfo(currentGrade.ID, data.Log, data.Status, model.NewGradeHistory(
+		currentGrade, &updatedGrade, accessClaims.LoginID, symbol.GradeChangePrivateTest)); err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
@@ -293,8 +388,15 @@ func (rs *GradeResource) PrivateResultEditHandler(w http.ResponseWriter, r *http
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  Query grades in a course
+// DESCRIPTION:
+// Tutors only see pseudonyms of the students in sheets which use blind grading
+// and have no released grades yet. For the same reason tutors cannot filter by
+// user_id as long as such a sheet exists. The first grading of tasks with two
+// independent gradings is hidden just like for a single grade.
 func (rs *GradeResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 
 	filterSheetID := helper.Int64FromURL(r, "sheet_id", 0)
 	filterTaskID := helper.Int64FromURL(r, "task_id", 0)
@@ -314,6 +416,17 @@ func (rs *GradeResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 	filterPublicExecutationState := helper.IntFromURL(r, "public_execution_state", -1)
 	filterPrivateExecutationState := helper.IntFromURL(r, "private_execution_state", -1)
 
+	blindSheets, err := blindSheetsOfCourse(rs.Stores, course.ID, givenRole)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if userFilterRevealsIdentity(blindSheets, filterUserID, filterSheetID) {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("user_id cannot be used while a sheet is graded blindly")))
+		return
+	}
+
 	submissions, err := rs.Stores.Grade.GetFiltered(
 		course.ID,
 		filterSheetID,
@@ -333,6 +446,17 @@ func (rs *GradeResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for k := range submissions {
+		if blindSheets[submissions[k].SheetID] {
+			hideIdentity(&submissions[k], course.ID)
+		}
+	}
+
+	if err := hideFirstGradings(rs.Stores, course.ID, submissions, givenRole, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	// render JSON response
 	if err = render.RenderList(w, r, newGradeListResponse(submissions, course.ID)); err != nil {
 		render.Render(w, r, ErrRender(err))
@@ -356,6 +480,8 @@ func (rs *GradeResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 // SUMMARY:  Query grades in a course
 // DESCRIPTION:
 // {"sheets":[{"id":179,"name":"1"},{"id":180,"name":"2"}],"achievements":[{"user_info":{"id":42,"first_name":"Sören","last_name":"Haase","student_number":"1161"},"points":[5,0]},{"user_info":{"id":43,"first_name":"Resi","last_name":"Naser","student_number":"1000"},"points":[8,7]}]}
+// Sheets which are graded blindly are left out for tutors until their grades
+// are released, as the points would reveal whom a submission belongs to.
 func (rs *GradeResource) IndexSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
 	filterGroupID := helper.Int64FromURL(r, "group_id", 0)
@@ -374,8 +500,21 @@ func (rs *GradeResource) IndexSummaryHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	blindSheets, err := blindSheetsOfCourse(rs.Stores, course.ID, givenRole)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	visibleSheets := []model.Sheet{}
+	for _, sheet := range sheets {
+		if !blindSheets[sheet.ID] {
+			visibleSheets = append(visibleSheets, sheet)
+		}
+	}
+
 	// render JSON response
-	if err = render.Render(w, r, newGradeOverviewResponse(grades, sheets, givenRole)); err != nil {
+	if err = render.Render(w, r, newGradeOverviewResponse(grades, visibleSheets, givenRole)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -398,6 +537,7 @@ func (rs *GradeResource) IndexSummaryHandler(w http.ResponseWriter, r *http.Requ
 func (rs *GradeResource) IndexMissingHandler(w http.ResponseWriter, r *http.Request) {
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
 
 	filterGroupID := helper.Int64FromURL(r, "group_id", 0)
 
@@ -407,6 +547,18 @@ func (rs *GradeResource) IndexMissingHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	blindSheets, err := blindSheetsOfCourse(rs.Stores, course.ID, givenRole)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	for k := range grades {
+		if blindSheets[grades[k].SheetID] {
+			hideIdentity(&grades[k].Grade, course.ID)
+		}
+	}
+
 	// render JSON response
 	if err = render.RenderList(w, r, newMissingGradeListResponse(grades)); err != nil {
 		render.Render(w, r, ErrRender(err))
@@ -417,6 +569,656 @@ func (rs *GradeResource) IndexMissingHandler(w http.ResponseWriter, r *http.Requ
 
 }
 
+// ExportHandler is public endpoint for
+// URL: /courses/{course_id}/grades/export
+// URLPARAM: course_id,integer
+// QUERYPARAM: format,string
+// QUERYPARAM: group_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,SpreadsheetFile
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  export the points of all students in a course as csv or xlsx
+// DESCRIPTION:
+// The format is either "csv" (default) or "xlsx". Each row contains a student
+// with the group, the points per task, the total per sheet and the total of the course.
+// The csv file can be imported again after changing the points.
+func (rs *GradeResource) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	format := helper.StringFromURL(r, "format", "csv")
+	filterGroupID := helper.Int64FromURL(r, "group_id", 0)
+
+	if format != "csv" && format != "xlsx" {
+		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("unknown format %s", format)))
+		return
+	}
+
+	table, err := BuildGradeExport(rs.Stores, course.ID, filterGroupID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"infomark-course%d-grades.%s\"", course.ID, format))
+
+	if format == "xlsx" {
+		w.Header().Set("Content-Type", helper.XLSXContentType)
+		if err := helper.WriteXLSX(w, "grades", table); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(table); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+}
+
+// ImportHandler is public endpoint for
+// URL: /courses/{course_id}/grades/import
+// URLPARAM: course_id,integer
+// QUERYPARAM: dry_run,bool
+// METHOD: post
+// TAG: grades
+// REQUEST: Csvfile
+// RESPONSE: 200,GradeImportChangeResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  bulk-update points and feedback of grades from a csv file
+// DESCRIPTION:
+// The csv file is uploaded as "file_data" and requires the columns user_id (or
+// student_number), task_id, acquired_points and optionally feedback. Instead, the
+// csv file of the grade export can be imported with one column per task.
+// Nothing is imported if any line is invalid.
+// Tutors can only change grades assigned to them or not assigned yet.
+// Setting dry_run=true only returns the changes which would be applied.
+func (rs *GradeResource) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	dryRun := helper.StringFromURL(r, "dry_run", "false") == "true"
+
+	file, _, err := r.FormFile("file_data")
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	defer file.Close()
+
+	rows, err := ParseGradeImport(file)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	changes, err := PlanGradeImport(rs.Stores, course.ID, rows,
+		accessClaims.LoginID, givenRole == authorize.ADMIN)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if !dryRun {
+		if err := ApplyGradeImport(rs.Stores, changes, accessClaims.LoginID); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	if err := render.RenderList(w, r, newGradeImportChangeListResponse(changes)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// BatchHandler is public endpoint for
+// URL: /courses/{course_id}/grades/batch
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: grades
+// REQUEST: GradeBatchRequest
+// RESPONSE: 200,GradeBatchResultResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  update points and feedback of many grades at once
+// DESCRIPTION:
+// All items are validated first and stored in a single transaction. If any item
+// is invalid, nothing is stored and the result of every item is returned with
+// status 400. Tutors can only change grades assigned to them or not assigned yet.
+func (rs *GradeResource) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	data := &GradeBatchRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	results := []GradeBatchResult{}
+	errBatchInvalid := errors.New("the batch contains invalid items")
+
+	// validating and storing happens atomically, as the grades stay locked
+	err := rs.Stores.Transaction(func(tx *Stores) error {
+		var grades []model.Grade
+		var histories []model.GradeHistory
+		var valid bool
+
+		results, grades, histories, valid = PlanGradeBatch(tx, course.ID, data.Grades,
+			accessClaims.LoginID, givenRole == authorize.ADMIN)
+		if !valid {
+			return errBatchInvalid
+		}
+		return tx.Grade.UpdateBatch(grades, histories)
+	})
+
+	if err == errBatchInvalid {
+		render.Status(r, http.StatusBadRequest)
+		if err := render.RenderList(w, r, newGradeBatchResultListResponse(results)); err != nil {
+			render.Render(w, r, ErrRender(err))
+		}
+		return
+	}
+
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newGradeBatchResultListResponse(results)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// ProgressHandler is public endpoint for
+// URL: /courses/{course_id}/grades/progress
+// URLPARAM: course_id,integer
+// QUERYPARAM: sheet_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,GradingProgressResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  the grading progress of all tutors in a course
+// DESCRIPTION:
+// A grade counts as done once the tutor has written a feedback. A tutor id of 1
+// collects all grades which are neither assigned to nor graded by a tutor.
+func (rs *GradeResource) ProgressHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	filterSheetID := helper.Int64FromURL(r, "sheet_id", 0)
+
+	progress, err := rs.Stores.Grade.GetGradingProgress(course.ID, filterSheetID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newGradingProgressListResponse(progress)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// ReassignHandler is public endpoint for
+// URL: /courses/{course_id}/grades/reassign
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: grades
+// REQUEST: GradeReassignRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  move all unfinished grades of a tutor to another tutor
+// DESCRIPTION:
+// Only grades without a feedback are moved. A sheet_id of 0 moves the grades
+// of all sheets.
+func (rs *GradeResource) ReassignHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	data := &GradeReassignRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.ensureGradingTutor(data.ToTutorID, course.ID); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	grades, err := rs.Stores.Grade.GetUnfinishedOfTutor(course.ID, data.SheetID, data.FromTutorID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	for k := range grades {
+		if err := rs.changeTutor(&grades[k], data.ToTutorID, accessClaims.LoginID); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// ChangeTutorHandler is public endpoint for
+// URL: /courses/{course_id}/grades/{grade_id}/tutor
+// URLPARAM: course_id,integer
+// URLPARAM: grade_id,integer
+// METHOD: put
+// TAG: grades
+// REQUEST: GradeTutorRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  assign a grade to another tutor
+func (rs *GradeResource) ChangeTutorHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	data := &GradeTutorRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.ensureGradingTutor(data.TutorID, course.ID); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.changeTutor(currentGrade, data.TutorID, accessClaims.LoginID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// GetSecondHandler is public endpoint for
+// URL: /courses/{course_id}/grades/{grade_id}/second
+// URLPARAM: course_id,integer
+// URLPARAM: grade_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,SecondGradingResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get the second grading of a grade
+// DESCRIPTION:
+// The first grader cannot see the second grading. Only admins see the points of
+// both gradings.
+func (rs *GradeResource) GetSecondHandler(w http.ResponseWriter, r *http.Request) {
+	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	if givenRole != authorize.ADMIN && currentGrade.TutorID == accessClaims.LoginID {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	second, err := rs.Stores.Grade.GetSecondGrading(currentGrade.ID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	if err := render.Render(w, r, newSecondGradingResponse(second, givenRole == authorize.ADMIN)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// EditSecondHandler is public endpoint for
+// URL: /courses/{course_id}/grades/{grade_id}/second
+// URLPARAM: course_id,integer
+// URLPARAM: grade_id,integer
+// METHOD: put
+// TAG: grades
+// REQUEST: GradeRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  grade a submission a second time
+// DESCRIPTION:
+// This is only possible for tasks requiring two independent gradings. The
+// second grader must not be the first grader. Once given, the second grading
+// can only be changed by the second grader. Changing the second grading
+// requires a new reconciliation.
+func (rs *GradeResource) EditSecondHandler(w http.ResponseWriter, r *http.Request) {
+	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	data := &GradeRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	task, err := rs.Stores.Grade.IdentifyTaskOfGrade(currentGrade.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if !task.DoubleGrading {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("this task does not require a second grading")))
+		return
+	}
+
+	if currentGrade.TutorID == accessClaims.LoginID {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("the first grader cannot grade a second time")))
+		return
+	}
+
+	if data.AcquiredPoints > task.MaxAcquirablePoints() {
+		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("acquired points is larger than max-points %v is more than %v", data.AcquiredPoints, task.MaxAcquirablePoints())))
+		return
+	}
+
+	second, err := rs.Stores.Grade.GetSecondGrading(currentGrade.ID)
+	if err == nil && second.TutorID != accessClaims.LoginID {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	if err := rs.Stores.Grade.SetSecondGrading(&model.SecondGrading{
+		GradeID:        currentGrade.ID,
+		TutorID:        accessClaims.LoginID,
+		AcquiredPoints: data.AcquiredPoints,
+		Feedback:       data.Feedback,
+	}); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// ReconciliationsHandler is public endpoint for
+// URL: /courses/{course_id}/grades/reconciliations
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,SecondGradingResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  all double gradings whose points differ beyond the threshold of the task
+func (rs *GradeResource) ReconciliationsHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	gradings, err := rs.Stores.Grade.GetSecondGradingsOfCourse(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	open := []model.SecondGrading{}
+	for _, grading := range gradings {
+		if grading.NeedsReconciliation() {
+			open = append(open, grading)
+		}
+	}
+
+	if err := render.RenderList(w, r, newSecondGradingListResponse(open, true)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// ReconcileHandler is public endpoint for
+// URL: /courses/{course_id}/grades/{grade_id}/reconcile
+// URLPARAM: course_id,integer
+// URLPARAM: grade_id,integer
+// METHOD: put
+// TAG: grades
+// REQUEST: GradeReconcileRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  decide on the final points of a grade with two gradings
+// DESCRIPTION:
+// The agreed points are stored in the grade itself and hence count for all
+// points summaries. Without a feedback the feedback of the first grader is kept.
+func (rs *GradeResource) ReconcileHandler(w http.ResponseWriter, r *http.Request) {
+	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	data := &GradeReconcileRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	second, err := rs.Stores.Grade.GetSecondGrading(currentGrade.ID)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("this grade has no second grading")))
+		return
+	}
+
+	task, err := rs.Stores.Grade.IdentifyTaskOfGrade(currentGrade.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if data.AcquiredPoints > task.MaxAcquirablePoints() {
+		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("acquired points is larger than max-points %v is more than %v", data.AcquiredPoints, task.MaxAcquirablePoints())))
+		return
+	}
+
+	previousGrade := *currentGrade
+	currentGrade.AcquiredPoints = data.AcquiredPoints
+	if data.Feedback != "" {
+		currentGrade.Feedback = data.Feedback
+	}
+
+	// the final points and the reconciliation are stored together
+	if err := rs.Stores.Transaction(func(tx *Stores) error {
+		if err := tx.Grade.UpdateWithHistory(currentGrade, model.NewGradeHistory(
+			&previousGrade, currentGrade, accessClaims.LoginID, symbol.GradeChangeReconcile)); err != nil {
+			return err
+		}
+
+		// updating the grade has reset the reconciliation of the second grading
+		second.Reconciled = true
+		second.ReconciledBy = null.IntFrom(accessClaims.LoginID)
+		return tx.Grade.UpdateSecondGrading(second)
+	}); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// GetFeedbackFileHandler is public endpoint for
+// URL: /courses/{course_id}/grades/{grade_id}/feedback_file
+// URLPARAM: course_id,integer
+// URLPARAM: grade_id,integer
+// METHOD: get
+// TAG: grades
+// RESPONSE: 200,ZipFile
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get the file a tutor attached to the feedback of a grade
+// DESCRIPTION:
+// Students can only download the file of their own grade after the grades of
+// the sheet have been released.
+func (rs *GradeResource) GetFeedbackFileHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	if givenRole == authorize.STUDENT {
+		if currentGrade.UserID != accessClaims.LoginID {
+			render.Render(w, r, ErrUnauthorized)
+			return
+		}
+
+		sheet, err := rs.Stores.Sheet.Get(currentGrade.SheetID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		if !GradesReleasedYet(sheet) {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+	}
+
+	hnd := helper.NewGradeFeedbackFileHandle(currentGrade.ID)
+	if !hnd.Exists() {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	publicFilename := fmt.Sprintf("%s-feedback-%d%s", course.Name, currentGrade.TaskID, filepath.Ext(hnd.Path()))
+	if err := hnd.WriteToBodyWithName(publicFilename, w); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+	}
+}
+
+// ChangeFeedbackFileHandler is public endpoint for
+// URL: /courses/{course_id}/grades/{grade_id}/feedback_file
+// URLPARAM: course_id,integer
+// URLPARAM: grade_id,integer
+// METHOD: post
+// TAG: grades
+// REQUEST: Zipfile
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  attach a file to the feedback of a grade
+// DESCRIPTION:
+// This endpoint will only support pdf or zip files. An existing file is replaced.
+// Tutors can only attach files to grades assigned to them or not assigned yet.
+func (rs *GradeResource) ChangeFeedbackFileHandler(w http.ResponseWriter, r *http.Request) {
+	currentGrade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	if err := checkGradeAccess(currentGrade, accessClaims.LoginID, givenRole == authorize.ADMIN); err != nil {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	if _, err := helper.NewGradeFeedbackFileHandle(currentGrade.ID).WriteToDisk(r, "file_data"); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// ensureGradingTutor verifies that a user is allowed to grade in a course.
+func (rs *GradeResource) ensureGradingTutor(tutorID int64, courseID int64) error {
+	role, err := rs.Stores.Course.RoleInCourse(tutorID, courseID)
+	if err != nil || role == authorize.STUDENT {
+		return fmt.Errorf("user %v is not a tutor in this course", tutorID)
+	}
+	return nil
+}
+
+// hideFirstGradings removes the points and feedback of the first grading in
+// tasks with two independent gradings, unless the tutor is the first grader or
+// has already given the second grading. Otherwise the second grading would not
+// be independent.
+func hideFirstGradings(stores *Stores, courseID int64, grades []model.Grade, givenRole authorize.CourseRole, viewerID int64) error {
+	if givenRole != authorize.TUTOR || len(grades) == 0 {
+		return nil
+	}
+
+	seconds, err := stores.Grade.GetSecondGradingsOfCourse(courseID)
+	if err != nil {
+		return err
+	}
+	secondGraderOf := make(map[int64]int64)
+	for _, second := range seconds {
+		secondGraderOf[second.GradeID] = second.TutorID
+	}
+
+	doubleGrading := make(map[int64]bool)
+	for k := range grades {
+		enabled, ok := doubleGrading[grades[k].TaskID]
+		if !ok {
+			task, err := stores.Task.Get(grades[k].TaskID)
+			if err != nil {
+				return err
+			}
+			enabled = task.DoubleGrading
+			doubleGrading[task.ID] = enabled
+		}
+
+		if !enabled || grades[k].TutorID == viewerID || secondGraderOf[grades[k].ID] == viewerID {
+			continue
+		}
+		grades[k].AcquiredPoints = 0
+		grades[k].Feedback = ""
+	}
+	return nil
+}
+
+// hideIdentity replaces the personal data of the student by a pseudonym. The
+// id is removed as well, as it could be matched against the enrollments.
+func hideIdentity(p *model.Grade, courseID int64) {
+	p.UserFirstName = Pseudonym(courseID, p.UserID)
+	p.UserLastName = ""
+	p.UserEmail = ""
+	p.UserID = 0
+}
+
+// changeTutor assigns a grade to another tutor and records this change.
+func (rs *GradeResource) changeTutor(grade *model.Grade, tutorID int64, actorID int64) error {
+	previousGrade := *grade
+	grade.AssignedTutorID = null.IntFrom(tutorID)
+
+	return rs.Stores.Grade.UpdateWithHistory(grade, model.NewGradeHistory(
+		&previousGrade, grade, actorID, symbol.GradeChangeReassignment))
+}
+
 // .............................................................................
 
 // Context middleware is used to load an Grade object from
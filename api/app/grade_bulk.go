@@ -0,0 +1,509 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+// GradeImportRow is a single grade of a csv file to bulk-update grades. If the
+// file identifies students by their student number, the UserID is 0.
+type GradeImportRow struct {
+	Line           int
+	UserID         int64
+	StudentNumber  string
+	TaskID         int64
+	AcquiredPoints int
+	Feedback       string
+	HasFeedback    bool
+}
+
+// GradeImportChange describes how a grade changes when importing a row.
+type GradeImportChange struct {
+	GradeID           int64
+	UserID            int64
+	TaskID            int64
+	OldAcquiredPoints int
+	NewAcquiredPoints int
+	OldFeedback       string
+	NewFeedback       string
+}
+
+// exportTaskColumnPattern matches the header of a task column in the grade
+// export and captures the id of the task.
+var exportTaskColumnPattern = regexp.MustCompile(`\[task (\d+)\]$`)
+
+// exportTaskColumn is the header of the points of a task in the grade export.
+func exportTaskColumn(sheet *model.Sheet, task *model.Task) string {
+	return fmt.Sprintf("%s: %s [task %d]", sheet.Name, task.Name, task.ID)
+}
+
+// ParseGradeImport reads a csv file (the first line is the header) in one of
+// two layouts. Either each line contains a single grade with the columns
+// "task_id", "acquired_points" and optionally "feedback", or each line contains
+// all points of a student just like the grade export, one column per task.
+// Empty points in the latter are skipped. Students are identified by the
+// column "user_id" or, if it is missing, by "student_number".
+func ParseGradeImport(r io.Reader) ([]GradeImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header: %v", err)
+	}
+
+	columns := make(map[string]int)
+	// taskColumns[column] = taskID in the layout of the grade export
+	taskColumns := make(map[int]int64)
+	for k, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = k
+		if match := exportTaskColumnPattern.FindStringSubmatch(strings.TrimSpace(name)); match != nil {
+			taskID, err := strconv.ParseInt(match[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("column %s has an invalid task id", name)
+			}
+			taskColumns[k] = taskID
+		}
+	}
+
+	userColumn, byUserID := columns["user_id"]
+	studentNumberColumn, byStudentNumber := columns["student_number"]
+	if !byUserID && !byStudentNumber {
+		return nil, errors.New("column user_id or student_number is missing")
+	}
+
+	_, hasTaskID := columns["task_id"]
+	_, hasAcquiredPoints := columns["acquired_points"]
+	perGrade := hasTaskID || hasAcquiredPoints || len(taskColumns) == 0
+	if perGrade {
+		for _, name := range []string{"task_id", "acquired_points"} {
+			if _, ok := columns[name]; !ok {
+				return nil, fmt.Errorf("column %s is missing", name)
+			}
+		}
+	}
+	feedbackColumn, hasFeedback := columns["feedback"]
+
+	rows := []GradeImportRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		student := GradeImportRow{Line: line}
+		if byUserID {
+			if student.UserID, err = strconv.ParseInt(strings.TrimSpace(record[userColumn]), 10, 64); err != nil {
+				return nil, fmt.Errorf("line %d: user_id is not a number", line)
+			}
+		} else {
+			student.StudentNumber = strings.TrimSpace(record[studentNumberColumn])
+			if student.StudentNumber == "" {
+				return nil, fmt.Errorf("line %d: student_number is empty", line)
+			}
+		}
+
+		if !perGrade {
+			for column, taskID := range taskColumns {
+				value := strings.TrimSpace(record[column])
+				if value == "" {
+					continue
+				}
+
+				row := student
+				row.TaskID = taskID
+				if row.AcquiredPoints, err = strconv.Atoi(value); err != nil {
+					return nil, fmt.Errorf("line %d: points of task %d are not a number", line, taskID)
+				}
+				rows = append(rows, row)
+			}
+			continue
+		}
+
+		row := student
+		row.HasFeedback = hasFeedback
+		if row.TaskID, err = strconv.ParseInt(strings.TrimSpace(record[columns["task_id"]]), 10, 64); err != nil {
+			return nil, fmt.Errorf("line %d: task_id is not a number", line)
+		}
+		if row.AcquiredPoints, err = strconv.Atoi(strings.TrimSpace(record[columns["acquired_points"]])); err != nil {
+			return nil, fmt.Errorf("line %d: acquired_points is not a number", line)
+		}
+		if hasFeedback {
+			row.Feedback = record[feedbackColumn]
+		}
+
+		rows = append(rows, row)
+	}
+
+	// the columns of a map are visited in random order
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Line != rows[j].Line {
+			return rows[i].Line < rows[j].Line
+		}
+		return rows[i].TaskID < rows[j].TaskID
+	})
+
+	return rows, nil
+}
+
+// PlanGradeImport validates all rows against the grades of a course and
+// returns the changes without touching the database. Rows which would not
+// change a grade are skipped. Just like in a batch update, tutors can only
+// change grades which are assigned to them or which are not assigned to anyone
+// yet. If any row is invalid, nothing should be imported.
+func PlanGradeImport(stores *Stores, courseID int64, rows []GradeImportRow, actorID int64, isAdmin bool) ([]GradeImportChange, error) {
+	tasks := make(map[int64]model.Task)
+
+	students, err := stores.Grade.GetExportStudents(courseID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	studentOfNumber := make(map[string]int64)
+	for _, student := range students {
+		if student.UserStudentNumber != "" {
+			studentOfNumber[student.UserStudentNumber] = student.UserID
+		}
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sheet := range sheets {
+		tasksOfSheet, err := stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasksOfSheet {
+			tasks[task.ID] = task
+		}
+	}
+
+	changes := []GradeImportChange{}
+	problems := []string{}
+	seen := make(map[[2]int64]int)
+
+	for _, row := range rows {
+		if row.UserID == 0 {
+			userID, ok := studentOfNumber[row.StudentNumber]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("line %d: no student with the student number %s in the course",
+					row.Line, row.StudentNumber))
+				continue
+			}
+			row.UserID = userID
+		}
+
+		task, ok := tasks[row.TaskID]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("line %d: task %d does not belong to the course", row.Line, row.TaskID))
+			continue
+		}
+
+		if row.AcquiredPoints < 0 || row.AcquiredPoints > task.MaxAcquirablePoints() {
+			problems = append(problems, fmt.Sprintf("line %d: acquired points %d are not between 0 and %d",
+				row.Line, row.AcquiredPoints, task.MaxAcquirablePoints()))
+			continue
+		}
+
+		key := [2]int64{row.UserID, row.TaskID}
+		if previousLine, ok := seen[key]; ok {
+			problems = append(problems, fmt.Sprintf("line %d: duplicates line %d", row.Line, previousLine))
+			continue
+		}
+		seen[key] = row.Line
+
+		grade, err := stores.Grade.GetOfUserAndTask(row.UserID, row.TaskID)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: user %d has no submission for task %d",
+				row.Line, row.UserID, row.TaskID))
+			continue
+		}
+
+		change := GradeImportChange{
+			GradeID:           grade.ID,
+			UserID:            row.UserID,
+			TaskID:            row.TaskID,
+			OldAcquiredPoints: grade.AcquiredPoints,
+			NewAcquiredPoints: row.AcquiredPoints,
+			OldFeedback:       grade.Feedback,
+			NewFeedback:       grade.Feedback,
+		}
+		if row.HasFeedback {
+			change.NewFeedback = row.Feedback
+		}
+
+		// an unchanged export can be imported again by everybody
+		if change.OldAcquiredPoints == change.NewAcquiredPoints && change.OldFeedback == change.NewFeedback {
+			continue
+		}
+
+		if err := checkGradeAccess(grade, actorID, isAdmin); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", row.Line, err))
+			continue
+		}
+
+		if err := checkGradingIndependence(stores, &task, grade, actorID); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", row.Line, err))
+			continue
+		}
+
+		changes = append(changes, change)
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	return changes, nil
+}
+
+// ApplyGradeImport writes the planned changes in a single transaction and
+// records them in the history of each grade. Either all changes are stored or
+// none.
+func ApplyGradeImport(stores *Stores, changes []GradeImportChange, actorID int64) error {
+	grades := []model.Grade{}
+	histories := []model.GradeHistory{}
+
+	for _, change := range changes {
+		grade, err := stores.Grade.Get(change.GradeID)
+		if err != nil {
+			return err
+		}
+
+		previousGrade := *grade
+		grade.AcquiredPoints = change.NewAcquiredPoints
+		grade.Feedback = change.NewFeedback
+
+		// by definition user with id 1 is the system, which keeps the tutor who
+		// graded before
+		if actorID != 1 {
+			grade.TutorID = actorID
+		}
+
+		grades = append(grades, *grade)
+		histories = append(histories, *model.NewGradeHistory(
+			&previousGrade, grade, actorID, symbol.GradeChangeImport))
+	}
+
+	return stores.Grade.UpdateBatch(grades, histories)
+}
+
+// BuildGradeExport creates a table with one row per student containing the
+// points of every task, the total of every sheet and the total of the course.
+// Tasks without a submission are left empty. The table can be imported again
+// by ParseGradeImport.
+func BuildGradeExport(stores *Stores, courseID int64, groupID int64) ([][]string, error) {
+	students, err := stores.Grade.GetExportStudents(courseID, groupID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := stores.Grade.GetExportPoints(courseID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make(map[[2]int64]int)
+	for _, entry := range entries {
+		points[[2]int64{entry.UserID, entry.TaskID}] = entry.AcquiredPoints
+	}
+
+	sheets, err := stores.Sheet.SheetsOfCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	header := []string{"user_id", "student_number", "last_name", "first_name", "email", "group_id", "group"}
+	tasksOfSheets := make([][]model.Task, len(sheets))
+	for k, sheet := range sheets {
+		tasksOfSheets[k], err = stores.Task.TasksOfSheet(sheet.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasksOfSheets[k] {
+			header = append(header, exportTaskColumn(&sheet, &task))
+		}
+		header = append(header, fmt.Sprintf("%s: total", sheet.Name))
+	}
+	header = append(header, "total")
+
+	table := [][]string{header}
+	for _, student := range students {
+		row := []string{
+			strconv.FormatInt(student.UserID, 10),
+			student.UserStudentNumber,
+			student.UserLastName,
+			student.UserFirstName,
+			student.UserEmail,
+			strconv.FormatInt(student.GroupID, 10),
+			student.GroupDescription,
+		}
+
+		courseTotal := 0
+		for k := range sheets {
+			sheetTotal := 0
+			for _, task := range tasksOfSheets[k] {
+				acquired, ok := points[[2]int64{student.UserID, task.ID}]
+				if !ok {
+					row = append(row, "")
+					continue
+				}
+				sheetTotal += acquired
+				row = append(row, strconv.Itoa(acquired))
+			}
+			courseTotal += sheetTotal
+			row = append(row, strconv.Itoa(sheetTotal))
+		}
+		row = append(row, strconv.Itoa(courseTotal))
+
+		table = append(table, row)
+	}
+
+	return table, nil
+}
+
+// GradeBatchResult is the outcome of a single item of a batch update.
+type GradeBatchResult struct {
+	GradeID int64
+	Error   string
+}
+
+// checkGradeAccess tests whether the actor may change a grade. Tutors
+// can only change grades which are assigned to them or which are not assigned
+// to anyone yet, admins can change all grades of the course.
+func checkGradeAccess(grade *model.Grade, actorID int64, isAdmin bool) error {
+	// by definition user with id 1 is the system, so nobody is in charge yet
+	responsibleID := grade.ResponsibleTutorID()
+	if !isAdmin && responsibleID != 1 && responsibleID != actorID {
+		return errors.New("grade is assigned to another tutor")
+	}
+	return nil
+}
+
+// checkGradingIndependence tests whether the actor may change the first grading
+// of a task with double grading. Both gradings have to be independent, hence
+// the second grader cannot grade a second time.
+func checkGradingIndependence(stores *Stores, task *model.Task, grade *model.Grade, actorID int64) error {
+	if !task.DoubleGrading {
+		return nil
+	}
+
+	second, err := stores.Grade.GetSecondGrading(grade.ID)
+	if err == nil && second.TutorID == actorID {
+		return errors.New("the second grader cannot grade a second time")
+	}
+	return nil
+}
+
+// planGradeBatchItem validates a single item of a batch update and returns the
+// updated grade together with its history entry.
+func planGradeBatchItem(stores *Stores, courseID int64, item GradeBatchItem, actorID int64, isAdmin bool) (*model.Grade, *model.GradeHistory, error) {
+	grade, err := stores.Grade.GetForUpdate(item.GradeID)
+	if err != nil {
+		return nil, nil, errors.New("grade does not exist")
+	}
+
+	course, err := stores.Grade.IdentifyCourseOfGrade(grade.ID)
+	if err != nil || course.ID != courseID {
+		return nil, nil, errors.New("grade does not belong to the course")
+	}
+
+	if err := checkGradeAccess(grade, actorID, isAdmin); err != nil {
+		return nil, nil, err
+	}
+
+	task, err := stores.Grade.IdentifyTaskOfGrade(grade.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if item.AcquiredPoints < 0 || item.AcquiredPoints > task.MaxAcquirablePoints() {
+		return nil, nil, fmt.Errorf("acquired points %d are not between 0 and %d",
+			item.AcquiredPoints, task.MaxAcquirablePoints())
+	}
+
+	if err := checkGradingIndependence(stores, task, grade, actorID); err != nil {
+		return nil, nil, err
+	}
+
+	if item.Feedback == "" {
+		return nil, nil, errors.New("feedback is required")
+	}
+
+	previousGrade := *grade
+	grade.AcquiredPoints = item.AcquiredPoints
+	grade.Feedback = item.Feedback
+	grade.TutorID = actorID
+
+	return grade, model.NewGradeHistory(&previousGrade, grade, actorID, symbol.GradeChangeBatch), nil
+}
+
+// PlanGradeBatch validates every item of a batch update. Tutors can only change
+// grades which are assigned to them or which are not assigned to anyone yet,
+// admins can change all grades of the course. The returned grades and history
+// entries should only be stored if all items are valid. All grades are locked,
+// hence stores running in a transaction should be given, which also stores the
+// returned grades.
+func PlanGradeBatch(stores *Stores, courseID int64, items []GradeBatchItem, actorID int64, isAdmin bool) ([]GradeBatchResult, []model.Grade, []model.GradeHistory, bool) {
+	results := []GradeBatchResult{}
+	grades := []model.Grade{}
+	histories := []model.GradeHistory{}
+	valid := true
+	seen := make(map[int64]bool)
+
+	for _, item := range items {
+		result := GradeBatchResult{GradeID: item.GradeID}
+
+		if seen[item.GradeID] {
+			result.Error = "grade occurs more than once"
+		} else {
+			grade, history, err := planGradeBatchItem(stores, courseID, item, actorID, isAdmin)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				grades = append(grades, *grade)
+				histories = append(histories, *history)
+			}
+		}
+		seen[item.GradeID] = true
+
+		if result.Error != "" {
+			valid = false
+		}
+		results = append(results, result)
+	}
+
+	return results, grades, histories, valid
+}
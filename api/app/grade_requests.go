@@ -32,8 +32,9 @@ import (
 // execution state will be handle internally and is not user-facing.
 type GradeRequest struct {
 	// SubmissionID   int64  `json:"submission_id"`
-	AcquiredPoints int    `json:"acquired_points" example:"13"`
-	Feedback       string `json:"feedback" example:"Das war gut"`
+	AcquiredPoints int     `json:"acquired_points" example:"13"`
+	Feedback       string  `json:"feedback" example:"Das war gut"`
+	SnippetIDs     []int64 `json:"snippet_ids" example:"3"`
 }
 
 // Bind preprocesses a GradeRequest.
@@ -83,3 +84,79 @@ func (body *GradeFromWorkerRequest) Validate() error {
 		),
 	)
 }
+
+// GradeTutorRequest is the request payload for assigning a grade to another tutor.
+type GradeTutorRequest struct {
+	TutorID int64 `json:"tutor_id" example:"2"`
+}
+
+// Bind preprocesses a GradeTutorRequest.
+func (body *GradeTutorRequest) Bind(r *http.Request) error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.TutorID,
+			validation.Required,
+		),
+	)
+}
+
+// GradeReassignRequest is the request payload for moving all unfinished grades
+// from one tutor to another tutor.
+type GradeReassignRequest struct {
+	FromTutorID int64 `json:"from_tutor_id" example:"2"`
+	ToTutorID   int64 `json:"to_tutor_id" example:"3"`
+	SheetID     int64 `json:"sheet_id" example:"0"`
+}
+
+// Bind preprocesses a GradeReassignRequest.
+func (body *GradeReassignRequest) Bind(r *http.Request) error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.FromTutorID,
+			validation.Required,
+		),
+		validation.Field(
+			&body.ToTutorID,
+			validation.Required,
+		),
+	)
+}
+
+// GradeBatchItem is a single grade update within a GradeBatchRequest.
+type GradeBatchItem struct {
+	GradeID        int64  `json:"grade_id" example:"31"`
+	AcquiredPoints int    `json:"acquired_points" example:"13"`
+	Feedback       string `json:"feedback" example:"Das war gut"`
+}
+
+// GradeBatchRequest is the request payload for updating many grades at once.
+type GradeBatchRequest struct {
+	Grades []GradeBatchItem `json:"grades"`
+}
+
+// Bind preprocesses a GradeBatchRequest.
+func (body *GradeBatchRequest) Bind(r *http.Request) error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Grades,
+			validation.Required,
+		),
+	)
+}
+
+// GradeReconcileRequest is the request payload for admins deciding on the
+// final points of a grade with two gradings.
+type GradeReconcileRequest struct {
+	AcquiredPoints int    `json:"acquired_points" example:"13"`
+	Feedback       string `json:"feedback" example:"Both gradings agreed on 13 points."`
+}
+
+// Bind preprocesses a GradeReconcileRequest.
+func (body *GradeReconcileRequest) Bind(r *http.Request) error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.AcquiredPoints,
+			validation.Min(0),
+		),
+	)
+}
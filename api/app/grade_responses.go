@@ -30,6 +30,7 @@ import (
 	"github.com/infomark-org/infomark/auth/authorize"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // .............................................................................
@@ -49,6 +50,8 @@ type GradeResponse struct {
 	TutorID               int64     `json:"tutor_id" example:"2"`
 	SubmissionID          int64     `json:"submission_id" example:"31"`
 	FileURL               string    `json:"file_url" example:"/api/v1/submissions/61/file"`
+	FeedbackFileURL       string    `json:"feedback_file_url" example:"/api/v1/courses/1/grades/31/feedback_file"`
+	AssignedTutorID       null.Int  `json:"assigned_tutor_id" example:"2"`
 	User                  *struct {
 		ID        int64  `json:"id" example:"1"`
 		FirstName string `json:"first_name" example:"Max"`
@@ -74,6 +77,15 @@ func newGradeResponse(p *model.Grade, courseID int64) *GradeResponse {
 		)
 	}
 
+	feedbackFileURL := ""
+	if helper.NewGradeFeedbackFileHandle(p.ID).Exists() {
+		feedbackFileURL = fmt.Sprintf("%s/api/v1/courses/%d/grades/%d/feedback_file",
+			configuration.Configuration.Server.ExternalURL(),
+			courseID,
+			p.ID,
+		)
+	}
+
 	user := &struct {
 		ID        int64  `json:"id" example:"1"`
 		FirstName string `json:"first_name" example:"Max"`
@@ -101,6 +113,8 @@ func newGradeResponse(p *model.Grade, courseID int64) *GradeResponse {
 		User:                  user,
 		SubmissionID:          p.SubmissionID,
 		FileURL:               fileURL,
+		FeedbackFileURL:       feedbackFileURL,
+		AssignedTutorID:       p.AssignedTutorID,
 	}
 }
 
@@ -224,6 +238,244 @@ func newMissingGradeListResponse(Grades []model.MissingGrade) []render.Renderer
 	return list
 }
 
+// GradeHistoryResponse is the response payload for a single recorded change
+// of a grade.
+type GradeHistoryResponse struct {
+	ID                   int64     `json:"id" example:"1"`
+	CreatedAt            time.Time `json:"created_at" example:"auto"`
+	GradeID              null.Int  `json:"grade_id" example:"31"`
+	Source               string    `json:"source" example:"manual"`
+	OldAcquiredPoints    int       `json:"old_acquired_points" example:"3"`
+	NewAcquiredPoints    int       `json:"new_acquired_points" example:"4"`
+	OldFeedback          string    `json:"old_feedback" example:"Some feedback"`
+	NewFeedback          string    `json:"new_feedback" example:"Some better feedback"`
+	OldTutorID           int64     `json:"old_tutor_id" example:"1"`
+	NewTutorID           int64     `json:"new_tutor_id" example:"2"`
+	OldPublicTestStatus  int       `json:"old_public_test_status" example:"0"`
+	NewPublicTestStatus  int       `json:"new_public_test_status" example:"0"`
+	OldPrivateTestStatus int       `json:"old_private_test_status" example:"0"`
+	NewPrivateTestStatus int       `json:"new_private_test_status" example:"1"`
+	OldAssignedTutorID   null.Int  `json:"old_assigned_tutor_id" example:"2"`
+	NewAssignedTutorID   null.Int  `json:"new_assigned_tutor_id" example:"3"`
+	Actor                *struct {
+		ID        int64  `json:"id" example:"2"`
+		FirstName string `json:"first_name" example:"Max"`
+		LastName  string `json:"last_name" example:"Mustermensch"`
+	} `json:"actor"`
+}
+
+// Render post-processes a GradeHistoryResponse.
+func (body *GradeHistoryResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newGradeHistoryResponse creates a response from a GradeHistory model.
+func newGradeHistoryResponse(p *model.GradeHistory) *GradeHistoryResponse {
+	actor := &struct {
+		ID        int64  `json:"id" example:"2"`
+		FirstName string `json:"first_name" example:"Max"`
+		LastName  string `json:"last_name" example:"Mustermensch"`
+	}{
+		ID:        p.ActorID,
+		FirstName: p.ActorFirstName,
+		LastName:  p.ActorLastName,
+	}
+
+	return &GradeHistoryResponse{
+		ID:                   p.ID,
+		CreatedAt:            p.CreatedAt,
+		GradeID:              p.GradeID,
+		Source:               p.Source,
+		OldAcquiredPoints:    p.OldAcquiredPoints,
+		NewAcquiredPoints:    p.NewAcquiredPoints,
+		OldFeedback:          p.OldFeedback,
+		NewFeedback:          p.NewFeedback,
+		OldTutorID:           p.OldTutorID,
+		NewTutorID:           p.NewTutorID,
+		OldPublicTestStatus:  p.OldPublicTestStatus,
+		NewPublicTestStatus:  p.NewPublicTestStatus,
+		OldPrivateTestStatus: p.OldPrivateTestStatus,
+		NewPrivateTestStatus: p.NewPrivateTestStatus,
+		OldAssignedTutorID:   p.OldAssignedTutorID,
+		NewAssignedTutorID:   p.NewAssignedTutorID,
+		Actor:                actor,
+	}
+}
+
+// newGradeHistoryListResponse creates a response from a list of GradeHistory models.
+func newGradeHistoryListResponse(entries []model.GradeHistory) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range entries {
+		list = append(list, newGradeHistoryResponse(&entries[k]))
+	}
+	return list
+}
+
+// GradingProgressResponse is the response payload for the grading progress
+// of a tutor.
+type GradingProgressResponse struct {
+	Tutor *struct {
+		ID        int64  `json:"id" example:"2"`
+		FirstName string `json:"first_name" example:"Max"`
+		LastName  string `json:"last_name" example:"Mustermensch"`
+	} `json:"tutor"`
+	Total int `json:"total" example:"40"`
+	Done  int `json:"done" example:"31"`
+}
+
+// Render post-processes a GradingProgressResponse.
+func (body *GradingProgressResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newGradingProgressResponse creates a response from a GradingProgress model.
+func newGradingProgressResponse(p *model.GradingProgress) *GradingProgressResponse {
+	tutor := &struct {
+		ID        int64  `json:"id" example:"2"`
+		FirstName string `json:"first_name" example:"Max"`
+		LastName  string `json:"last_name" example:"Mustermensch"`
+	}{
+		ID:        p.TutorID,
+		FirstName: p.TutorFirstName,
+		LastName:  p.TutorLastName,
+	}
+
+	return &GradingProgressResponse{
+		Tutor: tutor,
+		Total: p.Total,
+		Done:  p.Done,
+	}
+}
+
+// newGradingProgressListResponse creates a response from a list of GradingProgress models.
+func newGradingProgressListResponse(collection []model.GradingProgress) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range collection {
+		list = append(list, newGradingProgressResponse(&collection[k]))
+	}
+	return list
+}
+
+// GradeImportChangeResponse is the response payload describing how a grade
+// changes by an import.
+type GradeImportChangeResponse struct {
+	GradeID           int64  `json:"grade_id" example:"31"`
+	UserID            int64  `json:"user_id" example:"112"`
+	TaskID            int64  `json:"task_id" example:"2"`
+	OldAcquiredPoints int    `json:"old_acquired_points" example:"4"`
+	NewAcquiredPoints int    `json:"new_acquired_points" example:"6"`
+	OldFeedback       string `json:"old_feedback" example:"Some feedback"`
+	NewFeedback       string `json:"new_feedback" example:"Some better feedback"`
+}
+
+// Render post-processes a GradeImportChangeResponse.
+func (body *GradeImportChangeResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newGradeImportChangeResponse creates a response from a planned change.
+func newGradeImportChangeResponse(p *GradeImportChange) *GradeImportChangeResponse {
+	return &GradeImportChangeResponse{
+		GradeID:           p.GradeID,
+		UserID:            p.UserID,
+		TaskID:            p.TaskID,
+		OldAcquiredPoints: p.OldAcquiredPoints,
+		NewAcquiredPoints: p.NewAcquiredPoints,
+		OldFeedback:       p.OldFeedback,
+		NewFeedback:       p.NewFeedback,
+	}
+}
+
+// newGradeImportChangeListResponse creates a response from a list of planned changes.
+func newGradeImportChangeListResponse(changes []GradeImportChange) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range changes {
+		list = append(list, newGradeImportChangeResponse(&changes[k]))
+	}
+	return list
+}
+
+// GradeBatchResultResponse is the response payload describing the outcome of
+// a single item of a batch update.
+type GradeBatchResultResponse struct {
+	GradeID int64  `json:"grade_id" example:"31"`
+	Success bool   `json:"success" example:"false"`
+	Error   string `json:"error" example:"grade is assigned to another tutor"`
+}
+
+// Render post-processes a GradeBatchResultResponse.
+func (body *GradeBatchResultResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newGradeBatchResultResponse creates a response from the outcome of a batch item.
+func newGradeBatchResultResponse(p *GradeBatchResult) *GradeBatchResultResponse {
+	return &GradeBatchResultResponse{
+		GradeID: p.GradeID,
+		Success: p.Error == "",
+		Error:   p.Error,
+	}
+}
+
+// newGradeBatchResultListResponse creates a response from the outcomes of a batch update.
+func newGradeBatchResultListResponse(results []GradeBatchResult) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range results {
+		list = append(list, newGradeBatchResultResponse(&results[k]))
+	}
+	return list
+}
+
+// SecondGradingResponse is the response payload for the second grading of a grade.
+type SecondGradingResponse struct {
+	GradeID             int64    `json:"grade_id" example:"31"`
+	TaskID              int64    `json:"task_id" example:"2"`
+	TutorID             int64    `json:"tutor_id" example:"3"`
+	AcquiredPoints      int      `json:"acquired_points" example:"11"`
+	Feedback            string   `json:"feedback" example:"Part b) is missing."`
+	FirstTutorID        int64    `json:"first_tutor_id" example:"2"`
+	FirstAcquiredPoints int      `json:"first_acquired_points" example:"14"`
+	Reconciled          bool     `json:"reconciled" example:"false"`
+	ReconciledBy        null.Int `json:"reconciled_by" example:"1"`
+	NeedsReconciliation bool     `json:"needs_reconciliation" example:"true"`
+}
+
+// Render post-processes a SecondGradingResponse.
+func (body *SecondGradingResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newSecondGradingResponse creates a response from a SecondGrading model. The
+// first grading is only revealed if "withFirst" is set.
+func newSecondGradingResponse(p *model.SecondGrading, withFirst bool) *SecondGradingResponse {
+	r := &SecondGradingResponse{
+		GradeID:        p.GradeID,
+		TaskID:         p.TaskID,
+		TutorID:        p.TutorID,
+		AcquiredPoints: p.AcquiredPoints,
+		Feedback:       p.Feedback,
+		Reconciled:     p.Reconciled,
+		ReconciledBy:   p.ReconciledBy,
+	}
+
+	if withFirst {
+		r.FirstTutorID = p.FirstTutorID
+		r.FirstAcquiredPoints = p.FirstAcquiredPoints
+		r.NeedsReconciliation = p.NeedsReconciliation()
+	}
+
+	return r
+}
+
+// newSecondGradingListResponse creates a response from a list of SecondGrading models.
+func newSecondGradingListResponse(gradings []model.SecondGrading, withFirst bool) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range gradings {
+		list = append(list, newSecondGradingResponse(&gradings[k], withFirst))
+	}
+	return list
+}
+
 // for the swagger build relying on go.ast we need to duplicate code here
 type SheetInfo struct {
 	ID   int64  `json:"id" example:"42"`
@@ -300,7 +552,10 @@ func newGradeOverviewResponse(collection []model.OverviewGrade, sheets []model.S
 				}
 			}
 
-			currentPoints[sheet2pos[entry.SheetID]] = entry.Points
+			// points of sheets which are not listed are hidden
+			if pos, ok := sheet2pos[entry.SheetID]; ok {
+				currentPoints[pos] = entry.Points
+			}
 		}
 
 		// add the last student
@@ -20,12 +20,15 @@
 package app
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/franela/goblin"
@@ -33,6 +36,7 @@ import (
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/email"
 	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
 
 	null "gopkg.in/guregu/null.v3"
 )
@@ -323,6 +327,383 @@ func TestGrade(t *testing.T) {
 			g.Assert(entryAfter.TutorID).Equal(entryBefore.TutorID)
 		})
 
+		g.It("Should allow bonus points above the maximum", func() {
+
+			task, err := stores.Grade.IdentifyTaskOfGrade(1)
+			g.Assert(err).Equal(nil)
+
+			task.BonusPoints = 5
+			err = stores.Task.Update(task)
+			g.Assert(err).Equal(nil)
+
+			data := H{
+				"acquired_points": task.MaxPoints + 6,
+				"feedback":        "Lorem Ipsum_update",
+			}
+			w := tape.Put("/api/v1/courses/1/grades/1", data, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			data["acquired_points"] = task.MaxPoints + 5
+			w = tape.Put("/api/v1/courses/1/grades/1", data, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			entryAfter, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(entryAfter.AcquiredPoints).Equal(task.MaxPoints + 5)
+
+			// bonus tasks do not count towards the maximum of a sheet
+			pointsBefore, err := stores.Course.PointsForUser(entryAfter.UserID, 1)
+			g.Assert(err).Equal(nil)
+
+			task.IsBonus = true
+			err = stores.Task.Update(task)
+			g.Assert(err).Equal(nil)
+
+			pointsAfter, err := stores.Course.PointsForUser(entryAfter.UserID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pointsAfter)).Equal(len(pointsBefore))
+
+			for k := range pointsAfter {
+				if int64(pointsAfter[k].SheetID) == entryAfter.SheetID {
+					g.Assert(pointsAfter[k].MaxPoints).Equal(pointsBefore[k].MaxPoints - task.MaxPoints)
+					g.Assert(pointsAfter[k].AquiredPoints).Equal(pointsBefore[k].AquiredPoints)
+				}
+			}
+		})
+
+		g.It("Should update many grades in one batch", func() {
+			grades, err := stores.Grade.GetFiltered(1, 0, 0, 0, 0, 0, "%%", -1, -1, -1, -1, -1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(grades) >= 3).IsTrue()
+
+			// the first two grades are assigned to the tutor, the third one to someone else
+			for k := 0; k < 3; k++ {
+				grades[k].TutorID = 2
+				if k == 2 {
+					grades[k].TutorID = 3
+				}
+				err = stores.Grade.Update(&grades[k])
+				g.Assert(err).Equal(nil)
+			}
+
+			url := "/api/v1/courses/1/grades/batch"
+			data := H{
+				"grades": []H{
+					{"grade_id": grades[0].ID, "acquired_points": 0, "feedback": "batch feedback 0"},
+					{"grade_id": grades[1].ID, "acquired_points": 0, "feedback": "batch feedback 1"},
+					{"grade_id": grades[2].ID, "acquired_points": 0, "feedback": "batch feedback 2"},
+				},
+			}
+
+			w := tape.Post(url, data)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			w = tape.Post(url, data, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// nothing is stored if a single item is invalid
+			w = tape.Post(url, data, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			results := []GradeBatchResultResponse{}
+			err = json.NewDecoder(w.Body).Decode(&results)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(results)).Equal(3)
+			g.Assert(results[0].Success).IsTrue()
+			g.Assert(results[1].Success).IsTrue()
+			g.Assert(results[2].Success).IsFalse()
+
+			entryAfter, err := stores.Grade.Get(grades[0].ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(entryAfter.Feedback).Equal(grades[0].Feedback)
+
+			// duplicates and too many points are refused
+			task, err := stores.Grade.IdentifyTaskOfGrade(grades[0].ID)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post(url, H{
+				"grades": []H{
+					{"grade_id": grades[0].ID, "acquired_points": task.MaxAcquirablePoints() + 1, "feedback": "too much"},
+					{"grade_id": grades[1].ID, "acquired_points": 0, "feedback": "batch feedback 1"},
+					{"grade_id": grades[1].ID, "acquired_points": 0, "feedback": "batch feedback 1"},
+				},
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			err = json.NewDecoder(w.Body).Decode(&results)
+			g.Assert(err).Equal(nil)
+			g.Assert(results[0].Success).IsFalse()
+			g.Assert(results[1].Success).IsTrue()
+			g.Assert(results[2].Success).IsFalse()
+
+			// admins can change all grades
+			w = tape.Post(url, data, noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&results)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(results)).Equal(3)
+
+			for k := 0; k < 3; k++ {
+				g.Assert(results[k].Success).IsTrue()
+
+				entryAfter, err := stores.Grade.Get(grades[k].ID)
+				g.Assert(err).Equal(nil)
+				g.Assert(entryAfter.Feedback).Equal(fmt.Sprintf("batch feedback %d", k))
+				g.Assert(entryAfter.AcquiredPoints).Equal(0)
+
+				history, err := stores.Grade.GetHistory(grades[k].ID)
+				g.Assert(err).Equal(nil)
+				g.Assert(history[len(history)-1].Source).Equal(symbol.GradeChangeBatch)
+			}
+		})
+
+		g.It("Should support two independent gradings", func() {
+			secondTutorJWT := tape.NewJWTRequest(3, false)
+
+			task, err := stores.Grade.IdentifyTaskOfGrade(1)
+			g.Assert(err).Equal(nil)
+
+			grade, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			grade.TutorID = 2
+			grade.AcquiredPoints = 0
+			grade.Feedback = "first opinion"
+			err = stores.Grade.Update(grade)
+			g.Assert(err).Equal(nil)
+
+			data := H{
+				"acquired_points": 2,
+				"feedback":        "second opinion",
+			}
+
+			// the task does not require two gradings yet
+			w := tape.Put("/api/v1/courses/1/grades/1/second", data, secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			task.DoubleGrading = true
+			task.ReconcileThreshold = 1
+			task.MaxPoints = 10
+			err = stores.Task.Update(task)
+			g.Assert(err).Equal(nil)
+
+			// the first grader cannot be the second grader
+			w = tape.Put("/api/v1/courses/1/grades/1/second", data, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Put("/api/v1/courses/1/grades/1/second", data, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// the first grading is invisible to the prospective second grader
+			gradeActual := &GradeResponse{}
+			w = tape.Get("/api/v1/courses/1/grades/1", secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(gradeActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeActual.Feedback).Equal("")
+
+			gradesActual := []GradeResponse{}
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/grades?sheet_id=%d", grade.SheetID), secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&gradesActual)
+			g.Assert(err).Equal(nil)
+			for _, el := range gradesActual {
+				if el.ID == 1 {
+					g.Assert(el.Feedback).Equal("")
+				}
+			}
+
+			w = tape.Put("/api/v1/courses/1/grades/1/second", data, secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/grades/1", secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(gradeActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeActual.Feedback).Equal("first opinion")
+
+			// only the second grader can change the second grading
+			w = tape.Put("/api/v1/courses/1/grades/1/second", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// the second grading is invisible to the first grader
+			w = tape.Get("/api/v1/courses/1/grades/1/second", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			secondActual := &SecondGradingResponse{}
+			w = tape.Get("/api/v1/courses/1/grades/1/second", secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(secondActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(secondActual.TutorID).Equal(int64(3))
+			g.Assert(secondActual.AcquiredPoints).Equal(2)
+
+			// the second grader cannot grade the first time
+			w = tape.Put("/api/v1/courses/1/grades/1", data, secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// not even when the grade is assigned to the second grader
+			grade.AssignedTutorID = null.IntFrom(3)
+			err = stores.Grade.Update(grade)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post("/api/v1/courses/1/grades/batch", H{
+				"grades": []H{
+					{"grade_id": 1, "acquired_points": 2, "feedback": "second opinion"},
+				},
+			}, secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+			results := []GradeBatchResultResponse{}
+			err = json.NewDecoder(w.Body).Decode(&results)
+			g.Assert(err).Equal(nil)
+			g.Assert(results[0].Error).Equal("the second grader cannot grade a second time")
+
+			filename := "/tmp/infomark-grade-import.csv"
+			defer os.Remove(filename)
+			content := fmt.Sprintf("user_id,task_id,acquired_points,feedback\n%d,%d,2,second opinion\n",
+				grade.UserID, task.ID)
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			w, err = tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", secondTutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			grade.AssignedTutorID = null.Int{}
+			err = stores.Grade.Update(grade)
+			g.Assert(err).Equal(nil)
+
+			entryAfter, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(entryAfter.AcquiredPoints).Equal(0)
+
+			reconciliations := []SecondGradingResponse{}
+			w = tape.Get("/api/v1/courses/1/grades/reconciliations", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/grades/reconciliations", noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&reconciliations)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(reconciliations)).Equal(1)
+			g.Assert(reconciliations[0].GradeID).Equal(int64(1))
+			g.Assert(reconciliations[0].FirstAcquiredPoints).Equal(0)
+			g.Assert(reconciliations[0].NeedsReconciliation).IsTrue()
+
+			// within the threshold no reconciliation is required
+			data["acquired_points"] = 1
+			w = tape.Put("/api/v1/courses/1/grades/1/second", data, secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/grades/reconciliations", noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&reconciliations)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(reconciliations)).Equal(0)
+
+			data["acquired_points"] = 4
+			w = tape.Put("/api/v1/courses/1/grades/1/second", data, secondTutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Put("/api/v1/courses/1/grades/1/reconcile", H{"acquired_points": 3}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put("/api/v1/courses/1/grades/1/reconcile", H{"acquired_points": 3}, noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			entryAfter, err = stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(entryAfter.AcquiredPoints).Equal(3)
+			g.Assert(entryAfter.Feedback).Equal(grade.Feedback)
+
+			w = tape.Get("/api/v1/courses/1/grades/reconciliations", noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&reconciliations)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(reconciliations)).Equal(0)
+
+			// changing the first grading afterwards requires a new reconciliation
+			w = tape.Put("/api/v1/courses/1/grades/1", H{
+				"acquired_points": 0,
+				"feedback":        "changed my mind",
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/grades/reconciliations", noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			err = json.NewDecoder(w.Body).Decode(&reconciliations)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(reconciliations)).Equal(1)
+			g.Assert(reconciliations[0].Reconciled).IsFalse()
+			g.Assert(reconciliations[0].NeedsReconciliation).IsTrue()
+		})
+
+		g.It("Should serve feedback files after the grades are released", func() {
+			defer helper.NewGradeFeedbackFileHandle(1).Delete()
+
+			grade, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			ownerJWT := tape.NewJWTRequest(grade.UserID, false)
+			otherStudentID := int64(112)
+			if grade.UserID == otherStudentID {
+				otherStudentID = 113
+			}
+			otherJWT := tape.NewJWTRequest(otherStudentID, false)
+
+			sheet, err := stores.Sheet.Get(grade.SheetID)
+			g.Assert(err).Equal(nil)
+			sheet.GradesWithheld = true
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			url := "/api/v1/courses/1/grades/1/feedback_file"
+			filename := fmt.Sprintf("%s/empty.pdf", configuration.Configuration.Server.Debugging.Fixtures)
+
+			w := tape.Get(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			// students
+			w, err = tape.Upload(url, filename, "application/pdf", ownerJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// tutors who are not in charge of the grade
+			grade.AssignedTutorID = null.IntFrom(3)
+			err = stores.Grade.Update(grade)
+			g.Assert(err).Equal(nil)
+
+			w, err = tape.Upload(url, filename, "application/pdf", tutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+			g.Assert(helper.NewGradeFeedbackFileHandle(1).Exists()).IsFalse()
+
+			// the assigned tutor
+			grade.AssignedTutorID = null.IntFrom(2)
+			err = stores.Grade.Update(grade)
+			g.Assert(err).Equal(nil)
+
+			w, err = tape.Upload(url, filename, "application/pdf", tutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(helper.NewGradeFeedbackFileHandle(1).Exists()).IsTrue()
+
+			w = tape.Get(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(w.Header().Get("Content-Type")).Equal("application/pdf")
+
+			// hidden from the student until the grades are released
+			w = tape.Get(url, ownerJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			sheet.GradesWithheld = false
+			sheet.GradesReleaseAt = null.Time{}
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Get(url, ownerJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Get(url, otherJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+		})
+
 		g.It("Should list missing grades", func() {
 			gradesActual := []MissingGradeResponse{}
 			// students have no missing data
@@ -452,6 +833,464 @@ func TestGrade(t *testing.T) {
 
 		})
 
+		g.It("Should record the history of a grade", func() {
+
+			url := "/api/v1/courses/1/grades/1/history"
+
+			entryBefore, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Get(url)
+			g.Assert(w.Code).Equal(http.StatusUnauthorized)
+
+			w = tape.Get(url, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			historyActual := []GradeHistoryResponse{}
+			err = json.NewDecoder(w.Body).Decode(&historyActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(historyActual)).Equal(0)
+
+			w = tape.Put("/api/v1/courses/1/grades/1", H{
+				"acquired_points": 3,
+				"feedback":        "Lorem Ipsum_update",
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Post("/api/v1/courses/1/grades/1/public_result", H{
+				"log":    "some new logs",
+				"status": 1,
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Get(url, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			historyActual = []GradeHistoryResponse{}
+			err = json.NewDecoder(w.Body).Decode(&historyActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(historyActual)).Equal(2)
+
+			g.Assert(historyActual[0].Source).Equal("manual")
+			g.Assert(historyActual[0].Actor.ID).Equal(tutorJWT.Claims.LoginID)
+			g.Assert(historyActual[0].OldAcquiredPoints).Equal(entryBefore.AcquiredPoints)
+			g.Assert(historyActual[0].NewAcquiredPoints).Equal(3)
+			g.Assert(historyActual[0].OldFeedback).Equal(entryBefore.Feedback)
+			g.Assert(historyActual[0].NewFeedback).Equal("Lorem Ipsum_update")
+
+			g.Assert(historyActual[1].Source).Equal("public_test")
+			g.Assert(historyActual[1].Actor.ID).Equal(adminJWT.Claims.LoginID)
+			g.Assert(historyActual[1].OldPublicTestStatus).Equal(entryBefore.PublicTestStatus)
+			g.Assert(historyActual[1].NewPublicTestStatus).Equal(1)
+
+			entries, err := stores.Grade.GetHistoryOfCourse(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(entries)).Equal(2)
+
+			// entries cannot be changed
+			_, err = tape.DB.Exec(`UPDATE grade_histories SET new_acquired_points = 0`)
+			g.Assert(err).Equal(nil)
+
+			// and outlive the grade
+			_, err = tape.DB.Exec(`DELETE FROM submissions WHERE id = $1`, entryBefore.SubmissionID)
+			g.Assert(err).Equal(nil)
+
+			entries, err = stores.Grade.GetHistoryOfCourse(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(entries)).Equal(2)
+			g.Assert(entries[0].GradeID.Valid).IsFalse()
+			g.Assert(entries[0].NewAcquiredPoints).Equal(3)
+			g.Assert(entries[0].CourseID).Equal(int64(1))
+		})
+
+		g.It("Should report grading progress and reassign grades", func() {
+			w := tape.Get("/api/v1/courses/1/grades/progress", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/grades/progress", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			progressExpected, err := stores.Grade.GetGradingProgress(1, 0)
+			g.Assert(err).Equal(nil)
+
+			progressActual := []GradingProgressResponse{}
+			err = json.NewDecoder(w.Body).Decode(&progressActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(progressActual)).Equal(len(progressExpected))
+
+			_, err = tape.DB.Exec("UPDATE grades SET feedback='' WHERE tutor_id = 3 ")
+			g.Assert(err).Equal(nil)
+
+			unfinishedBefore, err := stores.Grade.GetUnfinishedOfTutor(1, 0, 3)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(unfinishedBefore) > 0).Equal(true)
+
+			// students cannot grade
+			w = tape.Post("/api/v1/courses/1/grades/reassign", H{
+				"from_tutor_id": 3,
+				"to_tutor_id":   112,
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/grades/reassign", H{
+				"from_tutor_id": 3,
+				"to_tutor_id":   2,
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/grades/reassign", H{
+				"from_tutor_id": 3,
+				"to_tutor_id":   2,
+			}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			unfinishedAfter, err := stores.Grade.GetUnfinishedOfTutor(1, 0, 3)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(unfinishedAfter)).Equal(0)
+
+			for _, el := range unfinishedBefore {
+				gradeAfter, err := stores.Grade.Get(el.ID)
+				g.Assert(err).Equal(nil)
+				g.Assert(gradeAfter.AssignedTutorID.Int64).Equal(int64(2))
+				// reassigning does not grade the submission
+				g.Assert(gradeAfter.TutorID).Equal(el.TutorID)
+			}
+
+			// single grade
+			w = tape.Put("/api/v1/courses/1/grades/1/tutor", H{"tutor_id": 3}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put("/api/v1/courses/1/grades/1/tutor", H{"tutor_id": 3}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeAfter, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.AssignedTutorID.Int64).Equal(int64(3))
+		})
+
+		g.It("Should hide students from tutors during blind grading", func() {
+			gradeExpected, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+
+			sheet, err := stores.Sheet.Get(gradeExpected.SheetID)
+			g.Assert(err).Equal(nil)
+
+			sheet.BlindGrading = true
+			sheet.GradesWithheld = true
+			g.Assert(stores.Sheet.Update(sheet)).Equal(nil)
+
+			w := tape.Get("/api/v1/courses/1/grades/1", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeActual := &GradeResponse{}
+			err = json.NewDecoder(w.Body).Decode(gradeActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeActual.User.FirstName).Equal(Pseudonym(1, gradeExpected.UserID))
+			g.Assert(gradeActual.User.LastName).Equal("")
+			g.Assert(gradeActual.User.Email).Equal("")
+			// the id could be matched against the enrollments
+			g.Assert(gradeActual.User.ID).Equal(int64(0))
+
+			// neither the submissions nor a filter reveal the student
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/submissions?sheet_id=%d", sheet.ID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			submissionsActual := []SubmissionResponse{}
+			err = json.NewDecoder(w.Body).Decode(&submissionsActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(submissionsActual) > 0).IsTrue()
+			for _, el := range submissionsActual {
+				g.Assert(el.UserID).Equal(int64(0))
+			}
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/submissions?user_id=%d", gradeExpected.UserID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/grades?group_id=1&user_id=%d", gradeExpected.UserID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/grades?group_id=1&user_id=%d", gradeExpected.UserID), adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			// the summary leaves out the points of the blind sheet
+			w = tape.Get("/api/v1/courses/1/grades/summary", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			summaryActual := &GradeOverviewResponse{}
+			err = json.NewDecoder(w.Body).Decode(summaryActual)
+			g.Assert(err).Equal(nil)
+			for _, el := range summaryActual.Sheets {
+				g.Assert(el.ID != sheet.ID).IsTrue()
+			}
+
+			w = tape.Get("/api/v1/courses/1/grades/summary", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			summaryActual = &GradeOverviewResponse{}
+			err = json.NewDecoder(w.Body).Decode(summaryActual)
+			g.Assert(err).Equal(nil)
+			found := false
+			for _, el := range summaryActual.Sheets {
+				if el.ID == sheet.ID {
+					found = true
+				}
+			}
+			g.Assert(found).IsTrue()
+
+			// pseudonyms are stable
+			g.Assert(Pseudonym(1, gradeExpected.UserID)).Equal(Pseudonym(1, gradeExpected.UserID))
+			g.Assert(Pseudonym(1, gradeExpected.UserID) != Pseudonym(2, gradeExpected.UserID)).IsTrue()
+
+			// admins keep the identity
+			w = tape.Get("/api/v1/courses/1/grades/1", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeActual = &GradeResponse{}
+			err = json.NewDecoder(w.Body).Decode(gradeActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeActual.User.FirstName).Equal(gradeExpected.UserFirstName)
+
+			w = tape.Get("/api/v1/courses/1/pseudonyms", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/pseudonyms", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			pseudonymsActual := []PseudonymResponse{}
+			err = json.NewDecoder(w.Body).Decode(&pseudonymsActual)
+			g.Assert(err).Equal(nil)
+
+			found = false
+			for _, el := range pseudonymsActual {
+				if el.User.ID == gradeExpected.UserID {
+					found = true
+					g.Assert(el.Pseudonym).Equal(Pseudonym(1, gradeExpected.UserID))
+				}
+			}
+			g.Assert(found).IsTrue()
+
+			// identity is revealed after releasing the grades
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/sheets/%d/release", sheet.ID), H{}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Get("/api/v1/courses/1/grades/1", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeActual = &GradeResponse{}
+			err = json.NewDecoder(w.Body).Decode(gradeActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeActual.User.FirstName).Equal(gradeExpected.UserFirstName)
+			g.Assert(gradeActual.User.ID).Equal(gradeExpected.UserID)
+		})
+
+		g.It("Should export grades as csv and xlsx", func() {
+			w := tape.Get("/api/v1/courses/1/grades/export", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/grades/export?format=pdf", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Get("/api/v1/courses/1/grades/export", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(w.Header().Get("Content-Type")).Equal("text/csv")
+
+			table, err := csv.NewReader(w.Body).ReadAll()
+			g.Assert(err).Equal(nil)
+
+			studentsExpected, err := stores.Grade.GetExportStudents(1, 0, 0)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(table)).Equal(len(studentsExpected) + 1)
+			g.Assert(table[0][1]).Equal("student_number")
+			g.Assert(table[0][len(table[0])-1]).Equal("total")
+
+			// the course total is the sum of all points of a student
+			pointsExpected, err := stores.Grade.GetExportPoints(1, 0)
+			g.Assert(err).Equal(nil)
+			total := 0
+			for _, el := range pointsExpected {
+				if el.UserID == studentsExpected[0].UserID {
+					total += el.AcquiredPoints
+				}
+			}
+			g.Assert(table[1][0]).Equal(strconv.FormatInt(studentsExpected[0].UserID, 10))
+			g.Assert(table[1][len(table[1])-1]).Equal(strconv.Itoa(total))
+
+			w = tape.Get("/api/v1/courses/1/grades/export?format=xlsx", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			g.Assert(w.Header().Get("Content-Type")).Equal(helper.XLSXContentType)
+		})
+
+		g.It("Should import grades from csv", func() {
+			gradeBefore, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+
+			task, err := stores.Task.Get(gradeBefore.TaskID)
+			g.Assert(err).Equal(nil)
+
+			filename := "/tmp/infomark-grade-import.csv"
+			defer os.Remove(filename)
+
+			// tutors cannot import grades which are assigned to another tutor
+			gradeOfOther := *gradeBefore
+			gradeOfOther.TutorID = 3
+			gradeOfOther.AssignedTutorID = null.IntFrom(3)
+			g.Assert(stores.Grade.Update(&gradeOfOther)).Equal(nil)
+
+			content := fmt.Sprintf("user_id,task_id,acquired_points,feedback\n%d,%d,%d,not mine\n",
+				gradeBefore.UserID, gradeBefore.TaskID, task.MaxPoints)
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			w, err := tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", tutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			gradeAfter, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.Feedback).Equal(gradeBefore.Feedback)
+
+			gradeBefore.TutorID = 2
+			gradeBefore.AssignedTutorID = null.IntFrom(2)
+			g.Assert(stores.Grade.Update(gradeBefore)).Equal(nil)
+
+			// too many points
+			content = fmt.Sprintf("user_id,task_id,acquired_points,feedback\n%d,%d,%d,too much\n",
+				gradeBefore.UserID, gradeBefore.TaskID, task.MaxPoints+1)
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			w, err = tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", tutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			content = fmt.Sprintf("user_id,task_id,acquired_points,feedback\n%d,%d,%d,imported feedback\n",
+				gradeBefore.UserID, gradeBefore.TaskID, task.MaxPoints)
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			w, err = tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", studentJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w, err = tape.Upload("/api/v1/courses/1/grades/import?dry_run=true", filename, "text/csv", tutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			changesActual := []GradeImportChangeResponse{}
+			err = json.NewDecoder(w.Body).Decode(&changesActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(changesActual)).Equal(1)
+			g.Assert(changesActual[0].GradeID).Equal(gradeBefore.ID)
+			g.Assert(changesActual[0].OldAcquiredPoints).Equal(gradeBefore.AcquiredPoints)
+			g.Assert(changesActual[0].NewAcquiredPoints).Equal(task.MaxPoints)
+			g.Assert(changesActual[0].NewFeedback).Equal("imported feedback")
+
+			gradeAfter, err = stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.Feedback).Equal(gradeBefore.Feedback)
+
+			w, err = tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", tutorJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeAfter, err = stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.AcquiredPoints).Equal(task.MaxPoints)
+			g.Assert(gradeAfter.Feedback).Equal("imported feedback")
+			g.Assert(gradeAfter.TutorID).Equal(int64(2))
+
+			history, err := stores.Grade.GetHistory(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(history[len(history)-1].Source).Equal("import")
+		})
+
+		g.It("Should import the grade export and student numbers", func() {
+			gradeBefore, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+
+			task, err := stores.Task.Get(gradeBefore.TaskID)
+			g.Assert(err).Equal(nil)
+
+			newPoints := task.MaxPoints
+			if gradeBefore.AcquiredPoints == newPoints {
+				newPoints = 0
+			}
+
+			w := tape.Get("/api/v1/courses/1/grades/export", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			table, err := csv.NewReader(w.Body).ReadAll()
+			g.Assert(err).Equal(nil)
+
+			column := -1
+			for k, name := range table[0] {
+				if strings.HasSuffix(name, fmt.Sprintf("[task %d]", task.ID)) {
+					column = k
+				}
+			}
+			g.Assert(column >= 0).IsTrue()
+
+			changed := false
+			for _, row := range table[1:] {
+				if row[0] == strconv.FormatInt(gradeBefore.UserID, 10) {
+					row[column] = strconv.Itoa(newPoints)
+					changed = true
+				}
+			}
+			g.Assert(changed).IsTrue()
+
+			filename := "/tmp/infomark-grade-import.csv"
+			defer os.Remove(filename)
+
+			f, err := os.Create(filename)
+			g.Assert(err).Equal(nil)
+			writer := csv.NewWriter(f)
+			g.Assert(writer.WriteAll(table)).Equal(nil)
+			f.Close()
+
+			// only the changed cell is imported
+			w, err = tape.Upload("/api/v1/courses/1/grades/import?dry_run=true", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			changesActual := []GradeImportChangeResponse{}
+			err = json.NewDecoder(w.Body).Decode(&changesActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(changesActual)).Equal(1)
+			g.Assert(changesActual[0].GradeID).Equal(gradeBefore.ID)
+			g.Assert(changesActual[0].NewAcquiredPoints).Equal(newPoints)
+			g.Assert(changesActual[0].NewFeedback).Equal(gradeBefore.Feedback)
+
+			w, err = tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeAfter, err := stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.AcquiredPoints).Equal(newPoints)
+
+			// students can be identified by their student number
+			user, err := stores.User.Get(gradeBefore.UserID)
+			g.Assert(err).Equal(nil)
+
+			content := fmt.Sprintf("student_number,task_id,acquired_points\n%s,%d,%d\n",
+				user.StudentNumber, task.ID, gradeBefore.AcquiredPoints)
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			w, err = tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeAfter, err = stores.Grade.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.AcquiredPoints).Equal(gradeBefore.AcquiredPoints)
+
+			content = fmt.Sprintf("student_number,task_id,acquired_points\nunknown,%d,0\n", task.ID)
+			g.Assert(ioutil.WriteFile(filename, []byte(content), 0644)).Equal(nil)
+
+			w, err = tape.Upload("/api/v1/courses/1/grades/import", filename, "text/csv", adminJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
 		g.It("Should show correct overview", func() {
 
 			course, err := stores.Course.Get(1)
@@ -49,6 +49,50 @@ func NewGroupResource(stores *Stores) *GroupResource {
 	}
 }
 
+// isGroupTutor tests if the request identity is one of the tutors of the group
+// or an admin of the course.
+func isGroupTutor(r *http.Request, stores *Stores, group *model.Group) bool {
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+
+	if givenRole == authorize.ADMIN {
+		return true
+	}
+
+	isTutor, err := stores.Group.IsTutorOfGroup(accessClaims.LoginID, group.ID)
+	return err == nil && isTutor
+}
+
+// GroupTutors returns the primary tutor followed by all additional tutors of
+// a group.
+func GroupTutors(stores *Stores, group *model.Group) ([]model.User, error) {
+	tutor, err := stores.User.Get(group.TutorID)
+	if err != nil {
+		return nil, err
+	}
+
+	coTutors, err := stores.Group.GetCoTutors(group.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]model.User{*tutor}, coTutors...), nil
+}
+
+// coTutorIDs extracts the additional tutors from the request and ensures they
+// are tutors of the course.
+func (rs *GroupResource) coTutorIDs(data *GroupRequest, courseID int64) ([]int64, error) {
+	ids := []int64{}
+	for _, t := range data.CoTutors {
+		role, err := rs.Stores.Course.RoleInCourse(t.ID, courseID)
+		if err != nil || role == authorize.STUDENT {
+			return nil, fmt.Errorf("user %v is not a tutor in this course", t.ID)
+		}
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
 // .............................................................................
 
 // IndexHandler is public endpoint for
@@ -75,8 +119,14 @@ func (rs *GroupResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	coTutors, err := rs.Stores.Group.CoTutorsOfCourse(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	// render JSON response
-	if err = render.RenderList(w, r, rs.newGroupListResponse(groups)); err != nil {
+	if err = render.RenderList(w, r, rs.newGroupListResponse(groups, coTutors)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -109,6 +159,7 @@ func (rs *GroupResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 	group.TutorID = data.Tutor.ID
 	group.CourseID = course.ID
 	group.Description = data.Description
+	group.Capacity = data.Capacity
 
 	tutor, err := rs.Stores.User.Get(group.TutorID)
 	if err != nil {
@@ -116,6 +167,12 @@ func (rs *GroupResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	coTutorIDs, err := rs.coTutorIDs(data, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
 	// create Group entry in database
 	newGroup, err := rs.Stores.Group.Create(group)
 	if err != nil {
@@ -123,10 +180,21 @@ func (rs *GroupResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := rs.Stores.Group.SetCoTutors(newGroup.ID, coTutorIDs); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	coTutors, err := rs.Stores.Group.GetCoTutors(newGroup.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	render.Status(r, http.StatusCreated)
 
 	// return Group information of created entry
-	if err := render.Render(w, r, rs.newGroupResponse(newGroup, tutor)); err != nil {
+	if err := render.Render(w, r, rs.newGroupResponse(newGroup, tutor, coTutors)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -154,8 +222,14 @@ func (rs *GroupResource) GetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	coTutors, err := rs.Stores.Group.GetCoTutors(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	// render JSON response
-	if err := render.Render(w, r, rs.newGroupResponse(group, tutor)); err != nil {
+	if err := render.Render(w, r, rs.newGroupResponse(group, tutor, coTutors)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -215,8 +289,14 @@ func (rs *GroupResource) GetMineHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	coTutors, err := rs.Stores.Group.CoTutorsOfCourse(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	// render JSON response
-	if err := render.RenderList(w, r, rs.newGroupListResponse(groups)); err != nil {
+	if err := render.RenderList(w, r, rs.newGroupListResponse(groups, coTutors)); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -237,6 +317,9 @@ func (rs *GroupResource) GetMineHandler(w http.ResponseWriter, r *http.Request)
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  update a specific group
+// DESCRIPTION:
+// The list "co_tutors" replaces all additional tutors of the group. Without
+// this list the additional tutors stay unchanged.
 func (rs *GroupResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	// start from empty Request
 	data := &GroupRequest{}
@@ -247,9 +330,18 @@ func (rs *GroupResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	coTutorIDs, err := rs.coTutorIDs(data, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
 	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
 	group.TutorID = data.Tutor.ID
 	group.Description = data.Description
+	group.Capacity = data.Capacity
 
 	// update database entry
 	if err := rs.Stores.Group.Update(group); err != nil {
@@ -257,6 +349,19 @@ func (rs *GroupResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if data.CoTutors != nil {
+		if err := rs.Stores.Group.SetCoTutors(group.ID, coTutorIDs); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	// a larger capacity might free seats for the waitlist
+	if err := promoteFromWaitlist(rs.Stores, course, group); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
 	render.Status(r, http.StatusNoContent)
 }
 
@@ -383,11 +488,31 @@ func (rs *GroupResource) EditGroupEnrollmentHandler(w http.ResponseWriter, r *ht
 
 	} else {
 		// does exists --> simply change it
+		previousGroupID := enrollment.GroupID
 		enrollment.GroupID = group.ID
 		if err := rs.Stores.Group.ChangeGroupEnrollmentOfUserInCourse(enrollment); err != nil {
 			render.Render(w, r, ErrRender(err))
 			return
 		}
+
+		// the seat in the previous group is free now
+		if previousGroupID != group.ID {
+			previousGroup, err := rs.Stores.Group.Get(previousGroupID)
+			if err != nil {
+				render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+				return
+			}
+			if err := promoteFromWaitlist(rs.Stores, course, previousGroup); err != nil {
+				render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+				return
+			}
+		}
+	}
+
+	// users with a group do not wait for another one
+	if err := rs.Stores.Group.RemoveFromWaitlistsInCourse(data.UserID, course.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
 	}
 
 	render.Status(r, http.StatusNoContent)
@@ -469,12 +594,19 @@ func (rs *GroupResource) ChangeBidHandler(w http.ResponseWriter, r *http.Request
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  send email to entire group
+// DESCRIPTION:
+// Only the tutors of the group and admins of the course can send these emails.
 func (rs *GroupResource) SendEmailHandler(w http.ResponseWriter, r *http.Request) {
 
 	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 	accessUser, _ := rs.Stores.User.Get(accessClaims.LoginID)
 
+	if !isGroupTutor(r, rs.Stores, group) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
 	data := &EmailRequest{}
 
 	// parse JSON request into struct
@@ -513,6 +645,248 @@ func (rs *GroupResource) SendEmailHandler(w http.ResponseWriter, r *http.Request
 
 }
 
+// JoinHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/join
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: post
+// TAG: groups
+// RESPONSE: 200,GroupJoinResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  join a group or its waitlist if the group is full
+// DESCRIPTION:
+// Only available if the course allows group self-enrollment and only for
+// students without a group in this course.
+func (rs *GroupResource) JoinHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	courseRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	if courseRole != authorize.STUDENT {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("only students in a course can join a group")))
+		return
+	}
+
+	if !course.GroupSelfEnrollment {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("self-enrollment into groups is disabled in this course")))
+		return
+	}
+
+	if _, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(accessClaims.LoginID, course.ID); err == nil {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("you are already enrolled in a group of this course")))
+		return
+	}
+
+	position, err := rs.Stores.Group.GetWaitlistPosition(accessClaims.LoginID, group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if position > 0 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("you are already on the waitlist of this group")))
+		return
+	}
+
+	enrolled, err := rs.Stores.Group.EnrollOrWaitlist(accessClaims.LoginID, group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	resp := &GroupJoinResponse{Enrolled: enrolled}
+	if !enrolled {
+		resp.WaitlistPosition, err = rs.Stores.Group.GetWaitlistPosition(accessClaims.LoginID, group.ID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	if err := render.Render(w, r, resp); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// LeaveHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/join
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: delete
+// TAG: groups
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  leave a group or its waitlist
+// DESCRIPTION:
+// Leaving a group frees a seat, which is given to the first student on the waitlist.
+func (rs *GroupResource) LeaveHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	if !course.GroupSelfEnrollment {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("self-enrollment into groups is disabled in this course")))
+		return
+	}
+
+	enrollment, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(accessClaims.LoginID, course.ID)
+	if err == nil && enrollment.GroupID == group.ID {
+		if err := rs.Stores.Group.DeleteGroupEnrollment(accessClaims.LoginID, group.ID); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		if err := promoteFromWaitlist(rs.Stores, course, group); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		render.Status(r, http.StatusNoContent)
+		return
+	}
+
+	position, err := rs.Stores.Group.GetWaitlistPosition(accessClaims.LoginID, group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	if position == 0 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("you are neither in this group nor on its waitlist")))
+		return
+	}
+
+	if err := rs.Stores.Group.RemoveFromWaitlist(accessClaims.LoginID, group.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// IndexWaitlistHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/waitlist
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: get
+// TAG: groups
+// RESPONSE: 200,UserResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list all students on the waitlist of a group in order
+func (rs *GroupResource) IndexWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	users, err := rs.Stores.Group.GetWaitlist(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newUserListResponse(users)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// promoteFromWaitlist fills free seats of a group from its waitlist and
+// notifies the promoted students if the course wants so.
+func promoteFromWaitlist(stores *Stores, course *model.Course, group *model.Group) error {
+	promoted, err := stores.Group.PromoteFromWaitlist(group.ID)
+	if err != nil {
+		return err
+	}
+
+	if !course.WaitlistNotification {
+		return nil
+	}
+
+	for _, userID := range promoted {
+		user, err := stores.User.Get(userID)
+		if err != nil {
+			return err
+		}
+
+		msg, err := email.NewEmailFromTemplate(
+			configuration.Configuration.Server.Email.From,
+			user.Email,
+			fmt.Sprintf("[%s] You got a seat in your exercise group", course.Name),
+			email.WaitlistPromotionTemplateEN,
+			map[string]string{
+				"first_name":        user.FirstName,
+				"last_name":         user.LastName,
+				"course_name":       course.Name,
+				"group_description": group.Description,
+			})
+		if err != nil {
+			return err
+		}
+
+		email.OutgoingEmailsChannel <- msg
+	}
+
+	return nil
+}
+
+// AssignmentHandler is public endpoint for
+// URL: /courses/{course_id}/groups/assignment
+// URLPARAM: course_id,integer
+// QUERYPARAM: dry_run,bool
+// METHOD: post
+// TAG: groups
+// REQUEST: GroupAssignmentRequest
+// RESPONSE: 200,GroupAssignmentResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  assign all students to groups maximizing the sum of their bids
+// DESCRIPTION:
+// Every student of the course is assigned to exactly one group, such that each
+// group gets between min_per_group and max_per_group students. Missing bids count
+// as the highest bid. Setting dry_run=true only returns the planned assignment
+// without changing any group enrollment.
+func (rs *GroupResource) AssignmentHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	dryRun := helper.StringFromURL(r, "dry_run", "false") == "true"
+
+	data := &GroupAssignmentRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	plan, err := PlanGroupAssignment(rs.Stores, course.ID, data.MinPerGroup, data.MaxPerGroup)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if !dryRun {
+		if err := ApplyGroupAssignment(rs.Stores, course.ID, plan); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	if err := render.Render(w, r, newGroupAssignmentResponse(plan, !dryRun)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
 // .............................................................................
 
 // Context middleware is used to load an group object from
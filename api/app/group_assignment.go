@@ -0,0 +1,167 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/model"
+)
+
+// defaultGroupBid is used for groups a student has not bid for. Like the
+// former export for external solvers, a missing bid means "fine with me".
+const defaultGroupBid = 10
+
+// GroupAssignment places a student into a group.
+type GroupAssignment struct {
+	UserID  int64
+	GroupID int64
+	Bid     int
+}
+
+// GroupAssignmentPlan is the outcome of assigning all students of a course to
+// the exercise groups together with some statistics how satisfied they are.
+type GroupAssignmentPlan struct {
+	Assignments []GroupAssignment
+	GroupIDs    []int64
+	GroupSizes  map[int64]int
+	TotalBid    int
+	TopChoice   int
+	WithoutBids int
+}
+
+// PlanGroupAssignment assigns every student of a course to exactly one group
+// such that the sum of the bids is maximal and every group gets between
+// minPerGroup and maxPerGroup students. Nothing is written to the database.
+func PlanGroupAssignment(stores *Stores, courseID int64, minPerGroup int, maxPerGroup int) (*GroupAssignmentPlan, error) {
+	groups, err := stores.Group.GroupsOfCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("the course has no groups")
+	}
+
+	students, err := stores.Course.EnrolledUsers(courseID,
+		[]string{"0"}, "%%", "%%", "%%", "%%", "%%",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	bidsOfCourse, err := stores.Group.GetBidsForCourse(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIndex := make(map[int64]int)
+	for j, group := range groups {
+		groupIndex[group.ID] = j
+	}
+
+	studentIndex := make(map[int64]int)
+	bids := make([][]int, len(students))
+	hasBids := make([]bool, len(students))
+	for i, student := range students {
+		studentIndex[student.ID] = i
+		bids[i] = make([]int, len(groups))
+		for j := range groups {
+			bids[i][j] = defaultGroupBid
+		}
+	}
+
+	for _, bid := range bidsOfCourse {
+		i, ok := studentIndex[bid.UserID]
+		if !ok {
+			continue
+		}
+		j, ok := groupIndex[bid.GroupID]
+		if !ok {
+			continue
+		}
+		bids[i][j] = bid.Bid
+		hasBids[i] = true
+	}
+
+	minSize := make([]int, len(groups))
+	maxSize := make([]int, len(groups))
+	for j := range groups {
+		minSize[j] = minPerGroup
+		maxSize[j] = maxPerGroup
+	}
+
+	assignment, err := helper.AssignToGroups(bids, minSize, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &GroupAssignmentPlan{
+		Assignments: []GroupAssignment{},
+		GroupIDs:    []int64{},
+		GroupSizes:  make(map[int64]int),
+	}
+
+	for _, group := range groups {
+		plan.GroupIDs = append(plan.GroupIDs, group.ID)
+		plan.GroupSizes[group.ID] = 0
+	}
+
+	for i, student := range students {
+		group := groups[assignment[i]]
+		bid := bids[i][assignment[i]]
+
+		plan.Assignments = append(plan.Assignments, GroupAssignment{
+			UserID:  student.ID,
+			GroupID: group.ID,
+			Bid:     bid,
+		})
+		plan.GroupSizes[group.ID]++
+		plan.TotalBid += bid
+
+		if !hasBids[i] {
+			plan.WithoutBids++
+		}
+
+		topChoice := true
+		for _, other := range bids[i] {
+			if other > bid {
+				topChoice = false
+			}
+		}
+		if topChoice {
+			plan.TopChoice++
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyGroupAssignment enrolls all students into their planned groups.
+func ApplyGroupAssignment(stores *Stores, courseID int64, plan *GroupAssignmentPlan) error {
+	enrollments := []model.GroupEnrollment{}
+	for _, assignment := range plan.Assignments {
+		enrollments = append(enrollments, model.GroupEnrollment{
+			UserID:  assignment.UserID,
+			GroupID: assignment.GroupID,
+		})
+	}
+	return stores.Group.ReplaceGroupEnrollmentsInCourse(courseID, enrollments)
+}
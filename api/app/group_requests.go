@@ -32,8 +32,14 @@ type GroupRequest struct {
 	Tutor *struct {
 		ID int64 `json:"id" example:"1"`
 	} `json:"tutor"`
+	// additional tutors besides the primary one, again only the ids are used;
+	// when editing a group, a missing list keeps the current ones
+	CoTutors []struct {
+		ID int64 `json:"id" example:"3"`
+	} `json:"co_tutors"`
 	// CourseID    int64  `json:"course_id"`
 	Description string `json:"description" example:"Gruppe fuer ersties am Montag im Raum C25435"`
+	Capacity    int    `json:"capacity" example:"20" minval:"0"`
 }
 
 // Bind preprocesses a GroupRequest.
@@ -58,6 +64,10 @@ func (body *GroupRequest) Validate() error {
 			&body.Description,
 			validation.Required,
 		),
+		validation.Field(
+			&body.Capacity,
+			validation.Min(0),
+		),
 	)
 	if err != nil {
 		return err
@@ -112,3 +122,41 @@ func (body *GroupEnrollmentRequest) Validate() error {
 		),
 	)
 }
+
+// GroupAssignmentRequest holds the capacities every group has to respect when
+// assigning all students to groups.
+type GroupAssignmentRequest struct {
+	MinPerGroup int `json:"min_per_group" example:"5" minval:"0"`
+	MaxPerGroup int `json:"max_per_group" example:"20" minval:"1"`
+}
+
+// Bind preprocesses a GroupAssignmentRequest.
+func (body *GroupAssignmentRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"assignment\" data")
+	}
+	return body.Validate()
+}
+
+func (body *GroupAssignmentRequest) Validate() error {
+	err := validation.ValidateStruct(body,
+		validation.Field(
+			&body.MinPerGroup,
+			validation.Min(0),
+		),
+		validation.Field(
+			&body.MaxPerGroup,
+			validation.Required,
+			validation.Min(1),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if body.MinPerGroup > body.MaxPerGroup {
+		return errors.New("min_per_group must not exceed max_per_group")
+	}
+
+	return nil
+}
@@ -32,6 +32,7 @@ type GroupResponse struct {
 	ID          int64  `json:"id" example:"9841"`
 	CourseID    int64  `json:"course_id" example:"1"`
 	Description string `json:"description" example:"Group every tuesday in room e43"`
+	Capacity    int    `json:"capacity" example:"20"`
 	// TutorID     int64  `json:"tutor_id" example:"12"`
 
 	// userResponse
@@ -48,10 +49,20 @@ type GroupResponse struct {
 		Subject       string `json:"subject" example:"bio informatics"`
 		Root          bool   `json:"root" example:"false"`
 	} `json:"tutor"`
+	CoTutors []GroupTutorResponse `json:"co_tutors"`
+}
+
+// GroupTutorResponse is an additional tutor of a group.
+type GroupTutorResponse struct {
+	ID        int64       `json:"id" example:"3"`
+	FirstName string      `json:"first_name" example:"Max"`
+	LastName  string      `json:"last_name" example:"Mustermensch"`
+	AvatarURL null.String `json:"avatar_url" example:"/url/to/file"`
+	Email     string      `json:"email" example:"test@unit-tuebingen.de"`
 }
 
 // newGroupResponse creates a response from a Group model.
-func (rs *GroupResource) newGroupResponse(p *model.Group, t *model.User) *GroupResponse {
+func (rs *GroupResource) newGroupResponse(p *model.Group, t *model.User, coTutors []model.User) *GroupResponse {
 
 	tutor := &struct {
 		ID        int64       `json:"id" example:"1"`
@@ -76,17 +87,42 @@ func (rs *GroupResource) newGroupResponse(p *model.Group, t *model.User) *GroupR
 		Language:  t.Language,
 	}
 
+	coTutorList := []GroupTutorResponse{}
+	for _, u := range coTutors {
+		coTutorList = append(coTutorList, GroupTutorResponse{
+			ID:        u.ID,
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+			AvatarURL: u.AvatarURL,
+			Email:     u.Email,
+		})
+	}
+
 	return &GroupResponse{
 		ID: p.ID,
 		// TutorID:     p.TutorID,
 		Tutor:       tutor,
+		CoTutors:    coTutorList,
 		CourseID:    p.CourseID,
 		Description: p.Description,
+		Capacity:    p.Capacity,
 	}
 }
 
 // newGroupListResponse creates a response from a list of Group models.
-func (rs *GroupResource) newGroupListResponse(Groups []model.GroupWithTutor) []render.Renderer {
+func (rs *GroupResource) newGroupListResponse(Groups []model.GroupWithTutor, coTutors []model.GroupCoTutor) []render.Renderer {
+	coTutorsOfGroup := make(map[int64][]model.User)
+	for _, t := range coTutors {
+		coTutorsOfGroup[t.GroupID] = append(coTutorsOfGroup[t.GroupID], model.User{
+			ID:        t.ID,
+			FirstName: t.FirstName,
+			LastName:  t.LastName,
+			AvatarURL: t.AvatarURL,
+			Email:     t.Email,
+			Language:  t.Language,
+		})
+	}
+
 	list := []render.Renderer{}
 	for k := range Groups {
 		// TODO(patwie): refactor this
@@ -103,8 +139,9 @@ func (rs *GroupResource) newGroupListResponse(Groups []model.GroupWithTutor) []r
 			ID:          Groups[k].ID,
 			CourseID:    Groups[k].CourseID,
 			Description: Groups[k].Description,
+			Capacity:    Groups[k].Capacity,
 		}
-		list = append(list, rs.newGroupResponse(group, tutor))
+		list = append(list, rs.newGroupResponse(group, tutor, coTutorsOfGroup[group.ID]))
 	}
 	return list
 }
@@ -123,3 +160,77 @@ type GroupBidResponse struct {
 func (body *GroupBidResponse) Render(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
+
+// GroupAssignmentResponse is the (planned) assignment of all students to groups
+// together with statistics how well the bids are satisfied.
+type GroupAssignmentResponse struct {
+	Applied     bool    `json:"applied" example:"false"`
+	Students    int     `json:"students" example:"120"`
+	AverageBid  float32 `json:"average_bid" example:"8.4"`
+	TopChoice   int     `json:"top_choice" example:"97"`
+	WithoutBids int     `json:"without_bids" example:"3"`
+	GroupSizes  []struct {
+		GroupID int64 `json:"group_id" example:"2"`
+		Size    int   `json:"size" example:"14"`
+	} `json:"group_sizes"`
+	Assignments []struct {
+		UserID  int64 `json:"user_id" example:"112"`
+		GroupID int64 `json:"group_id" example:"2"`
+		Bid     int   `json:"bid" example:"10"`
+	} `json:"assignments"`
+}
+
+// newGroupAssignmentResponse creates a response from a group assignment plan.
+func newGroupAssignmentResponse(plan *GroupAssignmentPlan, applied bool) *GroupAssignmentResponse {
+	r := &GroupAssignmentResponse{
+		Applied:     applied,
+		Students:    len(plan.Assignments),
+		TopChoice:   plan.TopChoice,
+		WithoutBids: plan.WithoutBids,
+	}
+
+	if r.Students > 0 {
+		r.AverageBid = float32(plan.TotalBid) / float32(r.Students)
+	}
+
+	for _, groupID := range plan.GroupIDs {
+		r.GroupSizes = append(r.GroupSizes, struct {
+			GroupID int64 `json:"group_id" example:"2"`
+			Size    int   `json:"size" example:"14"`
+		}{
+			GroupID: groupID,
+			Size:    plan.GroupSizes[groupID],
+		})
+	}
+
+	for _, assignment := range plan.Assignments {
+		r.Assignments = append(r.Assignments, struct {
+			UserID  int64 `json:"user_id" example:"112"`
+			GroupID int64 `json:"group_id" example:"2"`
+			Bid     int   `json:"bid" example:"10"`
+		}{
+			UserID:  assignment.UserID,
+			GroupID: assignment.GroupID,
+			Bid:     assignment.Bid,
+		})
+	}
+
+	return r
+}
+
+// Render post-processes a GroupAssignmentResponse.
+func (body *GroupAssignmentResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GroupJoinResponse tells whether a student got a seat in a group or the
+// position on its waitlist.
+type GroupJoinResponse struct {
+	Enrolled         bool `json:"enrolled" example:"false"`
+	WaitlistPosition int  `json:"waitlist_position" example:"3"`
+}
+
+// Render post-processes a GroupJoinResponse.
+func (body *GroupJoinResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
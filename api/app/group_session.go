@@ -0,0 +1,307 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+// GroupSessionResource specifies group session management handler.
+type GroupSessionResource struct {
+	Stores *Stores
+}
+
+// NewGroupSessionResource create and returns a GroupSessionResource.
+func NewGroupSessionResource(stores *Stores) *GroupSessionResource {
+	return &GroupSessionResource{
+		Stores: stores,
+	}
+}
+
+// validateRoomOfSession tests if the room of a session is free at these times.
+func (rs *GroupSessionResource) validateRoomOfSession(session *model.GroupSession) error {
+	if session.Room == "" {
+		return nil
+	}
+
+	clashes, err := rs.Stores.Session.FindRoomClashes(session)
+	if err != nil {
+		return err
+	}
+
+	if len(clashes) > 0 {
+		return fmt.Errorf("room %s is already used by group %d from %s to %s",
+			session.Room, clashes[0].GroupID, clashes[0].StartTime, clashes[0].EndTime)
+	}
+	return nil
+}
+
+// IndexHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: get
+// TAG: groups
+// RESPONSE: 200,GroupSessionResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  list the weekly sessions of a group
+func (rs *GroupSessionResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	sessions, err := rs.Stores.Session.SessionsOfGroup(group.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	cancelledDates := make(map[int64][]time.Time)
+	for _, session := range sessions {
+		cancelledDates[session.ID], err = rs.Stores.Session.GetCancellations(session.ID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newGroupSessionListResponse(sessions, cancelledDates)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// METHOD: post
+// TAG: groups
+// REQUEST: GroupSessionRequest
+// RESPONSE: 200,GroupSessionResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  add a weekly session to a group
+// DESCRIPTION:
+// The weekday starts with 0 for sunday. A session cannot use a room which is
+// used by another session at the same time.
+func (rs *GroupSessionResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+	data := &GroupSessionRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	session := &model.GroupSession{
+		GroupID:   group.ID,
+		Weekday:   data.Weekday,
+		StartTime: data.StartTime,
+		EndTime:   data.EndTime,
+		Room:      data.Room,
+		OnlineURL: data.OnlineURL,
+		FirstDate: data.FirstDate,
+		LastDate:  data.LastDate,
+	}
+
+	if err := rs.validateRoomOfSession(session); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	newSession, err := rs.Stores.Session.Create(session)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := rs.Stores.Session.SetCancellations(newSession.ID, data.CancelledDates); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	cancelledDates, err := rs.Stores.Session.GetCancellations(newSession.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newGroupSessionResponse(newSession, cancelledDates)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// GetHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// METHOD: get
+// TAG: groups
+// RESPONSE: 200,GroupSessionResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get a specific session of a group
+func (rs *GroupSessionResource) GetHandler(w http.ResponseWriter, r *http.Request) {
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	cancelledDates, err := rs.Stores.Session.GetCancellations(session.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err := render.Render(w, r, newGroupSessionResponse(session, cancelledDates)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// EditHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// METHOD: put
+// TAG: groups
+// REQUEST: GroupSessionRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  update a session of a group including its cancelled dates
+func (rs *GroupSessionResource) EditHandler(w http.ResponseWriter, r *http.Request) {
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	data := &GroupSessionRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	session.Weekday = data.Weekday
+	session.StartTime = data.StartTime
+	session.EndTime = data.EndTime
+	session.Room = data.Room
+	session.OnlineURL = data.OnlineURL
+	session.FirstDate = data.FirstDate
+	session.LastDate = data.LastDate
+
+	if err := rs.validateRoomOfSession(session); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if err := rs.Stores.Session.Update(session); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := rs.Stores.Session.SetCancellations(session.ID, data.CancelledDates); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// DeleteHandler is public endpoint for
+// URL: /courses/{course_id}/groups/{group_id}/sessions/{session_id}
+// URLPARAM: course_id,integer
+// URLPARAM: group_id,integer
+// URLPARAM: session_id,integer
+// METHOD: delete
+// TAG: groups
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  delete a session of a group
+func (rs *GroupSessionResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	session := r.Context().Value(symbol.CtxKeyGroupSession).(*model.GroupSession)
+
+	if err := rs.Stores.Session.Delete(session.ID); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// .............................................................................
+
+// Context middleware is used to load a GroupSession object from
+// the URL parameter `session_id` passed through as the request. In case
+// the GroupSession could not be found, we stop here and return a 404.
+// We do NOT check whether the identity is authorized to get this GroupSession.
+func (rs *GroupSessionResource) Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group := r.Context().Value(symbol.CtxKeyGroup).(*model.Group)
+
+		var sessionID int64
+		var err error
+
+		// try to get id from URL
+		if sessionID, err = strconv.ParseInt(chi.URLParam(r, "session_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific session in database
+		session, err := rs.Stores.Session.Get(sessionID)
+		if err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		if session.GroupID != group.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), symbol.CtxKeyGroupSession, session)
+
+		// serve next
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,105 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	null "gopkg.in/guregu/null.v3"
+)
+
+var timeOfDayRegex = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// GroupSessionRequest is the request payload for group session management.
+type GroupSessionRequest struct {
+	Weekday        int         `json:"weekday" example:"2" minval:"0" maxval:"6"`
+	StartTime      string      `json:"start_time" example:"10:15"`
+	EndTime        string      `json:"end_time" example:"12:00"`
+	Room           string      `json:"room" example:"A104"`
+	OnlineURL      null.String `json:"online_url" example:"https://meet.example.com/group-1"`
+	FirstDate      time.Time   `json:"first_date" example:"auto"`
+	LastDate       time.Time   `json:"last_date" example:"auto"`
+	CancelledDates []time.Time `json:"cancelled_dates"`
+}
+
+// Bind preprocesses a GroupSessionRequest.
+func (body *GroupSessionRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"session\" data")
+	}
+
+	return body.Validate()
+}
+
+// Validate validates a GroupSessionRequest.
+func (body *GroupSessionRequest) Validate() error {
+	err := validation.ValidateStruct(body,
+		validation.Field(
+			&body.Weekday,
+			validation.Min(0),
+			validation.Max(6),
+		),
+		validation.Field(
+			&body.StartTime,
+			validation.Required,
+			validation.Match(timeOfDayRegex),
+		),
+		validation.Field(
+			&body.EndTime,
+			validation.Required,
+			validation.Match(timeOfDayRegex),
+		),
+		validation.Field(
+			&body.FirstDate,
+			validation.Required,
+		),
+		validation.Field(
+			&body.LastDate,
+			validation.Required,
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if body.StartTime >= body.EndTime {
+		return errors.New("end_time should be later than start_time")
+	}
+
+	if body.LastDate.Before(body.FirstDate) {
+		return errors.New("last_date should not be before first_date")
+	}
+
+	if body.Room == "" && body.OnlineURL.String == "" {
+		return errors.New("either a room or an online_url is required")
+	}
+
+	for _, date := range body.CancelledDates {
+		if date.Before(body.FirstDate) || date.After(body.LastDate) {
+			return errors.New("cancelled_dates should be between first_date and last_date")
+		}
+	}
+
+	return nil
+}
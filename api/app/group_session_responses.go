@@ -0,0 +1,73 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// GroupSessionResponse is the response payload for group sessions.
+type GroupSessionResponse struct {
+	ID             int64       `json:"id" example:"1"`
+	GroupID        int64       `json:"group_id" example:"2"`
+	Weekday        int         `json:"weekday" example:"2"`
+	StartTime      string      `json:"start_time" example:"10:15"`
+	EndTime        string      `json:"end_time" example:"12:00"`
+	Room           string      `json:"room" example:"A104"`
+	OnlineURL      null.String `json:"online_url" example:"https://meet.example.com/group-1"`
+	FirstDate      time.Time   `json:"first_date" example:"auto"`
+	LastDate       time.Time   `json:"last_date" example:"auto"`
+	CancelledDates []time.Time `json:"cancelled_dates"`
+}
+
+// Render post-processes a GroupSessionResponse.
+func (body *GroupSessionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newGroupSessionResponse creates a response from a GroupSession model.
+func newGroupSessionResponse(p *model.GroupSession, cancelledDates []time.Time) *GroupSessionResponse {
+	return &GroupSessionResponse{
+		ID:             p.ID,
+		GroupID:        p.GroupID,
+		Weekday:        p.Weekday,
+		StartTime:      p.StartTime,
+		EndTime:        p.EndTime,
+		Room:           p.Room,
+		OnlineURL:      p.OnlineURL,
+		FirstDate:      p.FirstDate,
+		LastDate:       p.LastDate,
+		CancelledDates: cancelledDates,
+	}
+}
+
+// newGroupSessionListResponse creates a response from a list of GroupSession models.
+func newGroupSessionListResponse(sessions []model.GroupSession, cancelledDates map[int64][]time.Time) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range sessions {
+		list = append(list, newGroupSessionResponse(&sessions[k], cancelledDates[sessions[k].ID]))
+	}
+	return list
+}
@@ -0,0 +1,195 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+)
+
+func TestGroupSession(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	studentJWT := tape.NewJWTRequest(112, false)
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	firstDate := time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC)
+	lastDate := time.Date(2019, 7, 31, 0, 0, 0, 0, time.UTC)
+
+	g.Describe("GroupSession", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		g.It("Should create, list, update and delete sessions", func() {
+			data := H{
+				"weekday":         2,
+				"start_time":      "10:15",
+				"end_time":        "12:00",
+				"room":            "Z999",
+				"first_date":      firstDate,
+				"last_date":       lastDate,
+				"cancelled_dates": []time.Time{time.Date(2019, 4, 16, 0, 0, 0, 0, time.UTC)},
+			}
+
+			w := tape.Post("/api/v1/courses/1/groups/1/sessions", data, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/groups/1/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			session := &GroupSessionResponse{}
+			err := json.NewDecoder(w.Body).Decode(session)
+			g.Assert(err).Equal(nil)
+			g.Assert(session.GroupID).Equal(int64(1))
+			g.Assert(session.Weekday).Equal(2)
+			g.Assert(session.StartTime).Equal("10:15")
+			g.Assert(session.EndTime).Equal("12:00")
+			g.Assert(session.Room).Equal("Z999")
+			g.Assert(len(session.CancelledDates)).Equal(1)
+
+			w = tape.Get("/api/v1/courses/1/groups/1/sessions", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			sessions := []GroupSessionResponse{}
+			err = json.NewDecoder(w.Body).Decode(&sessions)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(sessions)).Equal(1)
+			g.Assert(sessions[0].ID).Equal(session.ID)
+
+			// sessions belong to their group
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/groups/2/sessions/%d", session.ID), studentJWT)
+			g.Assert(w.Code).Equal(http.StatusNotFound)
+
+			data["start_time"] = "14:00"
+			data["end_time"] = "16:00"
+			data["cancelled_dates"] = []time.Time{}
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/groups/1/sessions/%d", session.ID), data, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/groups/1/sessions/%d", session.ID), data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			sessionAfter, err := stores.Session.Get(session.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(sessionAfter.StartTime).Equal("14:00")
+			g.Assert(sessionAfter.EndTime).Equal("16:00")
+
+			cancelledDates, err := stores.Session.GetCancellations(session.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(cancelledDates)).Equal(0)
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/groups/1/sessions/%d", session.ID), adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			_, err = stores.Session.Get(session.ID)
+			g.Assert(err == nil).IsFalse()
+		})
+
+		g.It("Should reject invalid sessions", func() {
+			data := H{
+				"weekday":    2,
+				"start_time": "12:00",
+				"end_time":   "10:00",
+				"room":       "Z999",
+				"first_date": firstDate,
+				"last_date":  lastDate,
+			}
+
+			w := tape.Post("/api/v1/courses/1/groups/1/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			data["start_time"] = "9:00"
+			w = tape.Post("/api/v1/courses/1/groups/1/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			data["start_time"] = "08:00"
+			data["weekday"] = 7
+			w = tape.Post("/api/v1/courses/1/groups/1/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			data["weekday"] = 2
+			data["room"] = ""
+			w = tape.Post("/api/v1/courses/1/groups/1/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			data["online_url"] = "https://meet.example.com/group-1"
+			w = tape.Post("/api/v1/courses/1/groups/1/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+		})
+
+		g.It("Should reject overlapping sessions in the same room", func() {
+			data := H{
+				"weekday":    2,
+				"start_time": "10:15",
+				"end_time":   "12:00",
+				"room":       "Z999",
+				"first_date": firstDate,
+				"last_date":  lastDate,
+			}
+
+			w := tape.Post("/api/v1/courses/1/groups/1/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			data["start_time"] = "11:00"
+			data["end_time"] = "13:00"
+			data["room"] = "z999"
+			w = tape.Post("/api/v1/courses/1/groups/2/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// directly afterwards
+			data["start_time"] = "12:00"
+			w = tape.Post("/api/v1/courses/1/groups/2/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			// another day
+			data["start_time"] = "11:00"
+			data["weekday"] = 3
+			w = tape.Post("/api/v1/courses/1/groups/2/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			// another term
+			data["weekday"] = 2
+			data["first_date"] = time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+			data["last_date"] = time.Date(2020, 2, 28, 0, 0, 0, 0, time.UTC)
+			w = tape.Post("/api/v1/courses/1/groups/2/sessions", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
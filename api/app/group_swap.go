@@ -0,0 +1,535 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// GroupSwapResource specifies group swap management handler.
+type GroupSwapResource struct {
+	Stores *Stores
+}
+
+// NewGroupSwapResource create and returns a GroupSwapResource.
+func NewGroupSwapResource(stores *Stores) *GroupSwapResource {
+	return &GroupSwapResource{
+		Stores: stores,
+	}
+}
+
+// matchRequest looks for a complementary request. Matches are swapped right
+// away unless the course requires the approval of an admin. It returns the
+// notifications, which must be sent once the changes are committed.
+func matchRequest(stores *Stores, course *model.Course, request *model.GroupSwapRequest) ([]*email.Email, error) {
+	match, err := stores.GroupSwap.FindMatch(request)
+	if err != nil || match == nil {
+		return nil, err
+	}
+
+	request.MatchedWithID = null.IntFrom(match.ID)
+	match.MatchedWithID = null.IntFrom(request.ID)
+
+	if course.GroupSwapApproval {
+		for _, p := range []*model.GroupSwapRequest{request, match} {
+			p.Status = symbol.GroupSwapMatched
+			if err := stores.GroupSwap.Update(p); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	return completeSwap(stores, course, request, match)
+}
+
+// completeSwap moves both students and returns the notifications for them and
+// their tutors.
+func completeSwap(stores *Stores, course *model.Course, a *model.GroupSwapRequest, b *model.GroupSwapRequest) ([]*email.Email, error) {
+	if err := stores.GroupSwap.Execute(a, b); err != nil {
+		return nil, err
+	}
+
+	messages := []*email.Email{}
+	for _, move := range []struct {
+		request *model.GroupSwapRequest
+		toGroup int64
+	}{
+		{a, b.FromGroupID},
+		{b, a.FromGroupID},
+	} {
+		student, err := stores.User.Get(move.request.UserID)
+		if err != nil {
+			return nil, err
+		}
+		fromGroup, err := stores.Group.Get(move.request.FromGroupID)
+		if err != nil {
+			return nil, err
+		}
+		toGroup, err := stores.Group.Get(move.toGroup)
+		if err != nil {
+			return nil, err
+		}
+
+		msg, err := newGroupSwapStudentEmail(course, student, "completed",
+			fmt.Sprintf("You are now in the group \"%s\".", toGroup.Description))
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+
+		tutors := []model.User{}
+		for _, group := range []*model.Group{fromGroup, toGroup} {
+			groupTutors, err := GroupTutors(stores, group)
+			if err != nil {
+				return nil, err
+			}
+			tutors = append(tutors, groupTutors...)
+		}
+
+		for _, tutor := range tutors {
+			msg, err := email.NewEmailFromTemplate(
+				configuration.Configuration.Server.Email.From,
+				tutor.Email,
+				fmt.Sprintf("[%s] A student changed the group", course.Name),
+				email.GroupSwapTutorTemplateEN,
+				map[string]string{
+					"first_name":   tutor.FirstName,
+					"last_name":    tutor.LastName,
+					"course_name":  course.Name,
+					"student_name": student.FullName(),
+					"from_group":   fromGroup.Description,
+					"to_group":     toGroup.Description,
+				})
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
+
+// newGroupSwapStudentEmail tells a student about the decision on the request.
+func newGroupSwapStudentEmail(course *model.Course, student *model.User, decision string, details string) (*email.Email, error) {
+	return email.NewEmailFromTemplate(
+		configuration.Configuration.Server.Email.From,
+		student.Email,
+		fmt.Sprintf("[%s] Your group swap request", course.Name),
+		email.GroupSwapStudentTemplateEN,
+		map[string]string{
+			"first_name":  student.FirstName,
+			"last_name":   student.LastName,
+			"course_name": course.Name,
+			"decision":    decision,
+			"details":     details,
+		})
+}
+
+// queueEmails sends notifications of committed changes.
+func queueEmails(messages []*email.Email) {
+	for _, msg := range messages {
+		email.OutgoingEmailsChannel <- msg
+	}
+}
+
+// IndexHandler is public endpoint for
+// URL: /courses/{course_id}/group_swaps
+// URLPARAM: course_id,integer
+// QUERYPARAM: status,integer
+// METHOD: get
+// TAG: groups
+// RESPONSE: 200,GroupSwapResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  all group swap requests of a course
+// DESCRIPTION:
+// The status is 0 (open), 1 (matched, waiting for approval), 2 (completed),
+// 3 (cancelled) or 4 (rejected). Without a status all requests are listed.
+func (rs *GroupSwapResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	filterStatus := int(helper.Int64FromURL(r, "status", -1))
+
+	requests, err := rs.Stores.GroupSwap.GetOfCourse(course.ID, filterStatus)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newGroupSwapListResponse(requests)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// IndexOwnHandler is public endpoint for
+// URL: /courses/{course_id}/group_swaps/own
+// URLPARAM: course_id,integer
+// METHOD: get
+// TAG: groups
+// RESPONSE: 200,GroupSwapResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  all group swap requests of the request identity in a course
+func (rs *GroupSwapResource) IndexOwnHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	requests, err := rs.Stores.GroupSwap.GetOfUserInCourse(accessClaims.LoginID, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if err := render.RenderList(w, r, newGroupSwapListResponse(requests)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateHandler is public endpoint for
+// URL: /courses/{course_id}/group_swaps
+// URLPARAM: course_id,integer
+// METHOD: post
+// TAG: groups
+// REQUEST: GroupSwapRequest
+// RESPONSE: 201,GroupSwapResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  ask to leave the own group for one of the wanted groups
+// DESCRIPTION:
+// If another student is in one of the wanted groups and wants to join the own
+// group, both students are swapped immediately or, if the course requires it,
+// after an admin approved the swap. Students and tutors are notified by email.
+// Open requests from a group the student has left in the meantime are
+// cancelled.
+func (rs *GroupSwapResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	courseRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	if courseRole != authorize.STUDENT {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("only students in a course can swap groups")))
+		return
+	}
+
+	data := &GroupSwapRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	enrollment, err := rs.Stores.Group.GetGroupEnrollmentOfUserInCourse(accessClaims.LoginID, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("you are not in a group of this course")))
+		return
+	}
+
+	for _, groupID := range data.WantedGroupIDs {
+		if groupID == enrollment.GroupID {
+			render.Render(w, r, ErrBadRequestWithDetails(errors.New("you are already in a wanted group")))
+			return
+		}
+		group, err := rs.Stores.Group.Get(groupID)
+		if err != nil || group.CourseID != course.ID {
+			render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("group %d does not belong to this course", groupID)))
+			return
+		}
+	}
+
+	previous, err := rs.Stores.GroupSwap.GetOfUserInCourse(accessClaims.LoginID, course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	stale := []model.GroupSwapRequest{}
+	for _, p := range previous {
+		if p.Status != symbol.GroupSwapOpen && p.Status != symbol.GroupSwapMatched {
+			continue
+		}
+		// the student has changed the group in another way since
+		if p.Status == symbol.GroupSwapOpen && p.FromGroupID != enrollment.GroupID {
+			stale = append(stale, p)
+			continue
+		}
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("you already have a pending group swap request")))
+		return
+	}
+
+	var request *model.GroupSwapRequest
+	var messages []*email.Email
+	err = rs.Stores.Transaction(func(tx *Stores) error {
+		for k := range stale {
+			stale[k].Status = symbol.GroupSwapCancelled
+			if err := tx.GroupSwap.Update(&stale[k]); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		request, err = tx.GroupSwap.Create(&model.GroupSwapRequest{
+			CourseID:       course.ID,
+			UserID:         accessClaims.LoginID,
+			FromGroupID:    enrollment.GroupID,
+			Status:         symbol.GroupSwapOpen,
+			WantedGroupIDs: data.WantedGroupIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		messages, err = matchRequest(tx, course, request)
+		return err
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	queueEmails(messages)
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newGroupSwapResponse(request)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// DeleteHandler is public endpoint for
+// URL: /courses/{course_id}/group_swaps/{swap_id}
+// URLPARAM: course_id,integer
+// URLPARAM: swap_id,integer
+// METHOD: delete
+// TAG: groups
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  withdraw a pending group swap request
+// DESCRIPTION:
+// A matched request of another student becomes open again.
+func (rs *GroupSwapResource) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	courseRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	request := r.Context().Value(symbol.CtxKeyGroupSwap).(*model.GroupSwapRequest)
+
+	if request.UserID != accessClaims.LoginID && courseRole != authorize.ADMIN {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
+	if request.Status != symbol.GroupSwapOpen && request.Status != symbol.GroupSwapMatched {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("the group swap request is not pending anymore")))
+		return
+	}
+
+	var messages []*email.Email
+	err := rs.Stores.Transaction(func(tx *Stores) error {
+		request.Status = symbol.GroupSwapCancelled
+		if err := tx.GroupSwap.Update(request); err != nil {
+			return err
+		}
+
+		if !request.MatchedWithID.Valid {
+			return nil
+		}
+
+		partner, err := tx.GroupSwap.Get(request.MatchedWithID.Int64)
+		if err != nil {
+			return err
+		}
+
+		partner.Status = symbol.GroupSwapOpen
+		partner.MatchedWithID = null.Int{}
+		if err := tx.GroupSwap.Update(partner); err != nil {
+			return err
+		}
+
+		messages, err = matchRequest(tx, course, partner)
+		return err
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	queueEmails(messages)
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// ApproveHandler is public endpoint for
+// URL: /courses/{course_id}/group_swaps/{swap_id}/approve
+// URLPARAM: course_id,integer
+// URLPARAM: swap_id,integer
+// METHOD: post
+// TAG: groups
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  approve a matched group swap and move both students
+func (rs *GroupSwapResource) ApproveHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	request := r.Context().Value(symbol.CtxKeyGroupSwap).(*model.GroupSwapRequest)
+
+	if request.Status != symbol.GroupSwapMatched || !request.MatchedWithID.Valid {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("the group swap request is not waiting for an approval")))
+		return
+	}
+
+	partner, err := rs.Stores.GroupSwap.Get(request.MatchedWithID.Int64)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	var messages []*email.Email
+	if err := rs.Stores.Transaction(func(tx *Stores) error {
+		var err error
+		messages, err = completeSwap(tx, course, request, partner)
+		return err
+	}); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+	queueEmails(messages)
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// RejectHandler is public endpoint for
+// URL: /courses/{course_id}/group_swaps/{swap_id}/reject
+// URLPARAM: course_id,integer
+// URLPARAM: swap_id,integer
+// METHOD: post
+// TAG: groups
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  reject a matched group swap, both students stay in their groups
+func (rs *GroupSwapResource) RejectHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	request := r.Context().Value(symbol.CtxKeyGroupSwap).(*model.GroupSwapRequest)
+
+	if request.Status != symbol.GroupSwapMatched || !request.MatchedWithID.Valid {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("the group swap request is not waiting for an approval")))
+		return
+	}
+
+	partner, err := rs.Stores.GroupSwap.Get(request.MatchedWithID.Int64)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	messages := []*email.Email{}
+	if err := rs.Stores.Transaction(func(tx *Stores) error {
+		for _, p := range []*model.GroupSwapRequest{request, partner} {
+			p.Status = symbol.GroupSwapRejected
+			if err := tx.GroupSwap.Update(p); err != nil {
+				return err
+			}
+
+			student, err := tx.User.Get(p.UserID)
+			if err != nil {
+				return err
+			}
+
+			msg, err := newGroupSwapStudentEmail(course, student, "rejected",
+				"You stay in your current group.")
+			if err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+		}
+		return nil
+	}); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+	queueEmails(messages)
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// .............................................................................
+
+// Context middleware is used to load a GroupSwapRequest object from
+// the URL parameter `swap_id` passed through as the request. In case
+// the GroupSwapRequest could not be found, we stop here and return a 404.
+// We do NOT check whether the identity is authorized to get this GroupSwapRequest.
+func (rs *GroupSwapResource) Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+		var swapID int64
+		var err error
+
+		// try to get id from URL
+		if swapID, err = strconv.ParseInt(chi.URLParam(r, "swap_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific request in database
+		request, err := rs.Stores.GroupSwap.Get(swapID)
+		if err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		if request.CourseID != course.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), symbol.CtxKeyGroupSwap, request)
+
+		// serve next
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
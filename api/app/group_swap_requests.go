@@ -0,0 +1,63 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// GroupSwapRequest is the request payload to ask for another group.
+type GroupSwapRequest struct {
+	WantedGroupIDs []int64 `json:"wanted_group_ids"`
+}
+
+// Bind preprocesses a GroupSwapRequest.
+func (body *GroupSwapRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"group_swap\" data")
+	}
+
+	return body.Validate()
+}
+
+// Validate validates a GroupSwapRequest.
+func (body *GroupSwapRequest) Validate() error {
+	if err := validation.ValidateStruct(body,
+		validation.Field(
+			&body.WantedGroupIDs,
+			validation.Required,
+		),
+	); err != nil {
+		return err
+	}
+
+	seen := make(map[int64]bool)
+	for _, groupID := range body.WantedGroupIDs {
+		if seen[groupID] {
+			return errors.New("wanted_group_ids contains a group twice")
+		}
+		seen[groupID] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// GroupSwapResponse is the response payload for group swap requests.
+type GroupSwapResponse struct {
+	ID             int64     `json:"id" example:"1"`
+	CreatedAt      time.Time `json:"created_at" example:"auto"`
+	UserID         int64     `json:"user_id" example:"112"`
+	FromGroupID    int64     `json:"from_group_id" example:"1"`
+	WantedGroupIDs []int64   `json:"wanted_group_ids"`
+	Status         int       `json:"status" example:"0"`
+	MatchedWithID  null.Int  `json:"matched_with_id" example:"4"`
+}
+
+// Render post-processes a GroupSwapResponse.
+func (body *GroupSwapResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newGroupSwapResponse creates a response from a GroupSwapRequest model.
+func newGroupSwapResponse(p *model.GroupSwapRequest) *GroupSwapResponse {
+	return &GroupSwapResponse{
+		ID:             p.ID,
+		CreatedAt:      p.CreatedAt,
+		UserID:         p.UserID,
+		FromGroupID:    p.FromGroupID,
+		WantedGroupIDs: p.WantedGroupIDs,
+		Status:         p.Status,
+		MatchedWithID:  p.MatchedWithID,
+	}
+}
+
+// newGroupSwapListResponse creates a response from a list of GroupSwapRequest models.
+func newGroupSwapListResponse(requests []model.GroupSwapRequest) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range requests {
+		list = append(list, newGroupSwapResponse(&requests[k]))
+	}
+	return list
+}
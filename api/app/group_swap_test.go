@@ -0,0 +1,221 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/symbol"
+)
+
+func TestGroupSwap(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	tutorJWT := tape.NewJWTRequest(2, false)
+	adminJWT := tape.NewJWTRequest(1, true)
+
+	g.Describe("GroupSwap", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+		})
+
+		groupOf := func(userID int64) int64 {
+			enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(userID, 1)
+			g.Assert(err).Equal(nil)
+			return enrollment.GroupID
+		}
+
+		createSwap := func(jwt JWTRequest, wanted int64, expected int) *GroupSwapResponse {
+			w := tape.Post("/api/v1/courses/1/group_swaps", H{"wanted_group_ids": []int64{wanted}}, jwt)
+			g.Assert(w.Code).Equal(expected)
+			swap := &GroupSwapResponse{}
+			if expected == http.StatusCreated {
+				err := json.NewDecoder(w.Body).Decode(swap)
+				g.Assert(err).Equal(nil)
+			}
+			return swap
+		}
+
+		g.It("Should swap complementary requests", func() {
+			membersA, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+			membersB, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+
+			studentA := tape.NewJWTRequest(membersA[0].ID, false)
+			studentB := tape.NewJWTRequest(membersB[0].ID, false)
+
+			// only students
+			createSwap(tutorJWT, 2, http.StatusBadRequest)
+			// not the own group
+			createSwap(studentA, 1, http.StatusBadRequest)
+
+			swapA := createSwap(studentA, 2, http.StatusCreated)
+			g.Assert(swapA.Status).Equal(symbol.GroupSwapOpen)
+			g.Assert(swapA.FromGroupID).Equal(int64(1))
+
+			// one pending request at a time
+			createSwap(studentA, 2, http.StatusBadRequest)
+
+			w := tape.Get("/api/v1/courses/1/group_swaps", studentA)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			swapB := createSwap(studentB, 1, http.StatusCreated)
+			g.Assert(swapB.Status).Equal(symbol.GroupSwapCompleted)
+			g.Assert(swapB.MatchedWithID.Int64).Equal(swapA.ID)
+
+			g.Assert(groupOf(membersA[0].ID)).Equal(int64(2))
+			g.Assert(groupOf(membersB[0].ID)).Equal(int64(1))
+
+			request, err := stores.GroupSwap.Get(swapA.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(request.Status).Equal(symbol.GroupSwapCompleted)
+			g.Assert(request.MatchedWithID.Int64).Equal(swapB.ID)
+		})
+
+		g.It("Should wait for the approval of an admin if required", func() {
+			_, err := tape.DB.Exec(`UPDATE courses SET group_swap_approval = true WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+
+			membersA, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+			membersB, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+
+			studentA := tape.NewJWTRequest(membersA[0].ID, false)
+			studentB := tape.NewJWTRequest(membersB[0].ID, false)
+
+			swapA := createSwap(studentA, 2, http.StatusCreated)
+			swapB := createSwap(studentB, 1, http.StatusCreated)
+			g.Assert(swapB.Status).Equal(symbol.GroupSwapMatched)
+
+			// nobody moved yet
+			g.Assert(groupOf(membersA[0].ID)).Equal(int64(1))
+			g.Assert(groupOf(membersB[0].ID)).Equal(int64(2))
+
+			w := tape.Get("/api/v1/courses/1/group_swaps?status=1", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			swaps := []GroupSwapResponse{}
+			err = json.NewDecoder(w.Body).Decode(&swaps)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(swaps)).Equal(2)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/group_swaps/%d/approve", swapA.ID), H{}, studentA)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/group_swaps/%d/reject", swapA.ID), H{}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			g.Assert(groupOf(membersA[0].ID)).Equal(int64(1))
+			request, err := stores.GroupSwap.Get(swapB.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(request.Status).Equal(symbol.GroupSwapRejected)
+
+			// second attempt
+			swapA = createSwap(studentA, 2, http.StatusCreated)
+			createSwap(studentB, 1, http.StatusCreated)
+
+			w = tape.Post(fmt.Sprintf("/api/v1/courses/1/group_swaps/%d/approve", swapA.ID), H{}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			g.Assert(groupOf(membersA[0].ID)).Equal(int64(2))
+			g.Assert(groupOf(membersB[0].ID)).Equal(int64(1))
+		})
+
+		g.It("Should ignore requests of students who changed the group in another way", func() {
+			membersA, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+			membersB, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+
+			studentA := tape.NewJWTRequest(membersA[0].ID, false)
+			studentB := tape.NewJWTRequest(membersB[0].ID, false)
+
+			swapA := createSwap(studentA, 2, http.StatusCreated)
+
+			// an admin moves the student
+			_, err = tape.DB.Exec(`UPDATE user_group SET group_id = 2 WHERE user_id = $1 AND group_id = 1`, membersA[0].ID)
+			g.Assert(err).Equal(nil)
+
+			swapB := createSwap(studentB, 1, http.StatusCreated)
+			g.Assert(swapB.Status).Equal(symbol.GroupSwapOpen)
+			g.Assert(groupOf(membersB[0].ID)).Equal(int64(2))
+
+			// the outdated request does not block a new one
+			swapA2 := createSwap(studentA, 1, http.StatusCreated)
+			g.Assert(swapA2.FromGroupID).Equal(int64(2))
+
+			request, err := stores.GroupSwap.Get(swapA.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(request.Status).Equal(symbol.GroupSwapCancelled)
+		})
+
+		g.It("Should withdraw pending requests", func() {
+			membersA, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+			membersB, err := stores.Group.GetMembers(2)
+			g.Assert(err).Equal(nil)
+
+			studentA := tape.NewJWTRequest(membersA[0].ID, false)
+			studentB := tape.NewJWTRequest(membersB[0].ID, false)
+
+			swapA := createSwap(studentA, 2, http.StatusCreated)
+
+			w := tape.Delete(fmt.Sprintf("/api/v1/courses/1/group_swaps/%d", swapA.ID), studentB)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/group_swaps/%d", swapA.ID), studentA)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Delete(fmt.Sprintf("/api/v1/courses/1/group_swaps/%d", swapA.ID), studentA)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// nothing to match with anymore
+			swapB := createSwap(studentB, 1, http.StatusCreated)
+			g.Assert(swapB.Status).Equal(symbol.GroupSwapOpen)
+
+			w = tape.Get("/api/v1/courses/1/group_swaps/own", studentA)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			swaps := []GroupSwapResponse{}
+			err = json.NewDecoder(w.Body).Decode(&swaps)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(swaps)).Equal(1)
+			g.Assert(swaps[0].Status).Equal(symbol.GroupSwapCancelled)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
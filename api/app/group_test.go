@@ -20,6 +20,7 @@
 package app
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -169,6 +170,75 @@ func TestGroup(t *testing.T) {
 			g.Assert(entryAfter.CourseID).Equal(int64(1))
 		})
 
+		g.It("Should manage additional tutors of a group", func() {
+			group, err := stores.Group.Get(1)
+			g.Assert(err).Equal(nil)
+			coTutorID := int64(3)
+			g.Assert(group.TutorID != coTutorID).IsTrue()
+
+			// students cannot tutor a group
+			w := tape.Put("/api/v1/courses/1/groups/1", helper.H{
+				"tutor":       helper.H{"id": group.TutorID},
+				"co_tutors":   []helper.H{{"id": studentJWT.Claims.LoginID}},
+				"description": group.Description,
+			}, noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Put("/api/v1/courses/1/groups/1", helper.H{
+				"tutor":       helper.H{"id": group.TutorID},
+				"co_tutors":   []helper.H{{"id": coTutorID}},
+				"description": group.Description,
+			}, noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Get("/api/v1/courses/1/groups/1", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			entryReturn := &GroupResponse{}
+			err = json.NewDecoder(w.Body).Decode(&entryReturn)
+			g.Assert(err).Equal(nil)
+			g.Assert(entryReturn.Tutor.ID).Equal(group.TutorID)
+			g.Assert(len(entryReturn.CoTutors)).Equal(1)
+			g.Assert(entryReturn.CoTutors[0].ID).Equal(coTutorID)
+
+			// the additional tutor sees the group as own group
+			groups, err := stores.Group.GetOfTutor(coTutorID, 1)
+			g.Assert(err).Equal(nil)
+			found := false
+			for _, el := range groups {
+				if el.ID == group.ID {
+					found = true
+				}
+			}
+			g.Assert(found).IsTrue()
+
+			isTutor, err := stores.Group.IsTutorOfGroup(coTutorID, group.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(isTutor).IsTrue()
+
+			// omitting the list keeps the additional tutors
+			w = tape.Put("/api/v1/courses/1/groups/1", helper.H{
+				"tutor":       helper.H{"id": group.TutorID},
+				"description": group.Description,
+			}, noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			isTutor, err = stores.Group.IsTutorOfGroup(coTutorID, group.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(isTutor).IsTrue()
+
+			// an empty list removes all additional tutors
+			w = tape.Put("/api/v1/courses/1/groups/1", helper.H{
+				"tutor":       helper.H{"id": group.TutorID},
+				"co_tutors":   []helper.H{},
+				"description": group.Description,
+			}, noAdminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			isTutor, err = stores.Group.IsTutorOfGroup(coTutorID, group.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(isTutor).IsFalse()
+		})
+
 		g.It("Should delete when valid access claims", func() {
 			entriesBefore, err := stores.Group.GetAll()
 			g.Assert(err).Equal(nil)
@@ -273,7 +343,13 @@ func TestGroup(t *testing.T) {
 			w = tape.Post("/api/v1/courses/1/groups/1/emails", H{"subject": "subj", "body": "body"}, studentJWT)
 			g.Assert(w.Code).Equal(http.StatusForbidden)
 
-			// tutor
+			// tutor of another group
+			w = tape.Post("/api/v1/courses/1/groups/1/emails", H{"subject": "subj", "body": "body"}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// additional tutor of this group
+			err := stores.Group.SetCoTutors(1, []int64{2})
+			g.Assert(err).Equal(nil)
 			w = tape.Post("/api/v1/courses/1/groups/1/emails", H{"subject": "subj", "body": "body"}, tutorJWT)
 			g.Assert(w.Code).Equal(http.StatusOK)
 
@@ -397,6 +473,186 @@ func TestGroup(t *testing.T) {
 			g.Assert(len(enrollmentsActual)).Equal(numberEnrollmentsExpected)
 		})
 
+		g.It("Should assign all students to groups respecting the capacities", func() {
+			numStudents, err := DBGetInt(tape,
+				"SELECT count(*) FROM user_course WHERE course_id = $1 AND role = 0", 1)
+			g.Assert(err).Equal(nil)
+			numGroups, err := DBGetInt(tape,
+				"SELECT count(*) FROM groups WHERE course_id = $1", 1)
+			g.Assert(err).Equal(nil)
+
+			maxPerGroup := (numStudents+numGroups-1)/numGroups + 1
+			data := H{"min_per_group": 1, "max_per_group": maxPerGroup}
+
+			w := tape.Post("/api/v1/courses/1/groups/assignment?dry_run=true", data, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+			w = tape.Post("/api/v1/courses/1/groups/assignment?dry_run=true", data, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// infeasible capacities
+			w = tape.Post("/api/v1/courses/1/groups/assignment?dry_run=true",
+				H{"min_per_group": 0, "max_per_group": 1}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			enrollmentsBefore, err := DBGetInt(tape, "SELECT count(*) FROM user_group WHERE group_id IN (SELECT id FROM groups WHERE course_id = $1)", 1)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Post("/api/v1/courses/1/groups/assignment?dry_run=true", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			plan := GroupAssignmentResponse{}
+			err = json.NewDecoder(w.Body).Decode(&plan)
+			g.Assert(err).Equal(nil)
+			g.Assert(plan.Applied).Equal(false)
+			g.Assert(plan.Students).Equal(numStudents)
+			g.Assert(len(plan.Assignments)).Equal(numStudents)
+			g.Assert(len(plan.GroupSizes)).Equal(numGroups)
+
+			enrollmentsAfter, err := DBGetInt(tape, "SELECT count(*) FROM user_group WHERE group_id IN (SELECT id FROM groups WHERE course_id = $1)", 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollmentsAfter).Equal(enrollmentsBefore)
+
+			w = tape.Post("/api/v1/courses/1/groups/assignment", data, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			plan = GroupAssignmentResponse{}
+			err = json.NewDecoder(w.Body).Decode(&plan)
+			g.Assert(err).Equal(nil)
+			g.Assert(plan.Applied).Equal(true)
+
+			for _, size := range plan.GroupSizes {
+				g.Assert(size.Size >= 1).IsTrue()
+				g.Assert(size.Size <= maxPerGroup).IsTrue()
+
+				actualSize, err := DBGetInt(tape,
+					"SELECT count(*) FROM user_group WHERE group_id = $1", size.GroupID)
+				g.Assert(err).Equal(nil)
+				g.Assert(actualSize).Equal(size.Size)
+			}
+
+			for _, assignment := range plan.Assignments {
+				enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(assignment.UserID, 1)
+				g.Assert(err).Equal(nil)
+				g.Assert(enrollment.GroupID).Equal(assignment.GroupID)
+			}
+		})
+
+		g.It("Should join groups with capacity and move up from the waitlist", func() {
+			// the student has no group yet
+			_, err := tape.DB.Exec(`DELETE FROM user_group WHERE user_id = $1`, studentJWT.Claims.LoginID)
+			g.Assert(err).Equal(nil)
+
+			members, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(members) > 0).IsTrue()
+
+			// group 1 is full
+			_, err = tape.DB.Exec(`UPDATE groups SET capacity = $1 WHERE id = 1`, len(members))
+			g.Assert(err).Equal(nil)
+
+			// self-enrollment is disabled by default
+			w := tape.Post("/api/v1/courses/1/groups/1/join", H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			_, err = tape.DB.Exec(`UPDATE courses SET group_self_enrollment = true WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+
+			// tutors cannot join
+			w = tape.Post("/api/v1/courses/1/groups/1/join", H{}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post("/api/v1/courses/1/groups/1/join", H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			joined := &GroupJoinResponse{}
+			err = json.NewDecoder(w.Body).Decode(joined)
+			g.Assert(err).Equal(nil)
+			g.Assert(joined.Enrolled).Equal(false)
+			g.Assert(joined.WaitlistPosition).Equal(1)
+
+			// cannot wait twice
+			w = tape.Post("/api/v1/courses/1/groups/1/join", H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Get("/api/v1/courses/1/groups/1/waitlist", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/groups/1/waitlist", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			waiting := []UserResponse{}
+			err = json.NewDecoder(w.Body).Decode(&waiting)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(waiting)).Equal(1)
+			g.Assert(waiting[0].ID).Equal(studentJWT.Claims.LoginID)
+
+			// a member leaves and frees a seat
+			memberJWT := tape.NewJWTRequest(members[0].ID, false)
+			w = tape.Delete("/api/v1/courses/1/groups/1/join", memberJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(studentJWT.Claims.LoginID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(int64(1))
+
+			waitlist, err := stores.Group.GetWaitlist(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(waitlist)).Equal(0)
+
+			count, err := stores.Group.CountMembers(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(count).Equal(len(members))
+
+			// the former member can take a free seat in an unlimited group
+			w = tape.Post("/api/v1/courses/1/groups/2/join", H{}, memberJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			joined = &GroupJoinResponse{}
+			err = json.NewDecoder(w.Body).Decode(joined)
+			g.Assert(err).Equal(nil)
+			g.Assert(joined.Enrolled).Equal(true)
+		})
+
+		g.It("Should free group seats and waitlist entries when leaving the course", func() {
+			otherStudentJWT := tape.NewJWTRequest(113, false)
+
+			_, err := tape.DB.Exec(`DELETE FROM user_group WHERE user_id IN ($1, $2)`,
+				studentJWT.Claims.LoginID, otherStudentJWT.Claims.LoginID)
+			g.Assert(err).Equal(nil)
+
+			members, err := stores.Group.GetMembers(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(members) > 0).IsTrue()
+
+			_, err = tape.DB.Exec(`UPDATE groups SET capacity = $1 WHERE id = 1`, len(members))
+			g.Assert(err).Equal(nil)
+			_, err = tape.DB.Exec(`UPDATE courses SET group_self_enrollment = true WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/groups/1/join", H{}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			// the seat of a member leaving the course goes to the waitlist
+			memberJWT := tape.NewJWTRequest(members[0].ID, false)
+			w = tape.Delete("/api/v1/courses/1/enrollments", memberJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			_, err = stores.Group.GetGroupEnrollmentOfUserInCourse(members[0].ID, 1)
+			g.Assert(err).Equal(sql.ErrNoRows)
+
+			enrollment, err := stores.Group.GetGroupEnrollmentOfUserInCourse(studentJWT.Claims.LoginID, 1)
+			g.Assert(err).Equal(nil)
+			g.Assert(enrollment.GroupID).Equal(int64(1))
+
+			// a waiting student leaving the course gives up the waitlist entry
+			w = tape.Post("/api/v1/courses/1/groups/1/join", H{}, otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Delete("/api/v1/courses/1/enrollments", otherStudentJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			waitlist, err := stores.Group.GetWaitlist(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(waitlist)).Equal(0)
+		})
+
 		g.AfterEach(func() {
 			tape.AfterEach()
 		})
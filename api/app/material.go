@@ -21,6 +21,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -56,7 +57,7 @@ func NewMaterialResource(stores *Stores) *MaterialResource {
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  get all materials in course
 // DESCRIPTION:
-// The materials are ordered by the lecture date.
+// The materials are ordered by their position within the course.
 // Kind means 0: slide, 1: supplementary
 func (rs *MaterialResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 
@@ -79,6 +80,52 @@ func (rs *MaterialResource) IndexHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// OrderHandler is public endpoint for
+// URL: /courses/{course_id}/materials/order
+// URLPARAM: course_id,integer
+// METHOD: put
+// TAG: materials
+// REQUEST: OrderRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  rearrange the materials of a course
+// DESCRIPTION:
+// The request has to list the ids of all materials in the course exactly once.
+func (rs *MaterialResource) OrderHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &OrderRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	materials, err := rs.Stores.Material.MaterialsOfCourse(course.ID, authorize.ADMIN.ToInt())
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	materialIDs := []int64{}
+	for _, material := range materials {
+		materialIDs = append(materialIDs, material.ID)
+	}
+
+	if !data.Permutes(materialIDs) {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("ids have to contain every material of the course exactly once")))
+		return
+	}
+
+	if err := rs.Stores.Material.Reorder(course.ID, data.IDs); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
 // CreateHandler is public endpoint for
 // URL: /courses/{course_id}/materials
 // URLPARAM: course_id,integer
@@ -126,6 +126,40 @@ func TestMaterial(t *testing.T) {
 			g.Assert(len(materialsActual)).Equal(len(materialsExpected))
 		})
 
+		g.It("Should rearrange materials", func() {
+			materialsBefore, err := stores.Material.MaterialsOfCourse(1, authorize.ADMIN.ToInt())
+			g.Assert(err).Equal(nil)
+
+			ids := []int64{}
+			for k := len(materialsBefore) - 1; k >= 0; k-- {
+				ids = append(ids, materialsBefore[k].ID)
+			}
+
+			w := tape.Put("/api/v1/courses/1/materials/order", H{"ids": ids}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put("/api/v1/courses/1/materials/order", H{"ids": ids}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// duplicates are not allowed
+			w = tape.Put("/api/v1/courses/1/materials/order", H{"ids": append(ids[1:], ids[1])}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Put("/api/v1/courses/1/materials/order", H{"ids": ids}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/materials", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			materialsActual := []MaterialResponse{}
+			err = json.NewDecoder(w.Body).Decode(&materialsActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(materialsActual)).Equal(len(ids))
+			for k := range materialsActual {
+				g.Assert(materialsActual[k].ID).Equal(ids[k])
+			}
+		})
+
 		g.It("Should get a specific material", func() {
 			materialExpected, err := stores.Material.Get(1)
 			g.Assert(err).Equal(nil)
@@ -0,0 +1,432 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/api/helper"
+	"github.com/infomark-org/infomark/auth/authenticate"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// RegradeResource specifies regrade request management handler.
+type RegradeResource struct {
+	Stores *Stores
+}
+
+// NewRegradeResource create and returns a RegradeResource.
+func NewRegradeResource(stores *Stores) *RegradeResource {
+	return &RegradeResource{
+		Stores: stores,
+	}
+}
+
+// RegradeWindowOpen tests if students can still ask to reconsider the grades
+// of a sheet. The window starts when the grades are released.
+func RegradeWindowOpen(course *model.Course, sheet *model.Sheet) bool {
+	if course.RegradeWindowDays <= 0 || !GradesReleasedYet(sheet) {
+		return false
+	}
+
+	releasedAt := sheet.DueAt
+	if sheet.GradesReleaseAt.Valid {
+		releasedAt = sheet.GradesReleaseAt.Time
+	}
+
+	return !OverTime(releasedAt.Add(time.Duration(course.RegradeWindowDays) * 24 * time.Hour))
+}
+
+// IndexHandler is public endpoint for
+// URL: /courses/{course_id}/regrades
+// URLPARAM: course_id,integer
+// QUERYPARAM: group_id,integer
+// QUERYPARAM: status,integer
+// METHOD: get
+// TAG: regrades
+// RESPONSE: 200,RegradeResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  inbox of regrade requests in a course
+// DESCRIPTION:
+// Without a status filter only open requests are listed. Use status=-1 to get
+// all requests.
+func (rs *RegradeResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	filterGroupID := helper.Int64FromURL(r, "group_id", 0)
+	filterStatus := helper.IntFromURL(r, "status", symbol.RegradeOpen)
+
+	regrades, err := rs.Stores.Regrade.GetFiltered(course.ID, filterGroupID, filterStatus)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newRegradeListResponse(regrades)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// IndexOfGradeHandler is public endpoint for
+// URL: /courses/{course_id}/grades/{grade_id}/regrades
+// URLPARAM: course_id,integer
+// URLPARAM: grade_id,integer
+// METHOD: get
+// TAG: regrades
+// TAG: grades
+// RESPONSE: 200,RegradeResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  all regrade requests of a grade
+func (rs *RegradeResource) IndexOfGradeHandler(w http.ResponseWriter, r *http.Request) {
+	grade := r.Context().Value(symbol.CtxKeyGrade).(*model.Grade)
+
+	regrades, err := rs.Stores.Regrade.GetForGrade(grade.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newRegradeListResponse(regrades)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// IndexOfTaskHandler is public endpoint for
+// URL: /courses/{course_id}/tasks/{task_id}/regrades
+// URLPARAM: course_id,integer
+// URLPARAM: task_id,integer
+// METHOD: get
+// TAG: regrades
+// RESPONSE: 200,RegradeResponseList
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  all regrade requests of the request identity for a task
+func (rs *RegradeResource) IndexOfTaskHandler(w http.ResponseWriter, r *http.Request) {
+	task := r.Context().Value(symbol.CtxKeyTask).(*model.Task)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	submission, err := rs.Stores.Submission.GetByUserAndTask(accessClaims.LoginID, task.ID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	grade, err := rs.Stores.Grade.GetForSubmission(submission.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	regrades, err := rs.Stores.Regrade.GetForGrade(grade.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// render JSON response
+	if err = render.RenderList(w, r, newRegradeListResponse(regrades)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// CreateHandler is public endpoint for
+// URL: /courses/{course_id}/tasks/{task_id}/regrades
+// URLPARAM: course_id,integer
+// URLPARAM: task_id,integer
+// METHOD: post
+// TAG: regrades
+// REQUEST: RegradeRequest
+// RESPONSE: 200,RegradeResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  ask to reconsider the grade of the request identity for a task
+// DESCRIPTION:
+// This is only possible within the regrade window of the course after the
+// grades of the sheet have been released. There can only be one open request
+// per grade.
+func (rs *RegradeResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	sheet := r.Context().Value(symbol.CtxKeySheet).(*model.Sheet)
+	task := r.Context().Value(symbol.CtxKeyTask).(*model.Task)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	data := &RegradeRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	submission, err := rs.Stores.Submission.GetByUserAndTask(accessClaims.LoginID, task.ID)
+	if err != nil {
+		render.Render(w, r, ErrNotFound)
+		return
+	}
+
+	grade, err := rs.Stores.Grade.GetForSubmission(submission.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if !RegradeWindowOpen(course, sheet) {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("regrade requests are not possible for this sheet")))
+		return
+	}
+
+	// by definition user with id 1 is the system, so the grade is untouched
+	if grade.TutorID == 1 {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("this submission has not been graded yet")))
+		return
+	}
+
+	regrades, err := rs.Stores.Regrade.GetForGrade(grade.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	for _, regrade := range regrades {
+		if regrade.Status == symbol.RegradeOpen {
+			render.Render(w, r, ErrBadRequestWithDetails(errors.New("there is already an open regrade request for this grade")))
+			return
+		}
+	}
+
+	regrade, err := rs.Stores.Regrade.Create(&model.Regrade{
+		GradeID:           grade.ID,
+		UserID:            accessClaims.LoginID,
+		Justification:     data.Justification,
+		Status:            symbol.RegradeOpen,
+		OldAcquiredPoints: grade.AcquiredPoints,
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+
+	if err := render.Render(w, r, newRegradeResponse(regrade)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// GetHandler is public endpoint for
+// URL: /courses/{course_id}/regrades/{regrade_id}
+// URLPARAM: course_id,integer
+// URLPARAM: regrade_id,integer
+// METHOD: get
+// TAG: regrades
+// RESPONSE: 200,RegradeResponse
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  get a specific regrade request
+func (rs *RegradeResource) GetHandler(w http.ResponseWriter, r *http.Request) {
+	regrade := r.Context().Value(symbol.CtxKeyRegrade).(*model.Regrade)
+
+	// render JSON response
+	if err := render.Render(w, r, newRegradeResponse(regrade)); err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+}
+
+// EditHandler is public endpoint for
+// URL: /courses/{course_id}/regrades/{regrade_id}
+// URLPARAM: course_id,integer
+// URLPARAM: regrade_id,integer
+// METHOD: put
+// TAG: regrades
+// REQUEST: RegradeDecisionRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  decide on an open regrade request
+// DESCRIPTION:
+// The status is either 1 (accepted) or 2 (rejected). When accepting, the
+// acquired points of the grade can be changed. The student will be notified
+// by email.
+func (rs *RegradeResource) EditHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	regrade := r.Context().Value(symbol.CtxKeyRegrade).(*model.Regrade)
+	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+
+	data := &RegradeDecisionRequest{}
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	if regrade.Status != symbol.RegradeOpen {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("regrade request has already been answered")))
+		return
+	}
+
+	task, err := rs.Stores.Grade.IdentifyTaskOfGrade(regrade.GradeID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if data.AcquiredPoints.Valid && int(data.AcquiredPoints.Int64) > task.MaxAcquirablePoints() {
+		render.Render(w, r, ErrBadRequestWithDetails(fmt.Errorf("acquired points is larger than max-points %v is more than %v", data.AcquiredPoints.Int64, task.MaxAcquirablePoints())))
+		return
+	}
+
+	// the grade and the decision are stored together, the student is notified
+	// once both are committed
+	var grade *model.Grade
+	if err := rs.Stores.Transaction(func(tx *Stores) error {
+		var err error
+		grade, err = tx.Grade.GetForUpdate(regrade.GradeID)
+		if err != nil {
+			return err
+		}
+
+		if data.AcquiredPoints.Valid {
+			previousGrade := *grade
+			grade.AcquiredPoints = int(data.AcquiredPoints.Int64)
+			grade.TutorID = accessClaims.LoginID
+
+			if err := tx.Grade.UpdateWithHistory(grade, model.NewGradeHistory(
+				&previousGrade, grade, accessClaims.LoginID, symbol.GradeChangeRegrade)); err != nil {
+				return err
+			}
+		}
+
+		regrade.Status = data.Status
+		regrade.Response = data.Response
+		regrade.ResponderID = null.IntFrom(accessClaims.LoginID)
+		regrade.RespondedAt = null.TimeFrom(NowUTC())
+		regrade.NewAcquiredPoints = null.IntFrom(int64(grade.AcquiredPoints))
+
+		return tx.Regrade.Update(regrade)
+	}); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	decision := "accepted"
+	if regrade.Status == symbol.RegradeRejected {
+		decision = "rejected"
+	}
+
+	msg, err := email.NewEmailFromTemplate(
+		configuration.Configuration.Server.Email.From,
+		regrade.UserEmail,
+		fmt.Sprintf("[%s] Your regrade request for %s", course.Name, task.Name),
+		email.RegradeDecisionTemplateEN,
+		map[string]string{
+			"first_name":      regrade.UserFirstName,
+			"last_name":       regrade.UserLastName,
+			"course_name":     course.Name,
+			"task_name":       task.Name,
+			"decision":        decision,
+			"acquired_points": strconv.Itoa(grade.AcquiredPoints),
+			"response":        regrade.Response,
+		})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	email.OutgoingEmailsChannel <- msg
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// .............................................................................
+
+// Context middleware is used to load a Regrade object from
+// the URL parameter `regrade_id` passed through as the request. In case
+// the Regrade could not be found, we stop here and return a 404.
+// We do NOT check whether the identity is authorized to get this Regrade.
+func (rs *RegradeResource) Context(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		courseFromURL := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+		var regradeID int64
+		var err error
+
+		// try to get id from URL
+		if regradeID, err = strconv.ParseInt(chi.URLParam(r, "regrade_id"), 10, 64); err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		// find specific regrade request in database
+		regrade, err := rs.Stores.Regrade.Get(regradeID)
+		if err != nil {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		course, err := rs.Stores.Regrade.IdentifyCourseOfRegrade(regrade.ID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		if courseFromURL.ID != course.ID {
+			render.Render(w, r, ErrNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), symbol.CtxKeyRegrade, regrade)
+		ctx = context.WithValue(ctx, symbol.CtxKeyCourse, course)
+
+		// serve next
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,94 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// RegradeRequest is the request payload for students asking to reconsider a grade.
+type RegradeRequest struct {
+	Justification string `json:"justification" example:"I think my solution of part b) is correct."`
+}
+
+// Bind preprocesses a RegradeRequest.
+func (body *RegradeRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"regrade\" data")
+	}
+
+	return body.Validate()
+}
+
+// Validate validates a RegradeRequest.
+func (body *RegradeRequest) Validate() error {
+	return validation.ValidateStruct(body,
+		validation.Field(
+			&body.Justification,
+			validation.Required,
+		),
+	)
+}
+
+// RegradeDecisionRequest is the request payload for tutors answering a regrade request.
+type RegradeDecisionRequest struct {
+	Status         int      `json:"status" example:"1"`
+	Response       string   `json:"response" example:"You are right, part b) is fine."`
+	AcquiredPoints null.Int `json:"acquired_points" example:"4"`
+}
+
+// Bind preprocesses a RegradeDecisionRequest.
+func (body *RegradeDecisionRequest) Bind(r *http.Request) error {
+	if body == nil {
+		return errors.New("missing \"decision\" data")
+	}
+
+	return body.Validate()
+}
+
+// Validate validates a RegradeDecisionRequest.
+func (body *RegradeDecisionRequest) Validate() error {
+	err := validation.ValidateStruct(body,
+		validation.Field(
+			&body.Status,
+			validation.Required,
+			validation.In(symbol.RegradeAccepted, symbol.RegradeRejected),
+		),
+		validation.Field(
+			&body.Response,
+			validation.Required,
+		),
+	)
+
+	if err == nil {
+		if body.AcquiredPoints.Valid && body.AcquiredPoints.Int64 < 0 {
+			return errors.New("acquired_points should not be negative")
+		}
+		if body.AcquiredPoints.Valid && body.Status != symbol.RegradeAccepted {
+			return errors.New("acquired_points can only be changed when accepting the request")
+		}
+	}
+
+	return err
+}
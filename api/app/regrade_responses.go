@@ -0,0 +1,95 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// RegradeResponse is the response payload for regrade requests.
+type RegradeResponse struct {
+	ID                int64     `json:"id" example:"1"`
+	CreatedAt         time.Time `json:"created_at" example:"auto"`
+	GradeID           int64     `json:"grade_id" example:"31"`
+	TaskID            int64     `json:"task_id" example:"2"`
+	SheetID           int64     `json:"sheet_id" example:"10"`
+	Justification     string    `json:"justification" example:"I think my solution of part b) is correct."`
+	Status            int       `json:"status" example:"0"`
+	Response          string    `json:"response" example:"You are right, part b) is fine."`
+	ResponderID       null.Int  `json:"responder_id" example:"2"`
+	RespondedAt       null.Time `json:"responded_at" example:"auto"`
+	OldAcquiredPoints int       `json:"old_acquired_points" example:"3"`
+	NewAcquiredPoints null.Int  `json:"new_acquired_points" example:"4"`
+	User              *struct {
+		ID        int64  `json:"id" example:"1"`
+		FirstName string `json:"first_name" example:"Max"`
+		LastName  string `json:"last_name" example:"Mustermensch"`
+		Email     string `json:"email" example:"test@unit-tuebingen.de"`
+	} `json:"user"`
+}
+
+// Render post-processes a RegradeResponse.
+func (body *RegradeResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// newRegradeResponse creates a response from a Regrade model.
+func newRegradeResponse(p *model.Regrade) *RegradeResponse {
+	user := &struct {
+		ID        int64  `json:"id" example:"1"`
+		FirstName string `json:"first_name" example:"Max"`
+		LastName  string `json:"last_name" example:"Mustermensch"`
+		Email     string `json:"email" example:"test@unit-tuebingen.de"`
+	}{
+		ID:        p.UserID,
+		FirstName: p.UserFirstName,
+		LastName:  p.UserLastName,
+		Email:     p.UserEmail,
+	}
+
+	return &RegradeResponse{
+		ID:                p.ID,
+		CreatedAt:         p.CreatedAt,
+		GradeID:           p.GradeID,
+		TaskID:            p.TaskID,
+		SheetID:           p.SheetID,
+		Justification:     p.Justification,
+		Status:            p.Status,
+		Response:          p.Response,
+		ResponderID:       p.ResponderID,
+		RespondedAt:       p.RespondedAt,
+		OldAcquiredPoints: p.OldAcquiredPoints,
+		NewAcquiredPoints: p.NewAcquiredPoints,
+		User:              user,
+	}
+}
+
+// newRegradeListResponse creates a response from a list of Regrade models.
+func newRegradeListResponse(regrades []model.Regrade) []render.Renderer {
+	list := []render.Renderer{}
+	for k := range regrades {
+		list = append(list, newRegradeResponse(&regrades[k]))
+	}
+	return list
+}
@@ -0,0 +1,193 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise materials and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/franela/goblin"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
+)
+
+func TestRegrade(t *testing.T) {
+
+	g := goblin.Goblin(t)
+	email.DefaultMail = email.VoidMail
+
+	tape := NewTape()
+
+	var stores *Stores
+
+	studentJWT := tape.NewJWTRequest(112, false)
+	tutorJWT := tape.NewJWTRequest(2, false)
+
+	g.Describe("Regrade", func() {
+
+		g.BeforeEach(func() {
+			tape.BeforeEach()
+			stores = NewStores(tape.DB)
+			_ = stores
+		})
+
+		g.It("Should not accept requests after the window is closed", func() {
+			sheet, err := stores.Task.IdentifySheetOfTask(1)
+			g.Assert(err).Equal(nil)
+
+			sheet.GradesReleaseAt = null.TimeFrom(NowUTC().Add(-30 * 24 * time.Hour))
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/tasks/1/regrades", H{
+				"justification": "please have a look again",
+			}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+		})
+
+		g.It("Should handle the entire regrade workflow", func() {
+			submission, err := stores.Submission.GetByUserAndTask(112, 1)
+			g.Assert(err).Equal(nil)
+
+			grade, err := stores.Grade.GetForSubmission(submission.ID)
+			g.Assert(err).Equal(nil)
+			grade.TutorID = 2
+			grade.AcquiredPoints = 1
+			err = stores.Grade.Update(grade)
+			g.Assert(err).Equal(nil)
+
+			sheet, err := stores.Task.IdentifySheetOfTask(1)
+			g.Assert(err).Equal(nil)
+			sheet.GradesWithheld = false
+			sheet.GradesReleaseAt = null.TimeFrom(NowUTC().Add(-time.Hour))
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			course, err := stores.Course.Get(1)
+			g.Assert(err).Equal(nil)
+			course.RegradeWindowDays = 7
+			err = stores.Course.Update(course)
+			g.Assert(err).Equal(nil)
+
+			url := "/api/v1/courses/1/tasks/1/regrades"
+
+			w := tape.Post(url, H{"justification": ""}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Post(url, H{"justification": "please have a look again"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusCreated)
+
+			created := &RegradeResponse{}
+			err = json.NewDecoder(w.Body).Decode(created)
+			g.Assert(err).Equal(nil)
+			g.Assert(created.GradeID).Equal(grade.ID)
+			g.Assert(created.Status).Equal(symbol.RegradeOpen)
+			g.Assert(created.OldAcquiredPoints).Equal(grade.AcquiredPoints)
+
+			// only one open request per grade
+			w = tape.Post(url, H{"justification": "please have a look again"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Get(url, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			ownActual := []RegradeResponse{}
+			err = json.NewDecoder(w.Body).Decode(&ownActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(ownActual)).Equal(1)
+
+			// inbox
+			w = tape.Get("/api/v1/courses/1/regrades", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Get("/api/v1/courses/1/regrades", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			inboxActual := []RegradeResponse{}
+			err = json.NewDecoder(w.Body).Decode(&inboxActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(inboxActual)).Equal(1)
+			g.Assert(inboxActual[0].ID).Equal(created.ID)
+
+			// decision
+			decisionURL := fmt.Sprintf("/api/v1/courses/1/regrades/%d", created.ID)
+
+			w = tape.Put(decisionURL, H{"status": 1, "response": "you are right"}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put(decisionURL, H{"status": 2, "response": "no", "acquired_points": 0}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			// the grade is not changed when the decision cannot be stored
+			_, err = tape.DB.Exec(`
+CREATE FUNCTION fail_update() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'failed on purpose';
+END $$ LANGUAGE plpgsql;
+CREATE TRIGGER fail_regrades BEFORE UPDATE ON regrade_requests FOR EACH ROW EXECUTE PROCEDURE fail_update();`)
+			g.Assert(err).Equal(nil)
+
+			w = tape.Put(decisionURL, H{"status": 1, "response": "you are right", "acquired_points": 0}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusInternalServerError)
+
+			_, err = tape.DB.Exec("DROP TRIGGER fail_regrades ON regrade_requests; DROP FUNCTION fail_update();")
+			g.Assert(err).Equal(nil)
+
+			gradeAfter, err := stores.Grade.Get(grade.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.AcquiredPoints).Equal(1)
+
+			w = tape.Put(decisionURL, H{"status": 1, "response": "you are right", "acquired_points": 0}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			w = tape.Put(decisionURL, H{"status": 1, "response": "you are right", "acquired_points": 0}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			gradeAfter, err = stores.Grade.Get(grade.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.AcquiredPoints).Equal(0)
+
+			w = tape.Get(fmt.Sprintf("/api/v1/courses/1/grades/%d/regrades", grade.ID), tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			gradeActual := []RegradeResponse{}
+			err = json.NewDecoder(w.Body).Decode(&gradeActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(gradeActual)).Equal(1)
+			g.Assert(gradeActual[0].Status).Equal(symbol.RegradeAccepted)
+			g.Assert(gradeActual[0].Response).Equal("you are right")
+			g.Assert(gradeActual[0].ResponderID.Int64).Equal(tutorJWT.Claims.LoginID)
+			g.Assert(gradeActual[0].NewAcquiredPoints.Int64).Equal(int64(0))
+
+			// the inbox only shows open requests by default
+			w = tape.Get("/api/v1/courses/1/regrades", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+			inboxActual = []RegradeResponse{}
+			err = json.NewDecoder(w.Body).Decode(&inboxActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(inboxActual)).Equal(0)
+		})
+
+		g.AfterEach(func() {
+			tape.AfterEach()
+		})
+	})
+
+}
@@ -230,6 +230,7 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 				r.Get("/ping", appAPI.Common.PingHandler)
 				r.Get("/version", appAPI.Common.VersionHandler)
 				r.Get("/privacy_statement", appAPI.Common.PrivacyStatementHandler)
+				r.Get("/calendar/{token}", appAPI.Calendar.GetFeedHandler)
 			})
 
 			// protected routes
@@ -257,6 +258,7 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 				r.Route("/courses", func(r chi.Router) {
 					r.Get("/", appAPI.Course.IndexHandler)
 					r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Course.CreateHandler)
+					r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/import", appAPI.Course.ImportArchiveHandler)
 
 					r.Route("/{course_id}", func(r chi.Router) {
 						r.Use(appAPI.Course.Context)
@@ -273,14 +275,93 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 								r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
 
 								r.Post("/emails", appAPI.Course.SendEmailHandler)
+								r.Get("/pseudonyms", appAPI.Course.PseudonymsHandler)
 								r.Put("/", appAPI.Course.EditHandler)
 								r.Delete("/", appAPI.Course.DeleteHandler)
+								r.Post("/clone", appAPI.Course.CloneHandler)
+								r.Get("/archive", appAPI.Course.ExportArchiveHandler)
 							})
 
 							r.Get("/enrollments", appAPI.Course.IndexEnrollmentsHandler)
 							r.Delete("/enrollments", appAPI.Course.DisenrollHandler)
+							r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/enrollments/import", appAPI.Course.ImportUsersHandler)
 							r.Get("/points", appAPI.Course.PointsHandler)
 							r.Get("/bids", appAPI.Course.BidsHandler)
+							r.Get("/admission", appAPI.Admission.GetHandler)
+							r.Get("/attendances/own", appAPI.Attendance.IndexOwnHandler)
+
+							r.Route("/enrollment_requests", func(r chi.Router) {
+								r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
+
+								r.Get("/", appAPI.Course.IndexEnrollmentRequestsHandler)
+
+								r.Route("/{user_id}", func(r chi.Router) {
+									r.Use(appAPI.User.Context)
+
+									r.Post("/approve", appAPI.Course.ApproveEnrollmentRequestHandler)
+									r.Delete("/", appAPI.Course.RejectEnrollmentRequestHandler)
+								})
+							})
+
+							r.Route("/discussions", func(r chi.Router) {
+								r.Get("/", appAPI.Discussion.IndexHandler)
+								r.Post("/", appAPI.Discussion.CreateHandler)
+								r.Post("/subscription", appAPI.Discussion.SubscribeCourseHandler)
+								r.Delete("/subscription", appAPI.Discussion.UnsubscribeCourseHandler)
+
+								r.Route("/{thread_id}", func(r chi.Router) {
+									r.Use(appAPI.Discussion.Context)
+
+									r.Get("/", appAPI.Discussion.GetHandler)
+									r.Put("/", appAPI.Discussion.EditHandler)
+									r.Delete("/", appAPI.Discussion.DeleteHandler)
+									r.Post("/subscription", appAPI.Discussion.SubscribeHandler)
+									r.Delete("/subscription", appAPI.Discussion.UnsubscribeHandler)
+									r.Post("/posts", appAPI.Discussion.CreatePostHandler)
+
+									r.Route("/posts/{post_id}", func(r chi.Router) {
+										r.Use(appAPI.Discussion.PostContext)
+
+										r.Put("/", appAPI.Discussion.EditPostHandler)
+										r.Delete("/", appAPI.Discussion.DeletePostHandler)
+										r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Post("/endorse", appAPI.Discussion.EndorsePostHandler)
+										r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Delete("/endorse", appAPI.Discussion.UnendorsePostHandler)
+									})
+								})
+							})
+
+							r.Route("/announcements", func(r chi.Router) {
+								r.Get("/feed", appAPI.Announcement.FeedHandler)
+
+								r.Route("/", func(r chi.Router) {
+									r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
+
+									r.Get("/", appAPI.Announcement.IndexHandler)
+									r.Post("/", appAPI.Announcement.CreateHandler)
+
+									r.Route("/{announcement_id}", func(r chi.Router) {
+										r.Use(appAPI.Announcement.Context)
+
+										r.Get("/", appAPI.Announcement.GetHandler)
+										r.Put("/", appAPI.Announcement.EditHandler)
+										r.Delete("/", appAPI.Announcement.DeleteHandler)
+									})
+								})
+							})
+
+							r.Route("/admissions", func(r chi.Router) {
+								r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
+
+								r.Get("/", appAPI.Admission.IndexHandler)
+								r.Get("/export", appAPI.Admission.ExportHandler)
+
+								r.Route("/{user_id}", func(r chi.Router) {
+									r.Use(appAPI.User.Context)
+
+									r.Put("/", appAPI.Admission.EditHandler)
+									r.Delete("/", appAPI.Admission.DeleteHandler)
+								})
+							})
 
 							r.Route("/enrollments/{user_id}", func(r chi.Router) {
 								r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
@@ -294,6 +375,7 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 							r.Route("/sheets", func(r chi.Router) {
 								r.Get("/", appAPI.Sheet.IndexHandler)
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Sheet.CreateHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Put("/order", appAPI.Sheet.OrderHandler)
 
 								r.Route("/{sheet_id}", func(r chi.Router) {
 									r.Use(appAPI.Sheet.Context)
@@ -302,6 +384,7 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 									r.Route("/tasks", func(r chi.Router) {
 										r.Get("/", appAPI.Task.IndexHandler)
 										r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Task.CreateHandler)
+										r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Put("/order", appAPI.Task.OrderHandler)
 									})
 
 									r.Get("/file", appAPI.Sheet.GetFileHandler)
@@ -313,6 +396,7 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 										r.Put("/", appAPI.Sheet.EditHandler)
 										r.Delete("/", appAPI.Sheet.DeleteHandler)
 										r.Post("/file", appAPI.Sheet.ChangeFileHandler)
+										r.Post("/release", appAPI.Sheet.ReleaseHandler)
 									})
 								})
 							})
@@ -321,11 +405,35 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 								r.Get("/own", appAPI.Group.GetMineHandler)
 								r.Get("/", appAPI.Group.IndexHandler)
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Group.CreateHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/assignment", appAPI.Group.AssignmentHandler)
 
 								r.Route("/{group_id}", func(r chi.Router) {
 									r.Use(appAPI.Group.Context)
 
 									r.Post("/bids", appAPI.Group.ChangeBidHandler)
+									r.Post("/join", appAPI.Group.JoinHandler)
+									r.Delete("/join", appAPI.Group.LeaveHandler)
+									r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/waitlist", appAPI.Group.IndexWaitlistHandler)
+									r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/attendances/export", appAPI.Attendance.ExportGroupHandler)
+
+									r.Route("/sessions", func(r chi.Router) {
+										r.Get("/", appAPI.Session.IndexHandler)
+										r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Session.CreateHandler)
+
+										r.Route("/{session_id}", func(r chi.Router) {
+											r.Use(appAPI.Session.Context)
+											r.Get("/", appAPI.Session.GetHandler)
+											r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/attendances/{date}", appAPI.Attendance.IndexSessionHandler)
+											r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Put("/attendances/{date}", appAPI.Attendance.EditSessionHandler)
+
+											r.Route("/", func(r chi.Router) {
+												r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
+												r.Put("/", appAPI.Session.EditHandler)
+												r.Delete("/", appAPI.Session.DeleteHandler)
+											})
+										})
+									})
+
 									r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Post("/emails", appAPI.Group.SendEmailHandler)
 									r.Get("/enrollments", appAPI.Group.IndexEnrollmentsHandler)
 									r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/enrollments", appAPI.Group.EditGroupEnrollmentHandler)
@@ -340,6 +448,19 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 								})
 							})
 
+							r.Route("/group_swaps", func(r chi.Router) {
+								r.Get("/own", appAPI.GroupSwap.IndexOwnHandler)
+								r.Post("/", appAPI.GroupSwap.CreateHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Get("/", appAPI.GroupSwap.IndexHandler)
+
+								r.Route("/{swap_id}", func(r chi.Router) {
+									r.Use(appAPI.GroupSwap.Context)
+									r.Delete("/", appAPI.GroupSwap.DeleteHandler)
+									r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/approve", appAPI.GroupSwap.ApproveHandler)
+									r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/reject", appAPI.GroupSwap.RejectHandler)
+								})
+							})
+
 							r.Route("/exams", func(r chi.Router) {
 								r.Get("/", appAPI.Exam.IndexHandler)
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Exam.CreateHandler)
@@ -369,21 +490,68 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/", appAPI.Grade.IndexHandler)
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Get("/summary", appAPI.Grade.IndexSummaryHandler)
 								r.Get("/missing", appAPI.Grade.IndexMissingHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Get("/progress", appAPI.Grade.ProgressHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/reassign", appAPI.Grade.ReassignHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Get("/export", appAPI.Grade.ExportHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Post("/import", appAPI.Grade.ImportHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.TUTOR)).Post("/batch", appAPI.Grade.BatchHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Get("/reconciliations", appAPI.Grade.ReconciliationsHandler)
 
 								r.Route("/{grade_id}", func(r chi.Router) {
 									r.Use(appAPI.Grade.Context)
-									r.Use(authorize.RequiresAtLeastCourseRole(authorize.TUTOR))
 
-									r.Put("/", appAPI.Grade.EditHandler)
-									r.Get("/", appAPI.Grade.GetByIDHandler)
-									r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/public_result", appAPI.Grade.PublicResultEditHandler)
-									r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/private_result", appAPI.Grade.PrivateResultEditHandler)
+									r.Get("/feedback_file", appAPI.Grade.GetFeedbackFileHandler)
+
+									r.Route("/", func(r chi.Router) {
+										r.Use(authorize.RequiresAtLeastCourseRole(authorize.TUTOR))
+
+										r.Put("/", appAPI.Grade.EditHandler)
+										r.Get("/", appAPI.Grade.GetByIDHandler)
+										r.Get("/history", appAPI.Grade.HistoryHandler)
+										r.Get("/regrades", appAPI.Regrade.IndexOfGradeHandler)
+										r.Get("/second", appAPI.Grade.GetSecondHandler)
+										r.Put("/second", appAPI.Grade.EditSecondHandler)
+										r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Put("/reconcile", appAPI.Grade.ReconcileHandler)
+										r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Put("/tutor", appAPI.Grade.ChangeTutorHandler)
+										r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/public_result", appAPI.Grade.PublicResultEditHandler)
+										r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/private_result", appAPI.Grade.PrivateResultEditHandler)
+										r.Post("/feedback_file", appAPI.Grade.ChangeFeedbackFileHandler)
+									})
+								})
+							})
+
+							r.Route("/regrades", func(r chi.Router) {
+								r.Use(authorize.RequiresAtLeastCourseRole(authorize.TUTOR))
+
+								r.Get("/", appAPI.Regrade.IndexHandler)
+
+								r.Route("/{regrade_id}", func(r chi.Router) {
+									r.Use(appAPI.Regrade.Context)
+
+									r.Get("/", appAPI.Regrade.GetHandler)
+									r.Put("/", appAPI.Regrade.EditHandler)
+								})
+							})
+
+							r.Route("/snippets", func(r chi.Router) {
+								r.Use(authorize.RequiresAtLeastCourseRole(authorize.TUTOR))
+
+								r.Get("/", appAPI.Snippet.IndexHandler)
+								r.Post("/", appAPI.Snippet.CreateHandler)
+
+								r.Route("/{snippet_id}", func(r chi.Router) {
+									r.Use(appAPI.Snippet.Context)
+
+									r.Get("/", appAPI.Snippet.GetHandler)
+									r.Put("/", appAPI.Snippet.EditHandler)
+									r.Delete("/", appAPI.Snippet.DeleteHandler)
 								})
 							})
 
 							r.Route("/materials", func(r chi.Router) {
 								r.Get("/", appAPI.Material.IndexHandler)
 								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Post("/", appAPI.Material.CreateHandler)
+								r.With(authorize.RequiresAtLeastCourseRole(authorize.ADMIN)).Put("/order", appAPI.Material.OrderHandler)
 
 								r.Route("/{material_id}", func(r chi.Router) {
 									r.Use(appAPI.Material.Context)
@@ -423,6 +591,8 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 									r.Get("/submission", appAPI.Submission.GetFileHandler)
 									r.Post("/submission", appAPI.Submission.UploadFileHandler)
 									r.Get("/result", appAPI.Task.GetSubmissionResultHandler)
+									r.Get("/regrades", appAPI.Regrade.IndexOfTaskHandler)
+									r.Post("/regrades", appAPI.Regrade.CreateHandler)
 
 									r.Route("/", func(r chi.Router) {
 										r.Use(authorize.RequiresAtLeastCourseRole(authorize.ADMIN))
@@ -450,11 +620,13 @@ func New(db *sqlx.DB, promhttp http.Handler, log bool) (*chi.Mux, error) {
 
 				r.Get("/account", appAPI.Account.GetHandler)
 				r.Get("/account/enrollments", appAPI.Account.GetEnrollmentsHandler)
+				r.Get("/account/export", appAPI.Account.ExportHandler)
 				r.Get("/account/exams/enrollments", appAPI.Account.GetExamEnrollmentsHandler)
 				r.Get("/account/avatar", appAPI.Account.GetAvatarHandler)
 				r.Post("/account/avatar", appAPI.Account.ChangeAvatarHandler)
 				r.Delete("/account/avatar", appAPI.Account.DeleteAvatarHandler)
 				r.Patch("/account", appAPI.Account.EditHandler)
+				r.Post("/account/calendar", appAPI.Calendar.ChangeTokenHandler)
 				r.Delete("/auth/sessions", appAPI.Auth.LogoutHandler)
 
 			})
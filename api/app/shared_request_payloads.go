@@ -41,3 +41,35 @@ func (body *EmailRequest) Bind(r *http.Request) error {
 
 	return err
 }
+
+// OrderRequest is the request payload to rearrange the items of a collection.
+type OrderRequest struct {
+	IDs []int64 `json:"ids" example:"3,1,2"`
+}
+
+// Bind preprocesses a OrderRequest.
+func (body *OrderRequest) Bind(r *http.Request) error {
+	return validation.ValidateStruct(body,
+		validation.Field(&body.IDs, validation.Required),
+	)
+}
+
+// Permutes checks whether the requested ids contain every given id exactly once.
+func (body *OrderRequest) Permutes(ids []int64) bool {
+	if len(body.IDs) != len(ids) {
+		return false
+	}
+
+	remaining := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		remaining[id] = true
+	}
+
+	for _, id := range body.IDs {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
@@ -21,6 +21,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -30,8 +31,11 @@ import (
 	"github.com/infomark-org/infomark/api/helper"
 	"github.com/infomark-org/infomark/auth/authenticate"
 	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
 	"github.com/infomark-org/infomark/model"
 	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // SheetResource specifies Sheet management handler.
@@ -57,7 +61,7 @@ func NewSheetResource(stores *Stores) *SheetResource {
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  get all sheets in course
 // DESCRIPTION:
-// The sheets are ordered by their names
+// The sheets are ordered by their position within the course.
 func (rs *SheetResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 
 	var sheets []model.Sheet
@@ -108,6 +112,14 @@ func (rs *SheetResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		Name:      data.Name,
 		PublishAt: data.PublishAt,
 		DueAt:     data.DueAt,
+
+		GradesReleaseAt: data.GradesReleaseAt,
+		GradesWithheld:  data.GradesWithheld,
+		GradingStrategy: data.GradingStrategy,
+		BlindGrading:    data.BlindGrading,
+		MinPercentage:   data.MinPercentage,
+
+		GradesReleaseNotify: data.GradesReleaseNotify,
 	}
 
 	// create Sheet entry in database
@@ -163,6 +175,9 @@ func (rs *SheetResource) GetHandler(w http.ResponseWriter, r *http.Request) {
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  update a specific sheet
+// DESCRIPTION:
+// If the grades are not released anymore after the change, e.g. because the
+// release has been postponed, the students are notified again on the next release.
 func (rs *SheetResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	sheet := r.Context().Value(symbol.CtxKeySheet).(*model.Sheet)
 
@@ -178,9 +193,71 @@ func (rs *SheetResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	sheet.Name = data.Name
 	sheet.PublishAt = data.PublishAt
 	sheet.DueAt = data.DueAt
+	sheet.GradesReleaseAt = data.GradesReleaseAt
+	sheet.GradesWithheld = data.GradesWithheld
+	sheet.GradingStrategy = data.GradingStrategy
+	sheet.BlindGrading = data.BlindGrading
+	sheet.MinPercentage = data.MinPercentage
+	sheet.GradesReleaseNotify = data.GradesReleaseNotify
 
 	// update database entry
-	if err := rs.Stores.Sheet.Update(sheet); err != nil {
+	err := rs.Stores.Transaction(func(tx *Stores) error {
+		if err := tx.Sheet.Update(sheet); err != nil {
+			return err
+		}
+		// a release which has been moved into the future is announced again
+		if !GradesReleasedYet(sheet) {
+			return tx.Sheet.RearmGradeReleaseEmails(sheet.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// OrderHandler is public endpoint for
+// URL: /courses/{course_id}/sheets/order
+// URLPARAM: course_id,integer
+// METHOD: put
+// TAG: sheets
+// REQUEST: OrderRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  rearrange the sheets of a course
+// DESCRIPTION:
+// The request has to list the ids of all sheets in the course exactly once.
+func (rs *SheetResource) OrderHandler(w http.ResponseWriter, r *http.Request) {
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &OrderRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	sheets, err := rs.Stores.Sheet.SheetsOfCourse(course.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	sheetIDs := []int64{}
+	for _, sheet := range sheets {
+		sheetIDs = append(sheetIDs, sheet.ID)
+	}
+
+	if !data.Permutes(sheetIDs) {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("ids have to contain every sheet of the course exactly once")))
+		return
+	}
+
+	if err := rs.Stores.Sheet.Reorder(course.ID, data.IDs); err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
@@ -276,6 +353,7 @@ func (rs *SheetResource) ChangeFileHandler(w http.ResponseWriter, r *http.Reques
 func (rs *SheetResource) PointsHandler(w http.ResponseWriter, r *http.Request) {
 	sheet := r.Context().Value(symbol.CtxKeySheet).(*model.Sheet)
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
 
 	taskPoints, err := rs.Stores.Sheet.PointsForUser(accessClaims.LoginID, sheet.ID)
 	if err != nil {
@@ -283,6 +361,14 @@ func (rs *SheetResource) PointsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// students should not see any manual grading before the release
+	if givenRole == authorize.STUDENT && !GradesReleasedYet(sheet) {
+		for k := range taskPoints {
+			taskPoints[k].AquiredPoints = 0
+			taskPoints[k].AchievablePoints = 0
+		}
+	}
+
 	// resp := &SheetPointsResponse{SheetPoints: taskPoints}
 	if err := render.RenderList(w, r, newTaskPointsListResponse(taskPoints)); err != nil {
 		render.Render(w, r, ErrRender(err))
@@ -292,6 +378,110 @@ func (rs *SheetResource) PointsHandler(w http.ResponseWriter, r *http.Request) {
 	render.Status(r, http.StatusOK)
 }
 
+// ReleaseHandler is public endpoint for
+// URL: /courses/{course_id}/sheets/{sheet_id}/release
+// URLPARAM: course_id,integer
+// URLPARAM: sheet_id,integer
+// METHOD: post
+// TAG: sheets
+// REQUEST: SheetReleaseRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  release the grades of a sheet to the students
+// DESCRIPTION:
+// This releases the grades immediately. If "notify" is given, all students of
+// the course who did not mute emails will receive an email. Otherwise no email will be sent, even if
+// the sheet asks for a notification on its scheduled release.
+func (rs *SheetResource) ReleaseHandler(w http.ResponseWriter, r *http.Request) {
+	sheet := r.Context().Value(symbol.CtxKeySheet).(*model.Sheet)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+
+	data := &SheetReleaseRequest{}
+
+	// parse JSON request into struct
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	sheet.GradesWithheld = false
+	sheet.GradesReleaseAt = null.TimeFrom(NowUTC())
+
+	// update database entry
+	if err := rs.Stores.Sheet.Update(sheet); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	// a manual release replaces the scheduled notification
+	claimed, err := rs.Stores.Sheet.ClaimGradeReleaseEmails(sheet.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if data.Notify && claimed {
+		if err := sendGradesReleasedEmails(rs.Stores, course, sheet); err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// SendGradeReleaseEmails notifies all students of the course once the grades
+// of the sheet have been released by its schedule. Every release is only
+// announced once, postponing it re-arms the notification.
+func SendGradeReleaseEmails(stores *Stores, sheet *model.Sheet) error {
+	if !sheet.GradesReleaseNotify || !GradesReleasedYet(sheet) {
+		return nil
+	}
+
+	claimed, err := stores.Sheet.ClaimGradeReleaseEmails(sheet.ID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	course, err := stores.Sheet.IdentifyCourseOfSheet(sheet.ID)
+	if err != nil {
+		return err
+	}
+
+	return sendGradesReleasedEmails(stores, course, sheet)
+}
+
+func sendGradesReleasedEmails(stores *Stores, course *model.Course, sheet *model.Sheet) error {
+	recipients, err := stores.Sheet.GradeReleaseRecipients(course.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		msg, err := email.NewEmailFromTemplate(
+			configuration.Configuration.Server.Email.From,
+			recipient.Email,
+			fmt.Sprintf("[%s] Grades for %s released", course.Name, sheet.Name),
+			email.GradesReleasedTemplateEN,
+			map[string]string{
+				"first_name":  recipient.FirstName,
+				"last_name":   recipient.LastName,
+				"course_name": course.Name,
+				"sheet_name":  sheet.Name,
+				"course_url":  fmt.Sprintf("%s/#/course/%d", configuration.Configuration.Server.ExternalURL(), course.ID),
+			})
+		if err != nil {
+			return err
+		}
+
+		email.OutgoingEmailsChannel <- msg
+	}
+
+	return nil
+}
+
 // .............................................................................
 
 // Context middleware is used to load an Sheet object from
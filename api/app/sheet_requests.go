@@ -25,6 +25,8 @@ import (
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // SheetRequest is the request payload for Sheet management.
@@ -32,6 +34,14 @@ type SheetRequest struct {
 	Name      string    `json:"name" example:"Blatt 42"`
 	PublishAt time.Time `json:"publish_at" example:"auto"`
 	DueAt     time.Time `json:"due_at" example:"auto"`
+
+	GradesReleaseAt null.Time `json:"grades_release_at" example:"auto"`
+	GradesWithheld  bool      `json:"grades_withheld" example:"false"`
+	GradingStrategy int       `json:"grading_strategy" example:"0"`
+	BlindGrading    bool      `json:"blind_grading" example:"false"`
+	MinPercentage   int       `json:"min_percentage" example:"0"`
+
+	GradesReleaseNotify bool `json:"grades_release_notify" example:"false"`
 }
 
 // Bind preprocesses a SheetRequest.
@@ -60,6 +70,20 @@ func (body *SheetRequest) Validate() error {
 			&body.Name,
 			validation.Required,
 		),
+		validation.Field(
+			&body.GradingStrategy,
+			validation.In(
+				symbol.GradingByGroupTutor,
+				symbol.GradingRoundRobin,
+				symbol.GradingBalanced,
+				symbol.GradingByTask,
+			),
+		),
+		validation.Field(
+			&body.MinPercentage,
+			validation.Min(0),
+			validation.Max(100),
+		),
 	)
 
 	if err == nil {
@@ -70,3 +94,18 @@ func (body *SheetRequest) Validate() error {
 
 	return err
 }
+
+// SheetReleaseRequest is the request payload for releasing the grades of a sheet.
+type SheetReleaseRequest struct {
+	Notify bool `json:"notify" example:"true"`
+}
+
+// Bind preprocesses a SheetReleaseRequest.
+func (body *SheetReleaseRequest) Bind(r *http.Request) error {
+
+	if body == nil {
+		return errors.New("missing \"release\" data")
+	}
+
+	return nil
+}
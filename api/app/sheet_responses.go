@@ -28,6 +28,7 @@ import (
 	"github.com/go-chi/render"
 	"github.com/infomark-org/infomark/auth/authorize"
 	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // SheetResponse is the response payload for Sheet management.
@@ -37,6 +38,16 @@ type SheetResponse struct {
 	FileURL   string    `json:"file_url" example:"/api/v1/sheets/13/file"`
 	PublishAt time.Time `json:"publish_at" example:"auto"`
 	DueAt     time.Time `json:"due_at" example:"auto"`
+
+	GradesReleaseAt null.Time `json:"grades_release_at" example:"auto"`
+	GradesWithheld  bool      `json:"grades_withheld" example:"false"`
+	GradesReleased  bool      `json:"grades_released" example:"true"`
+	GradingStrategy int       `json:"grading_strategy" example:"0"`
+	BlindGrading    bool      `json:"blind_grading" example:"false"`
+	MinPercentage   int       `json:"min_percentage" example:"0"`
+
+	GradesReleaseNotify     bool      `json:"grades_release_notify" example:"false"`
+	GradesReleaseNotifiedAt null.Time `json:"grades_release_notified_at" example:"auto"`
 }
 
 // Render post-processes a SheetResponse.
@@ -52,6 +63,16 @@ func (rs *SheetResource) newSheetResponse(p *model.Sheet) *SheetResponse {
 		PublishAt: p.PublishAt,
 		DueAt:     p.DueAt,
 		FileURL:   fmt.Sprintf("/api/v1/sheets/%s/file", strconv.FormatInt(p.ID, 10)),
+
+		GradesReleaseAt: p.GradesReleaseAt,
+		GradesWithheld:  p.GradesWithheld,
+		GradesReleased:  GradesReleasedYet(p),
+		GradingStrategy: p.GradingStrategy,
+		BlindGrading:    p.BlindGrading,
+		MinPercentage:   p.MinPercentage,
+
+		GradesReleaseNotify:     p.GradesReleaseNotify,
+		GradesReleaseNotifiedAt: p.GradesReleaseNotifiedAt,
 	}
 }
 
@@ -70,10 +91,11 @@ func (rs *SheetResource) newSheetListResponse(givenRole authorize.CourseRole, Sh
 
 // TaskPointsResponse returns a performance summary for a task and student
 type TaskPointsResponse struct {
-	AquiredPoints    int `json:"acquired_points" example:"58"`
-	AchievablePoints int `json:"achievable_points" example:"42"`
-	MaxPoints        int `json:"max_points" example:"90"`
-	TaskID           int `json:"task_id" example:"2"`
+	AquiredPoints    int  `json:"acquired_points" example:"58"`
+	AchievablePoints int  `json:"achievable_points" example:"42"`
+	MaxPoints        int  `json:"max_points" example:"90"`
+	TaskID           int  `json:"task_id" example:"2"`
+	IsBonus          bool `json:"is_bonus" example:"false"`
 }
 
 // Render post-processes a TaskPointsResponse.
@@ -87,6 +109,7 @@ func newTaskPointsResponse(p *model.TaskPoints) *TaskPointsResponse {
 		AchievablePoints: p.AchievablePoints,
 		MaxPoints:        p.MaxPoints,
 		TaskID:           p.TaskID,
+		IsBonus:          p.IsBonus,
 	}
 }
 
@@ -30,6 +30,8 @@ import (
 	"github.com/infomark-org/infomark/api/helper"
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
 )
 
 func TestSheet(t *testing.T) {
@@ -255,6 +257,52 @@ func TestSheet(t *testing.T) {
 			g.Assert(len(entriesAfter)).Equal(len(entriesBefore) - 1)
 		})
 
+		g.It("Should rearrange sheets", func() {
+			sheetsBefore, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+
+			ids := []int64{}
+			for k := len(sheetsBefore) - 1; k >= 0; k-- {
+				ids = append(ids, sheetsBefore[k].ID)
+			}
+
+			w := tape.Put("/api/v1/courses/1/sheets/order", H{"ids": ids}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put("/api/v1/courses/1/sheets/order", H{"ids": ids}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// every sheet has to be listed
+			w = tape.Put("/api/v1/courses/1/sheets/order", H{"ids": ids[1:]}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Put("/api/v1/courses/1/sheets/order", H{"ids": ids}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/sheets", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			sheetsActual := []SheetResponse{}
+			err = json.NewDecoder(w.Body).Decode(&sheetsActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(sheetsActual)).Equal(len(ids))
+			for k := range sheetsActual {
+				g.Assert(sheetsActual[k].ID).Equal(ids[k])
+			}
+
+			// new sheets are appended
+			sheet, err := stores.Sheet.Create(&model.Sheet{
+				Name:      "appended",
+				PublishAt: NowUTC(),
+				DueAt:     NowUTC(),
+			}, 1)
+			g.Assert(err).Equal(nil)
+
+			sheetsAfter, err := stores.Sheet.SheetsOfCourse(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(sheetsAfter[len(sheetsAfter)-1].ID).Equal(sheet.ID)
+		})
+
 		g.It("Should see points for a sheet", func() {
 			w := tape.Get("/api/v1/courses/1/sheets/1/points")
 			g.Assert(w.Code).Equal(http.StatusUnauthorized)
@@ -264,6 +312,159 @@ func TestSheet(t *testing.T) {
 
 		})
 
+		g.It("Should hide points until grades are released", func() {
+			sheet, err := stores.Sheet.Get(1)
+			g.Assert(err).Equal(nil)
+
+			sheet.GradesWithheld = true
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			pointsExpected, err := stores.Sheet.PointsForUser(112, 1)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Get("/api/v1/courses/1/sheets/1/points", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			pointsActual := []TaskPointsResponse{}
+			err = json.NewDecoder(w.Body).Decode(&pointsActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pointsActual)).Equal(len(pointsExpected))
+			for _, el := range pointsActual {
+				g.Assert(el.AquiredPoints).Equal(0)
+				g.Assert(el.AchievablePoints).Equal(0)
+			}
+
+			w = tape.Post("/api/v1/courses/1/sheets/1/release", H{"notify": true}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/sheets/1/release", H{"notify": true}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Post("/api/v1/courses/1/sheets/1/release", H{"notify": false}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			sheetAfter, err := stores.Sheet.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(sheetAfter.GradesWithheld).Equal(false)
+			g.Assert(sheetAfter.GradesReleaseAt.Valid).Equal(true)
+
+			w = tape.Get("/api/v1/courses/1/sheets/1/points", studentJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			pointsActual = []TaskPointsResponse{}
+			err = json.NewDecoder(w.Body).Decode(&pointsActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pointsActual)).Equal(len(pointsExpected))
+			for k, el := range pointsActual {
+				g.Assert(el.AquiredPoints).Equal(pointsExpected[k].AquiredPoints)
+				g.Assert(el.AchievablePoints).Equal(pointsExpected[k].AchievablePoints)
+			}
+		})
+
+		g.It("Should notify students about a scheduled release once", func() {
+			sheet, err := stores.Sheet.Get(1)
+			g.Assert(err).Equal(nil)
+
+			sheet.GradesReleaseAt = null.TimeFrom(NowUTC().Add(time.Hour))
+			sheet.GradesReleaseNotify = true
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			pending, err := stores.Sheet.PendingGradeReleaseEmails()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pending)).Equal(0)
+
+			sheet.GradesReleaseAt = null.TimeFrom(NowUTC().Add(-time.Minute))
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			pending, err = stores.Sheet.PendingGradeReleaseEmails()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pending)).Equal(1)
+			g.Assert(pending[0].ID).Equal(sheet.ID)
+
+			g.Assert(SendGradeReleaseEmails(stores, &pending[0])).Equal(nil)
+
+			sheetAfter, err := stores.Sheet.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(sheetAfter.GradesReleaseNotifiedAt.Valid).IsTrue()
+
+			// editing the sheet keeps the notification state
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			pending, err = stores.Sheet.PendingGradeReleaseEmails()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pending)).Equal(0)
+
+			claimed, err := stores.Sheet.ClaimGradeReleaseEmails(sheet.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(claimed).IsFalse()
+		})
+
+		g.It("Should notify students again when the release is postponed", func() {
+			_, err := tape.DB.Exec(`
+UPDATE sheets
+SET grades_release_notify = true, grades_release_at = now() - interval '1 minute', grades_release_notified_at = now()
+WHERE id = 1`)
+			g.Assert(err).Equal(nil)
+
+			sheet, err := stores.Sheet.Get(1)
+			g.Assert(err).Equal(nil)
+
+			// changes which keep the grades released do not re-arm
+			sheetSent := SheetRequest{
+				Name:                "renamed",
+				PublishAt:           sheet.PublishAt,
+				DueAt:               sheet.DueAt,
+				GradesReleaseAt:     sheet.GradesReleaseAt,
+				GradesReleaseNotify: true,
+			}
+			w := tape.Put("/api/v1/courses/1/sheets/1", tape.ToH(sheetSent), adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			sheetAfter, err := stores.Sheet.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(sheetAfter.GradesReleaseNotifiedAt.Valid).IsTrue()
+
+			sheetSent.GradesReleaseAt = null.TimeFrom(NowUTC().Add(time.Hour))
+			w = tape.Put("/api/v1/courses/1/sheets/1", tape.ToH(sheetSent), adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			sheetAfter, err = stores.Sheet.Get(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(sheetAfter.GradesReleaseNotifiedAt.Valid).IsFalse()
+
+			// students who muted emails are skipped
+			_, err = tape.DB.Exec(`UPDATE users SET announcement_emails_muted = true WHERE id = 112`)
+			g.Assert(err).Equal(nil)
+
+			recipients, err := stores.Sheet.GradeReleaseRecipients(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(recipients) > 0).IsTrue()
+			for _, recipient := range recipients {
+				g.Assert(recipient.ID == 112).IsFalse()
+			}
+		})
+
+		g.It("Should not notify students again after a manual release", func() {
+			sheet, err := stores.Sheet.Get(1)
+			g.Assert(err).Equal(nil)
+
+			sheet.GradesWithheld = true
+			sheet.GradesReleaseNotify = true
+			err = stores.Sheet.Update(sheet)
+			g.Assert(err).Equal(nil)
+
+			w := tape.Post("/api/v1/courses/1/sheets/1/release", H{"notify": false}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			pending, err := stores.Sheet.PendingGradeReleaseEmails()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(pending)).Equal(0)
+		})
+
 		g.It("Permission test", func() {
 			url := "/api/v1/courses/1/sheets"
 
@@ -22,8 +22,10 @@ package app
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/go-chi/chi"
@@ -35,6 +37,7 @@ import (
 	"github.com/infomark-org/infomark/configuration"
 	"github.com/infomark-org/infomark/model"
 	"github.com/infomark-org/infomark/symbol"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // SubmissionResource specifies Submission management handler.
@@ -142,6 +145,11 @@ func (rs *SubmissionResource) GetCollectionHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	if !isGroupTutor(r, rs.Stores, group) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
 	hnd := helper.NewSubmissionsCollectionFileHandle(course.ID, sheet.ID, task.ID, group.ID)
 
 	text := ""
@@ -170,6 +178,8 @@ func (rs *SubmissionResource) GetCollectionHandler(w http.ResponseWriter, r *htt
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  get the zip file containing all submissions for a given task and a given group
+// DESCRIPTION:
+// Only the tutors of the group and admins of the course can download it.
 func (rs *SubmissionResource) GetCollectionFileHandler(w http.ResponseWriter, r *http.Request) {
 	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
 
@@ -203,6 +213,11 @@ func (rs *SubmissionResource) GetCollectionFileHandler(w http.ResponseWriter, r
 		return
 	}
 
+	if !isGroupTutor(r, rs.Stores, group) {
+		render.Render(w, r, ErrUnauthorized)
+		return
+	}
+
 	hnd := helper.NewSubmissionsCollectionFileHandle(course.ID, sheet.ID, task.ID, group.ID)
 
 	if !hnd.Exists() {
@@ -227,9 +242,14 @@ func (rs *SubmissionResource) GetCollectionFileHandler(w http.ResponseWriter, r
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  get the zip file of a specific submission
+// DESCRIPTION:
+// Tutors receive the file named after the pseudonym of the student when the sheet
+// uses blind grading and the grades are not released yet.
 func (rs *SubmissionResource) GetFileByIDHandler(w http.ResponseWriter, r *http.Request) {
 
 	submission := r.Context().Value(symbol.CtxKeySubmission).(*model.Submission)
+	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	task := r.Context().Value(symbol.CtxKeyTask).(*model.Task)
 	accessClaims := r.Context().Value(symbol.CtxKeyAccessClaims).(*authenticate.AccessClaims)
 	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
 
@@ -254,6 +274,22 @@ func (rs *SubmissionResource) GetFileByIDHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if givenRole == authorize.TUTOR {
+		sheet, err := rs.Stores.Task.IdentifySheetOfTask(task.ID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
+		if BlindGradingActive(sheet) {
+			publicFilename := fmt.Sprintf("%s-task%d.zip", Pseudonym(course.ID, submission.UserID), task.ID)
+			if err := hnd.WriteToBodyWithName(publicFilename, w); err != nil {
+				render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			}
+			return
+		}
+	}
+
 	if err := hnd.WriteToBody(w); err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
@@ -322,6 +358,12 @@ func (rs *SubmissionResource) UploadFileHandler(w http.ResponseWriter, r *http.R
 			return
 		}
 
+		assignedTutorID, err := rs.gradingTutorFor(course, sheet, task, usedUserID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+
 		// create also empty grade, which will be filled in later
 		grade = &model.Grade{
 			PublicExecutionState:  0,
@@ -334,6 +376,7 @@ func (rs *SubmissionResource) UploadFileHandler(w http.ResponseWriter, r *http.R
 			Feedback:              "",
 			TutorID:               1,
 			SubmissionID:          submission.ID,
+			AssignedTutorID:       assignedTutorID,
 		}
 
 		// fetch id from grade as we need it
@@ -351,13 +394,17 @@ func (rs *SubmissionResource) UploadFileHandler(w http.ResponseWriter, r *http.R
 			return
 		}
 
+		previousGrade := *grade
+
 		// and update the grade
 		grade.PublicExecutionState = 0
 		grade.PrivateExecutionState = 0
 		grade.PublicTestLog = defaultPublicTestLog
 		grade.PrivateTestLog = defaultPrivateTestLog
 
-		err = rs.Stores.Grade.Update(grade)
+		// the re-test is part of the grade history
+		err = rs.Stores.Grade.UpdateWithHistory(grade, model.NewGradeHistory(
+			&previousGrade, grade, accessClaims.LoginID, symbol.GradeChangeResubmission))
 		if err != nil {
 			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 			return
@@ -450,6 +497,68 @@ func (rs *SubmissionResource) UploadFileHandler(w http.ResponseWriter, r *http.R
 	render.Status(r, http.StatusOK)
 }
 
+// gradingTutorFor picks the tutor who should grade a new submission according
+// to the grading strategy of the sheet. The result is invalid if no tutor
+// could be found.
+func (rs *SubmissionResource) gradingTutorFor(course *model.Course, sheet *model.Sheet, task *model.Task, userID int64) (null.Int, error) {
+	switch sheet.GradingStrategy {
+	case symbol.GradingByTask:
+		if task.GraderID.Valid {
+			return task.GraderID, nil
+		}
+
+	case symbol.GradingRoundRobin, symbol.GradingBalanced:
+		tutors, err := rs.Stores.Course.EnrolledUsers(course.ID,
+			[]string{"1"}, "%%", "%%", "%%", "%%", "%%",
+		)
+		if err != nil {
+			return null.Int{}, err
+		}
+
+		if len(tutors) == 0 {
+			break
+		}
+
+		sort.Slice(tutors, func(i, j int) bool { return tutors[i].ID < tutors[j].ID })
+
+		progress, err := rs.Stores.Grade.GetGradingProgress(course.ID, sheet.ID)
+		if err != nil {
+			return null.Int{}, err
+		}
+
+		total := 0
+		assigned := make(map[int64]int)
+		for _, p := range progress {
+			total += p.Total
+			assigned[p.TutorID] = p.Total
+		}
+
+		if sheet.GradingStrategy == symbol.GradingRoundRobin {
+			return null.IntFrom(tutors[total%len(tutors)].ID), nil
+		}
+
+		best := tutors[0].ID
+		for _, tutor := range tutors {
+			if assigned[tutor.ID] < assigned[best] {
+				best = tutor.ID
+			}
+		}
+		return null.IntFrom(best), nil
+	}
+
+	// fall back to the tutor of the group of the student
+	groups, err := rs.Stores.Group.GetInCourseWithUser(userID, course.ID)
+	if err != nil {
+		return null.Int{}, err
+	}
+
+	if len(groups) == 0 {
+		return null.Int{}, nil
+	}
+
+	return null.IntFrom(groups[0].TutorID), nil
+}
+
 // IndexHandler is public endpoint for
 // URL: /courses/{course_id}/submissions
 // URLPARAM: course_id,integer
@@ -464,20 +573,53 @@ func (rs *SubmissionResource) UploadFileHandler(w http.ResponseWriter, r *http.R
 // RESPONSE: 401,Unauthenticated
 // RESPONSE: 403,Unauthorized
 // SUMMARY:  Query submissions in a course
+// DESCRIPTION:
+// Tutors do not see the user_id of submissions in sheets which are graded
+// blindly, hence they cannot filter by user_id as long as such a sheet exists.
 func (rs *SubmissionResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 	course := r.Context().Value(symbol.CtxKeyCourse).(*model.Course)
+	givenRole := r.Context().Value(symbol.CtxKeyCourseRole).(authorize.CourseRole)
 
 	filterGroupID := helper.Int64FromURL(r, "group_id", 0)
 	filterUserID := helper.Int64FromURL(r, "user_id", 0)
 	filterSheetID := helper.Int64FromURL(r, "sheet_id", 0)
 	filterTaskID := helper.Int64FromURL(r, "task_id", 0)
 
+	blindSheets, err := blindSheetsOfCourse(rs.Stores, course.ID, givenRole)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	if userFilterRevealsIdentity(blindSheets, filterUserID, filterSheetID) {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("user_id cannot be used while a sheet is graded blindly")))
+		return
+	}
+
 	submissions, err := rs.Stores.Submission.GetFiltered(course.ID, filterGroupID, filterUserID, filterSheetID, filterTaskID)
 	if err != nil {
 		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
 		return
 	}
 
+	// the user id would reveal whom a pseudonym belongs to
+	blindTasks := make(map[int64]bool)
+	for sheetID := range blindSheets {
+		tasks, err := rs.Stores.Task.TasksOfSheet(sheetID)
+		if err != nil {
+			render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+			return
+		}
+		for _, task := range tasks {
+			blindTasks[task.ID] = true
+		}
+	}
+	for k := range submissions {
+		if blindTasks[submissions[k].TaskID] {
+			submissions[k].UserID = 0
+		}
+	}
+
 	// render JSON response
 	if err = render.RenderList(w, r, newSubmissionListResponse(submissions, course.ID)); err != nil {
 		render.Render(w, r, ErrRender(err))
@@ -91,6 +91,13 @@ func TestSubmission(t *testing.T) {
 			w = tape.Get("/api/v1/courses/1/tasks/1/groups/1/file", studentJWT)
 			g.Assert(w.Code).Equal(http.StatusForbidden)
 
+			// group 1 is not tutored by this tutor
+			w = tape.Get("/api/v1/courses/1/tasks/1/groups/1/file", tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			err = stores.Group.SetCoTutors(1, []int64{2})
+			g.Assert(err).Equal(nil)
+
 			w = tape.Get("/api/v1/courses/1/tasks/1/groups/1/file", tutorJWT)
 			g.Assert(w.Code).Equal(http.StatusOK)
 
@@ -307,6 +314,83 @@ func TestSubmission(t *testing.T) {
 			g.Assert(state).Equal(int64(0))
 		})
 
+		g.It("a new submission is assigned to a tutor but counts as ungraded", func() {
+			_, err := tape.DB.Exec("DELETE FROM submissions WHERE user_id = 112;")
+			g.Assert(err).Equal(nil)
+
+			task, err := stores.Task.Get(1)
+			g.Assert(err).Equal(nil)
+			task.IsBonus = false
+			g.Assert(stores.Task.Update(task)).Equal(nil)
+
+			sheet, err := stores.Task.IdentifySheetOfTask(task.ID)
+			g.Assert(err).Equal(nil)
+			sheet.PublishAt = NowUTC().Add(-time.Hour)
+			sheet.DueAt = NowUTC().Add(time.Hour)
+			g.Assert(stores.Sheet.Update(sheet)).Equal(nil)
+
+			filename := fmt.Sprintf("%s/empty.zip", configuration.Configuration.Server.Debugging.Fixtures)
+			w, err := tape.Upload("/api/v1/courses/1/tasks/1/submission", filename, "application/zip", studentJWT)
+			g.Assert(err).Equal(nil)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			submission, err := stores.Submission.GetByUserAndTask(112, 1)
+			g.Assert(err).Equal(nil)
+			defer helper.NewSubmissionFileHandle(submission.ID).Delete()
+
+			grade, err := stores.Grade.GetForSubmission(submission.ID)
+			g.Assert(err).Equal(nil)
+			// by definition user with id 1 is the system, so nobody graded it yet
+			g.Assert(grade.TutorID).Equal(int64(1))
+
+			groups, err := stores.Group.GetInCourseWithUser(112, 1)
+			g.Assert(err).Equal(nil)
+			if len(groups) > 0 {
+				g.Assert(grade.AssignedTutorID.Valid).IsTrue()
+				g.Assert(grade.AssignedTutorID.Int64).Equal(groups[0].TutorID)
+			}
+
+			sheetPointsOf := func() (int, int) {
+				points, err := stores.Course.PointsForUser(112, 1)
+				g.Assert(err).Equal(nil)
+				for _, el := range points {
+					if int64(el.SheetID) == sheet.ID {
+						return el.AquiredPoints, el.AchievablePoints
+					}
+				}
+				return 0, 0
+			}
+
+			taskPointsOf := func() int {
+				points, err := stores.Sheet.PointsForUser(112, sheet.ID)
+				g.Assert(err).Equal(nil)
+				for _, el := range points {
+					if int64(el.TaskID) == task.ID {
+						return el.AchievablePoints
+					}
+				}
+				return -1
+			}
+
+			_, achievableBefore := sheetPointsOf()
+			g.Assert(taskPointsOf()).Equal(0)
+
+			w = tape.Put(fmt.Sprintf("/api/v1/courses/1/grades/%d", grade.ID), H{
+				"acquired_points": 1,
+				"feedback":        "graded",
+			}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			gradeAfter, err := stores.Grade.Get(grade.ID)
+			g.Assert(err).Equal(nil)
+			g.Assert(gradeAfter.TutorID).Equal(tutorJWT.Claims.LoginID)
+			g.Assert(gradeAfter.AssignedTutorID).Equal(grade.AssignedTutorID)
+
+			_, achievableAfter := sheetPointsOf()
+			g.Assert(taskPointsOf()).Equal(task.MaxPoints)
+			g.Assert(achievableAfter).Equal(achievableBefore + task.MaxPoints)
+		})
+
 		g.It("Students can only access their own submissions", func() {
 
 			defer helper.NewSubmissionFileHandle(3001).Delete()
@@ -21,6 +21,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -76,6 +77,53 @@ func (rs *TaskResource) IndexHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// OrderHandler is public endpoint for
+// URL: /courses/{course_id}/sheets/{sheet_id}/tasks/order
+// URLPARAM: course_id,integer
+// URLPARAM: sheet_id,integer
+// METHOD: put
+// TAG: tasks
+// REQUEST: OrderRequest
+// RESPONSE: 204,NoContent
+// RESPONSE: 400,BadRequest
+// RESPONSE: 401,Unauthenticated
+// RESPONSE: 403,Unauthorized
+// SUMMARY:  rearrange the tasks of a sheet
+// DESCRIPTION:
+// The request has to list the ids of all tasks in the sheet exactly once.
+func (rs *TaskResource) OrderHandler(w http.ResponseWriter, r *http.Request) {
+	sheet := r.Context().Value(symbol.CtxKeySheet).(*model.Sheet)
+
+	data := &OrderRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBadRequestWithDetails(err))
+		return
+	}
+
+	tasks, err := rs.Stores.Task.TasksOfSheet(sheet.ID)
+	if err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	taskIDs := []int64{}
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	if !data.Permutes(taskIDs) {
+		render.Render(w, r, ErrBadRequestWithDetails(errors.New("ids have to contain every task of the sheet exactly once")))
+		return
+	}
+
+	if err := rs.Stores.Task.Reorder(sheet.ID, data.IDs); err != nil {
+		render.Render(w, r, ErrInternalServerErrorWithDetails(err))
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
 // MissingIndexHandler is public endpoint for
 // URL: /courses/{course_id}/tasks/missing
 // URLPARAM: course_id,integer
@@ -136,6 +184,11 @@ func (rs *TaskResource) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		MaxPoints:          data.MaxPoints,
 		PublicDockerImage:  null.StringFrom(data.PublicDockerImage),
 		PrivateDockerImage: null.StringFrom(data.PrivateDockerImage),
+		GraderID:           data.GraderID,
+		IsBonus:            data.IsBonus,
+		BonusPoints:        data.BonusPoints,
+		DoubleGrading:      data.DoubleGrading,
+		ReconcileThreshold: data.ReconcileThreshold,
 	}
 
 	// create Task entry in database
@@ -206,6 +259,11 @@ func (rs *TaskResource) EditHandler(w http.ResponseWriter, r *http.Request) {
 	task.MaxPoints = data.MaxPoints
 	task.PublicDockerImage = null.StringFrom(data.PublicDockerImage)
 	task.PrivateDockerImage = null.StringFrom(data.PrivateDockerImage)
+	task.GraderID = data.GraderID
+	task.IsBonus = data.IsBonus
+	task.BonusPoints = data.BonusPoints
+	task.DoubleGrading = data.DoubleGrading
+	task.ReconcileThreshold = data.ReconcileThreshold
 
 	// update database entry
 	if err := rs.Stores.Task.Update(task); err != nil {
@@ -380,8 +438,21 @@ func (rs *TaskResource) GetSubmissionResultHandler(w http.ResponseWriter, r *htt
 	grade.PrivateTestStatus = -1
 	grade.PrivateTestLog = ""
 
+	// tutor feedback and points stay hidden until the grades are released
+	sheet := r.Context().Value(symbol.CtxKeySheet).(*model.Sheet)
+	released := GradesReleasedYet(sheet)
+	if !released {
+		grade.Feedback = ""
+		grade.AcquiredPoints = 0
+	}
+
+	resp := newGradeResponse(grade, course.ID)
+	if !released {
+		resp.FeedbackFileURL = ""
+	}
+
 	// render JSON response
-	if err := render.Render(w, r, newGradeResponse(grade, course.ID)); err != nil {
+	if err := render.Render(w, r, resp); err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
@@ -24,14 +24,20 @@ import (
 	"net/http"
 
 	validation "github.com/go-ozzo/ozzo-validation"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // TaskRequest is the request payload for Task management.
 type TaskRequest struct {
-	MaxPoints          int    `json:"max_points" example:"25"`
-	Name               string `json:"name" example:"Task 1"`
-	PublicDockerImage  string `json:"public_docker_image" example:"DefaultJavaTestingImage"`
-	PrivateDockerImage string `json:"private_docker_image" example:"DefaultJavaTestingImage"`
+	MaxPoints          int      `json:"max_points" example:"25"`
+	Name               string   `json:"name" example:"Task 1"`
+	PublicDockerImage  string   `json:"public_docker_image" example:"DefaultJavaTestingImage"`
+	PrivateDockerImage string   `json:"private_docker_image" example:"DefaultJavaTestingImage"`
+	GraderID           null.Int `json:"grader_id" example:"2"`
+	IsBonus            bool     `json:"is_bonus" example:"false"`
+	BonusPoints        int      `json:"bonus_points" example:"0"`
+	DoubleGrading      bool     `json:"double_grading" example:"false"`
+	ReconcileThreshold int      `json:"reconcile_threshold" example:"1"`
 }
 
 // Bind preprocesses a TaskRequest.
@@ -53,5 +59,13 @@ func (body *TaskRequest) Validate() error {
 			&body.Name,
 			validation.Required,
 		),
+		validation.Field(
+			&body.BonusPoints,
+			validation.Min(0),
+		),
+		validation.Field(
+			&body.ReconcileThreshold,
+			validation.Min(0),
+		),
 	)
 }
@@ -37,6 +37,11 @@ type TaskResponse struct {
 	MaxPoints          int         `json:"max_points" example:"23"`
 	PublicDockerImage  null.String `json:"public_docker_image" example:"DefaultJavaTestingImage"`
 	PrivateDockerImage null.String `json:"private_docker_image" example:"DefaultJavaTestingImage"`
+	GraderID           null.Int    `json:"grader_id" example:"2"`
+	IsBonus            bool        `json:"is_bonus" example:"false"`
+	BonusPoints        int         `json:"bonus_points" example:"0"`
+	DoubleGrading      bool        `json:"double_grading" example:"false"`
+	ReconcileThreshold int         `json:"reconcile_threshold" example:"1"`
 }
 
 // newTaskResponse creates a response from a Task model.
@@ -47,6 +52,11 @@ func newTaskResponse(p *model.Task) *TaskResponse {
 		MaxPoints:          p.MaxPoints,
 		PublicDockerImage:  p.PublicDockerImage,
 		PrivateDockerImage: p.PrivateDockerImage,
+		GraderID:           p.GraderID,
+		IsBonus:            p.IsBonus,
+		BonusPoints:        p.BonusPoints,
+		DoubleGrading:      p.DoubleGrading,
+		ReconcileThreshold: p.ReconcileThreshold,
 	}
 }
 
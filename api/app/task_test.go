@@ -301,6 +301,40 @@ func TestTask(t *testing.T) {
 
 		})
 
+		g.It("Should rearrange tasks", func() {
+			tasksBefore, err := stores.Task.TasksOfSheet(1)
+			g.Assert(err).Equal(nil)
+
+			ids := []int64{}
+			for k := len(tasksBefore) - 1; k >= 0; k-- {
+				ids = append(ids, tasksBefore[k].ID)
+			}
+
+			w := tape.Put("/api/v1/courses/1/sheets/1/tasks/order", H{"ids": ids}, studentJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			w = tape.Put("/api/v1/courses/1/sheets/1/tasks/order", H{"ids": ids}, tutorJWT)
+			g.Assert(w.Code).Equal(http.StatusForbidden)
+
+			// tasks of another sheet cannot be used
+			w = tape.Put("/api/v1/courses/1/sheets/1/tasks/order", H{"ids": append(ids[1:], 4)}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusBadRequest)
+
+			w = tape.Put("/api/v1/courses/1/sheets/1/tasks/order", H{"ids": ids}, adminJWT)
+			g.Assert(w.Code).Equal(http.StatusNoContent)
+
+			w = tape.Get("/api/v1/courses/1/sheets/1/tasks", adminJWT)
+			g.Assert(w.Code).Equal(http.StatusOK)
+
+			tasksActual := []TaskResponse{}
+			err = json.NewDecoder(w.Body).Decode(&tasksActual)
+			g.Assert(err).Equal(nil)
+			g.Assert(len(tasksActual)).Equal(len(ids))
+			for k := range tasksActual {
+				g.Assert(tasksActual[k].ID).Equal(ids[k])
+			}
+		})
+
 		g.It("Permission test", func() {
 			// sheet (id=1) belongs to group(id=1)
 			url := "/api/v1/courses/1/sheets/1/tasks"
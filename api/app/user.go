@@ -223,6 +223,10 @@ func (rs *UserResource) EditMeHandler(w http.ResponseWriter, r *http.Request) {
 	user.Semester = data.Semester
 	user.Subject = data.Subject
 	user.Language = data.Language
+	// keep the preference if it is not given
+	if data.AnnouncementEmailsMuted.Valid {
+		user.AnnouncementEmailsMuted = data.AnnouncementEmailsMuted.Bool
+	}
 
 	// update database entry
 	if err := rs.Stores.User.Update(user); err != nil {
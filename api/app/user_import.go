@@ -0,0 +1,325 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-validation/is"
+	"github.com/infomark-org/infomark/auth"
+	"github.com/infomark-org/infomark/auth/authorize"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Actions of a single line when importing users into a course.
+const (
+	UserImportCreate = "create"
+	UserImportEnroll = "enroll"
+	UserImportKeep   = "keep"
+)
+
+// UserImportRow is a single line of a csv file to bulk-import users into a course.
+type UserImportRow struct {
+	Line          int
+	FirstName     string
+	LastName      string
+	Email         string
+	StudentNumber string
+	Subject       string
+	Semester      int
+	Role          authorize.CourseRole
+	Group         string
+}
+
+// UserImportEntry describes what happens when importing a row. Rows having a
+// conflict are reported but never imported.
+type UserImportEntry struct {
+	UserImportRow
+
+	Action   string
+	UserID   int64
+	GroupID  int64
+	Conflict string
+}
+
+// userImportRoleNames are the names of the course roles used in the csv file.
+var userImportRoleNames = map[authorize.CourseRole]string{
+	authorize.STUDENT: "student",
+	authorize.TUTOR:   "tutor",
+	authorize.ADMIN:   "admin",
+}
+
+// parseUserImportRole accepts the role either by name or by its number.
+func parseUserImportRole(value string) (authorize.CourseRole, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "student", "0":
+		return authorize.STUDENT, nil
+	case "tutor", "1":
+		return authorize.TUTOR, nil
+	case "admin", "2":
+		return authorize.ADMIN, nil
+	}
+	return authorize.NOCOURSEROLE, fmt.Errorf("unknown role %q", value)
+}
+
+// ParseUserImport reads a csv file with the columns "email" and either "name" or
+// "first_name" and "last_name". The columns "student_number", "subject",
+// "semester", "role" (student, tutor or admin) and "group" (id or description)
+// are optional. The first line is the header.
+func ParseUserImport(r io.Reader) ([]UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header: %v", err)
+	}
+
+	columns := make(map[string]int)
+	for k, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = k
+	}
+
+	if _, ok := columns["email"]; !ok {
+		return nil, fmt.Errorf("column email is missing")
+	}
+	_, hasName := columns["name"]
+	_, hasFirstName := columns["first_name"]
+	_, hasLastName := columns["last_name"]
+	if !hasName && !(hasFirstName && hasLastName) {
+		return nil, fmt.Errorf("column name or the columns first_name and last_name are missing")
+	}
+
+	value := func(record []string, name string) string {
+		if k, ok := columns[name]; ok && k < len(record) {
+			return strings.TrimSpace(record[k])
+		}
+		return ""
+	}
+
+	rows := []UserImportRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		row := UserImportRow{
+			Line:          line,
+			FirstName:     value(record, "first_name"),
+			LastName:      value(record, "last_name"),
+			Email:         strings.ToLower(value(record, "email")),
+			StudentNumber: value(record, "student_number"),
+			Subject:       value(record, "subject"),
+			Group:         value(record, "group"),
+		}
+
+		// the registrar exports the full name as "first names last name"
+		if row.FirstName == "" && row.LastName == "" {
+			name := strings.Fields(value(record, "name"))
+			if len(name) > 0 {
+				row.FirstName = strings.Join(name[:len(name)-1], " ")
+				row.LastName = name[len(name)-1]
+			}
+		}
+
+		if semester := value(record, "semester"); semester != "" {
+			if row.Semester, err = strconv.Atoi(semester); err != nil {
+				return nil, fmt.Errorf("line %d: semester is not a number", line)
+			}
+		}
+
+		if row.Role, err = parseUserImportRole(value(record, "role")); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// PlanUserImport matches all rows against the existing accounts, enrollments
+// and groups of a course without touching the database. Existing accounts are
+// never changed. The second return value is false if any row has a conflict,
+// in that case nothing should be imported.
+func PlanUserImport(stores *Stores, courseID int64, rows []UserImportRow) ([]UserImportEntry, bool, error) {
+	groups, err := stores.Group.GroupsOfCourse(courseID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	entries := []UserImportEntry{}
+	valid := true
+	seenEmails := make(map[string]int)
+	seenStudentNumbers := make(map[string]int)
+
+	for _, row := range rows {
+		entry := UserImportEntry{UserImportRow: row, Action: UserImportCreate}
+		conflicts := []string{}
+
+		if previousLine, ok := seenEmails[row.Email]; ok {
+			conflicts = append(conflicts, fmt.Sprintf("email duplicates line %d", previousLine))
+		} else {
+			seenEmails[row.Email] = row.Line
+		}
+
+		if row.StudentNumber != "" {
+			if previousLine, ok := seenStudentNumbers[row.StudentNumber]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("student number duplicates line %d", previousLine))
+			} else {
+				seenStudentNumbers[row.StudentNumber] = row.Line
+			}
+		}
+
+		if row.Email == "" || is.Email.Validate(row.Email) != nil {
+			conflicts = append(conflicts, "email is invalid")
+		} else if user, err := stores.User.FindByEmail(row.Email); err == nil {
+			entry.UserID = user.ID
+			entry.Action = UserImportEnroll
+
+			if row.StudentNumber != "" && user.StudentNumber != "" && row.StudentNumber != user.StudentNumber {
+				conflicts = append(conflicts, fmt.Sprintf(
+					"student number differs from the existing account (%s)", user.StudentNumber))
+			}
+
+			role, err := stores.Course.RoleInCourse(user.ID, courseID)
+			if err != nil {
+				return nil, false, err
+			}
+			if role == row.Role {
+				entry.Action = UserImportKeep
+			} else if role != authorize.NOCOURSEROLE {
+				conflicts = append(conflicts, fmt.Sprintf("already enrolled as %s", userImportRoleNames[role]))
+			}
+		} else if row.FirstName == "" || row.LastName == "" {
+			conflicts = append(conflicts, "name is required for a new account")
+		}
+
+		if row.Group != "" {
+			for _, group := range groups {
+				if strconv.FormatInt(group.ID, 10) == row.Group || strings.EqualFold(group.Description, row.Group) {
+					entry.GroupID = group.ID
+					break
+				}
+			}
+			if entry.GroupID == 0 {
+				conflicts = append(conflicts, fmt.Sprintf("group %q does not exist in the course", row.Group))
+			} else if row.Role != authorize.STUDENT {
+				conflicts = append(conflicts, "only students can be enrolled into a group")
+			}
+		}
+
+		if len(conflicts) > 0 {
+			entry.Conflict = strings.Join(conflicts, "; ")
+			valid = false
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, valid, nil
+}
+
+// ApplyUserImport creates the missing accounts and enrolls all users into the
+// course and their group within a single transaction, hence nothing is
+// imported if any line fails. New accounts do not have a password yet but a
+// reset token, the returned users should be invited to set a password once the
+// import succeeded.
+func ApplyUserImport(stores *Stores, courseID int64, entries []UserImportEntry) ([]model.User, error) {
+	created := []model.User{}
+
+	err := stores.Transaction(func(tx *Stores) error {
+		for k, entry := range entries {
+			if entry.Action == UserImportCreate {
+				user, err := tx.User.Create(&model.User{
+					FirstName:          entry.FirstName,
+					LastName:           entry.LastName,
+					Email:              entry.Email,
+					StudentNumber:      entry.StudentNumber,
+					Semester:           entry.Semester,
+					Subject:            entry.Subject,
+					Language:           "en",
+					ResetPasswordToken: null.StringFrom(auth.GenerateToken(32)),
+				})
+				if err != nil {
+					return fmt.Errorf("line %d: %v", entry.Line, err)
+				}
+				entries[k].UserID = user.ID
+				created = append(created, *user)
+			}
+
+			if entry.Action != UserImportKeep {
+				if err := tx.Course.Enroll(courseID, entries[k].UserID, int64(entry.Role)); err != nil {
+					return fmt.Errorf("line %d: %v", entry.Line, err)
+				}
+			}
+
+			if entry.GroupID != 0 {
+				enrollment, err := tx.Group.GetGroupEnrollmentOfUserInCourse(entries[k].UserID, courseID)
+				if err == nil {
+					enrollment.GroupID = entry.GroupID
+					err = tx.Group.ChangeGroupEnrollmentOfUserInCourse(enrollment)
+				} else {
+					_, err = tx.Group.CreateGroupEnrollmentOfUserInCourse(&model.GroupEnrollment{
+						UserID:  entries[k].UserID,
+						GroupID: entry.GroupID,
+					})
+				}
+				if err != nil {
+					return fmt.Errorf("line %d: %v", entry.Line, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// NewUserInviteEmail creates the email inviting an imported user to set a
+// password for the new account.
+func NewUserInviteEmail(course *model.Course, user *model.User) (*email.Email, error) {
+	return email.NewEmailFromTemplate(
+		configuration.Configuration.Server.Email.From,
+		user.Email,
+		fmt.Sprintf("Your account for %s", course.Name),
+		email.UserInviteTemplateEN,
+		map[string]string{
+			"first_name":           user.FirstName,
+			"last_name":            user.LastName,
+			"course_name":          course.Name,
+			"email_address":        user.Email,
+			"reset_password_url":   fmt.Sprintf("%s/#/password_reset", configuration.Configuration.Server.ExternalURL()),
+			"reset_password_token": user.ResetPasswordToken.String,
+		})
+}
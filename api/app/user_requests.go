@@ -26,6 +26,7 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // UserRequest is the request payload for user management.
@@ -106,6 +107,8 @@ type UserMeRequest struct {
 	Subject       string `json:"subject" example:"bio informatics"`
 	Language      string `json:"language" example:"en" len:"2"`
 	// PlainPassword string `json:"plain_password" example:"new_password"`
+
+	AnnouncementEmailsMuted null.Bool `json:"announcement_emails_muted" example:"false" required:"false"`
 }
 
 // Bind preprocesses a UserMeRequest.
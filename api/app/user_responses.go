@@ -41,6 +41,8 @@ type UserResponse struct {
 	Subject       string      `json:"subject" example:"bio informatics"`
 	Language      string      `json:"language" example:"en" len:"2"`
 	Root          bool        `json:"root" example:"false"`
+
+	AnnouncementEmailsMuted bool `json:"announcement_emails_muted" example:"false"`
 }
 
 // newUserResponse creates a response from a user model.
@@ -55,6 +57,8 @@ func newUserResponse(p *model.User) *UserResponse {
 		Semester:      p.Semester,
 		Subject:       p.Subject,
 		Language:      p.Language,
+
+		AnnouncementEmailsMuted: p.AnnouncementEmailsMuted,
 	}
 }
 
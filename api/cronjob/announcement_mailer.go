@@ -0,0 +1,45 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package cronjob
+
+import (
+	"fmt"
+
+	"github.com/infomark-org/infomark/api/app"
+)
+
+// AnnouncementMailer sends the emails of announcements once they are published.
+type AnnouncementMailer struct {
+	Stores *app.Stores
+}
+
+// Run sends the emails of all published announcements which have not been sent yet
+func (job *AnnouncementMailer) Run() {
+	announcements, err := job.Stores.Announcement.PendingEmails()
+	if err != nil {
+		fmt.Println(" Fetching pending announcements failed:", err)
+		return
+	}
+
+	for k := range announcements {
+		if err := app.SendAnnouncementEmails(job.Stores, &announcements[k]); err != nil {
+			fmt.Println(" Sending announcement", announcements[k].ID, "failed:", err)
+		}
+	}
+}
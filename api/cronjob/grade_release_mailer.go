@@ -0,0 +1,48 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cronjob
+
+import (
+	"fmt"
+
+	"github.com/infomark-org/infomark/api/app"
+)
+
+// GradeReleaseMailer notifies students once the grades of a sheet are
+// released by the schedule of the sheet.
+type GradeReleaseMailer struct {
+	Stores *app.Stores
+}
+
+// Run sends the emails of all scheduled grade releases which have not been
+// announced yet
+func (job *GradeReleaseMailer) Run() {
+	sheets, err := job.Stores.Sheet.PendingGradeReleaseEmails()
+	if err != nil {
+		fmt.Println(" Fetching pending grade releases failed:", err)
+		return
+	}
+
+	for k := range sheets {
+		if err := app.SendGradeReleaseEmails(job.Stores, &sheets[k]); err != nil {
+			fmt.Println(" Sending grade release of sheet", sheets[k].ID, "failed:", err)
+		}
+	}
+}
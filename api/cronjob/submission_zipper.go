@@ -49,6 +49,7 @@ type SubmissionFileZipper struct {
 // submissions should be included in the final zip file
 type StudentSubmission struct {
 	ID               int64  `db:"id"`
+	UserID           int64  `db:"user_id"`
 	StudentFirstName string `db:"first_name"`
 	StudentLastName  string `db:"last_name"`
 }
@@ -57,7 +58,7 @@ type StudentSubmission struct {
 func FetchStudentSubmissions(db *sqlx.DB, groupID int64, taskID int64) ([]StudentSubmission, error) {
 	p := []StudentSubmission{}
 	err := db.Select(&p, `
-SELECT s.id, u.first_name, u.last_name FROM submissions s
+SELECT s.id, s.user_id, u.first_name, u.last_name FROM submissions s
   INNER JOIN user_group ug ON ug.user_id = s.user_id
   INNER JOIN users u ON u.id  = s.user_id
   WHERE  ug.group_id = $1
@@ -170,6 +171,10 @@ func (job *SubmissionFileZipper) Run() {
 									// Using FileInfoHeader() above only uses the basename of the file. If we want
 									// to preserve the folder structure we can overwrite this with the full path.
 									header.Name = fmt.Sprintf("%s-%s.zip", submission.StudentLastName, submission.StudentFirstName)
+									if sheet.BlindGrading {
+										// the archive is built once, admins can resolve the pseudonyms
+										header.Name = fmt.Sprintf("%s.zip", app.Pseudonym(courseID, submission.UserID))
+									}
 
 									// Change to deflate to gain better compression
 									// see http://golang.org/pkg/archive/zip/#pkg-constants
@@ -196,15 +201,17 @@ func (job *SubmissionFileZipper) Run() {
 
 							}
 
-							// notify the tutor
-							tutor, _ := job.Stores.User.Get(group.TutorID)
+							// notify all tutors of the group
+							tutors, _ := app.GroupTutors(job.Stores, &group.Group)
 
 							from := configuration.Configuration.Server.Email.From
 
-							email.DefaultMail.Send(email.NewEmail(from, tutor.Email, "Submission-Zip", fmt.Sprintf(`Hi %s,
+							for _, tutor := range tutors {
+								email.DefaultMail.Send(email.NewEmail(from, tutor.Email, "Submission-Zip", fmt.Sprintf(`Hi %s,
 the deadline for the exercise sheet '%s' is over. We have collected all submissions in a single zip file.
 Please log in to grade these solutions.
 `, tutor.FullName(), sheet.Name)))
+							}
 						}
 					}
 
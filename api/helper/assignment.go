@@ -0,0 +1,239 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package helper
+
+import (
+	"errors"
+	"math"
+)
+
+// The assignment of students to groups is a min-cost flow problem:
+//
+//   source -> student (capacity 1, cost 0)
+//   student -> group (capacity 1, cost maxBid - bid)
+//   group -> sink (capacity min, cost -penalty) and (capacity max - min, cost 0)
+//
+// The large negative cost on the first group edge makes every optimal flow
+// fill all groups up to their minimum before it considers preferences. If the
+// minimum edges are not saturated in the end, the capacities are infeasible.
+
+type flowEdge struct {
+	to       int
+	capacity int
+	cost     int
+	reverse  int
+}
+
+type flowNetwork struct {
+	edges [][]flowEdge
+}
+
+func newFlowNetwork(nodes int) *flowNetwork {
+	return &flowNetwork{edges: make([][]flowEdge, nodes)}
+}
+
+// addEdge adds a directed edge and returns its position in the adjacency list
+// of "from".
+func (n *flowNetwork) addEdge(from int, to int, capacity int, cost int) int {
+	n.edges[from] = append(n.edges[from], flowEdge{to: to, capacity: capacity, cost: cost, reverse: len(n.edges[to])})
+	n.edges[to] = append(n.edges[to], flowEdge{to: from, capacity: 0, cost: -cost, reverse: len(n.edges[from]) - 1})
+	return len(n.edges[from]) - 1
+}
+
+// minCostFlow sends up to "amount" units from source to sink along shortest
+// paths. Potentials keep the reduced costs non-negative, so Dijkstra can be
+// used after the first Bellman-Ford pass. It returns the sent flow.
+func (n *flowNetwork) minCostFlow(source int, sink int, amount int) int {
+	nodes := len(n.edges)
+	potential := make([]int, nodes)
+
+	// initial potentials, the network has no cycles yet
+	for k := range potential {
+		potential[k] = math.MaxInt32
+	}
+	potential[source] = 0
+	for changed := true; changed; {
+		changed = false
+		for v := 0; v < nodes; v++ {
+			if potential[v] == math.MaxInt32 {
+				continue
+			}
+			for _, e := range n.edges[v] {
+				if e.capacity > 0 && potential[v]+e.cost < potential[e.to] {
+					potential[e.to] = potential[v] + e.cost
+					changed = true
+				}
+			}
+		}
+	}
+	for k := range potential {
+		if potential[k] == math.MaxInt32 {
+			potential[k] = 0
+		}
+	}
+
+	dist := make([]int, nodes)
+	done := make([]bool, nodes)
+	prevNode := make([]int, nodes)
+	prevEdge := make([]int, nodes)
+
+	flow := 0
+	for flow < amount {
+		for k := range dist {
+			dist[k] = math.MaxInt32
+			done[k] = false
+		}
+		dist[source] = 0
+
+		// dense Dijkstra, the networks are small
+		for {
+			v := -1
+			for k := 0; k < nodes; k++ {
+				if !done[k] && dist[k] != math.MaxInt32 && (v == -1 || dist[k] < dist[v]) {
+					v = k
+				}
+			}
+			if v == -1 {
+				break
+			}
+			done[v] = true
+
+			for i, e := range n.edges[v] {
+				if e.capacity <= 0 {
+					continue
+				}
+				d := dist[v] + e.cost + potential[v] - potential[e.to]
+				if d < dist[e.to] {
+					dist[e.to] = d
+					prevNode[e.to] = v
+					prevEdge[e.to] = i
+				}
+			}
+		}
+
+		if dist[sink] == math.MaxInt32 {
+			break
+		}
+
+		for k := range potential {
+			if dist[k] != math.MaxInt32 {
+				potential[k] += dist[k]
+			}
+		}
+
+		// every path has a capacity of one due to the student edges
+		push := amount - flow
+		for v := sink; v != source; v = prevNode[v] {
+			if c := n.edges[prevNode[v]][prevEdge[v]].capacity; c < push {
+				push = c
+			}
+		}
+		for v := sink; v != source; v = prevNode[v] {
+			e := &n.edges[prevNode[v]][prevEdge[v]]
+			e.capacity -= push
+			n.edges[v][e.reverse].capacity += push
+		}
+		flow += push
+	}
+
+	return flow
+}
+
+// AssignToGroups assigns every student to exactly one group such that the sum
+// of the bids of the assigned groups is maximal and every group j gets between
+// minSize[j] and maxSize[j] students. The bid of student i for group j is given
+// by bids[i][j], higher is better. It returns the group index of each student.
+func AssignToGroups(bids [][]int, minSize []int, maxSize []int) ([]int, error) {
+	numStudents := len(bids)
+	numGroups := len(minSize)
+
+	if len(maxSize) != numGroups {
+		return nil, errors.New("minimum and maximum sizes of the groups do not match")
+	}
+
+	totalMin, totalMax := 0, 0
+	for j := 0; j < numGroups; j++ {
+		if minSize[j] < 0 || minSize[j] > maxSize[j] {
+			return nil, errors.New("minimum size of a group is larger than its maximum size")
+		}
+		totalMin += minSize[j]
+		totalMax += maxSize[j]
+	}
+	if numStudents < totalMin || numStudents > totalMax {
+		return nil, errors.New("the students do not fit into the groups with the given sizes")
+	}
+
+	maxBid := 0
+	for i := range bids {
+		if len(bids[i]) != numGroups {
+			return nil, errors.New("every student needs a bid for every group")
+		}
+		for _, bid := range bids[i] {
+			if bid > maxBid {
+				maxBid = bid
+			}
+		}
+	}
+
+	// any change of the preferences costs less than a single missing student
+	// in a group which is below its minimum size
+	penalty := (maxBid+1)*(numStudents+1) + 1
+
+	source := numStudents + numGroups
+	sink := source + 1
+	network := newFlowNetwork(sink + 1)
+
+	studentEdges := make([][]int, numStudents)
+	for i := 0; i < numStudents; i++ {
+		network.addEdge(source, i, 1, 0)
+		studentEdges[i] = make([]int, numGroups)
+		for j := 0; j < numGroups; j++ {
+			studentEdges[i][j] = network.addEdge(i, numStudents+j, 1, maxBid-bids[i][j])
+		}
+	}
+
+	minEdges := make([]int, numGroups)
+	for j := 0; j < numGroups; j++ {
+		minEdges[j] = network.addEdge(numStudents+j, sink, minSize[j], -penalty)
+		network.addEdge(numStudents+j, sink, maxSize[j]-minSize[j], 0)
+	}
+
+	if network.minCostFlow(source, sink, numStudents) != numStudents {
+		return nil, errors.New("the students do not fit into the groups with the given sizes")
+	}
+
+	for j := 0; j < numGroups; j++ {
+		if network.edges[numStudents+j][minEdges[j]].capacity != 0 {
+			return nil, errors.New("not every group can reach its minimum size")
+		}
+	}
+
+	assignment := make([]int, numStudents)
+	for i := 0; i < numStudents; i++ {
+		for j := 0; j < numGroups; j++ {
+			if network.edges[i][studentEdges[i][j]].capacity == 0 {
+				assignment[i] = j
+				break
+			}
+		}
+	}
+
+	return assignment, nil
+}
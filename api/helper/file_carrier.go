@@ -50,6 +50,7 @@ const (
 	MaterialCategory              FileCategory = 4
 	SubmissionCategory            FileCategory = 5
 	SubmissionsCollectionCategory FileCategory = 6
+	GradeFeedbackCategory         FileCategory = 7
 )
 
 // FileManager contains all operations we need to handle files
@@ -146,6 +147,17 @@ func NewSubmissionsCollectionFileHandle(courseID int64, sheetID int64,
 	}
 }
 
+// NewGradeFeedbackFileHandle will handle files tutors attach to the feedback
+// of a grade (pdf or zip files).
+func NewGradeFeedbackFileHandle(ID int64) *FileHandle {
+	return &FileHandle{
+		Category:   GradeFeedbackCategory,
+		ID:         ID,
+		Extensions: []string{"zip", "pdf"},
+		MaxBytes:   configuration.Configuration.Server.HTTP.Limits.MaxSubmission,
+	}
+}
+
 // Sha256 computes the checksum and return it as a string
 func (f *FileHandle) Sha256() (string, error) {
 
@@ -201,6 +213,16 @@ func (f *FileHandle) Path() string {
 	case SubmissionsCollectionCategory:
 		return fmt.Sprintf("%s/collection-course%d-sheet%d-task%d-group%d.zip",
 			configuration.Configuration.Server.Paths.GeneratedFiles, f.Infos[0], f.Infos[1], f.Infos[2], f.Infos[3])
+
+	case GradeFeedbackCategory:
+
+		for _, ext := range f.Extensions {
+			path := fmt.Sprintf("%s/feedback/%d.%s", configuration.Configuration.Server.Paths.Uploads, f.ID, ext)
+			if FileExists(path) {
+				return path
+			}
+		}
+		return ""
 	}
 	return ""
 }
@@ -241,6 +263,57 @@ func (f *FileHandle) Delete() error {
 	return os.Remove(f.Path())
 }
 
+// targetPath returns the location of a file with the given extension. Most
+// categories have a fixed extension and ignore the argument.
+func (f *FileHandle) targetPath(ext string) string {
+	switch f.Category {
+	case AvatarCategory:
+		return fmt.Sprintf("%s/avatars/%d.%s", configuration.Configuration.Server.Paths.Uploads, f.ID, ext)
+	case MaterialCategory:
+		return fmt.Sprintf("%s/materials/%d.%s", configuration.Configuration.Server.Paths.Uploads, f.ID, ext)
+	case GradeFeedbackCategory:
+		return fmt.Sprintf("%s/feedback/%d.%s", configuration.Configuration.Server.Paths.Uploads, f.ID, ext)
+	}
+	return f.Path()
+}
+
+// WriteFromReader stores the content of a reader as the file of this handle.
+// The extension (e.g. "pdf") is only used for categories supporting several
+// file types.
+func (f *FileHandle) WriteFromReader(src io.Reader, ext string) error {
+	out, err := os.OpenFile(f.targetPath(ext), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// CopyTo copies the file to the location of another handle of the same
+// category, e.g. to duplicate the test framework of a task.
+func (f *FileHandle) CopyTo(dst *FileHandle) error {
+	if f.Category != dst.Category {
+		return errors.New("cannot copy files between different categories")
+	}
+
+	src := f.Path()
+	if src == "" || !FileExists(src) {
+		return fmt.Errorf("file %s does not exist", src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	return dst.WriteFromReader(in, strings.TrimPrefix(pathpkg.Ext(src), "."))
+}
+
 // GetContentType tries to predict the content type without reading the entire
 // file. There are some issues with this function as it cannot distinguish
 // between zip and octstream.
@@ -441,6 +514,20 @@ func (f *FileHandle) WriteToDisk(r *http.Request, fieldName string) (string, err
 		} else {
 			return "", errors.New("Only PDF and ZIP files are allowed")
 		}
+
+	case GradeFeedbackCategory:
+		pathToDelete := fmt.Sprintf("%s/feedback/%s.zip", configuration.Configuration.Server.Paths.Uploads, strconv.FormatInt(f.ID, 10))
+		FileDelete(pathToDelete)
+		pathToDelete = fmt.Sprintf("%s/feedback/%s.pdf", configuration.Configuration.Server.Paths.Uploads, strconv.FormatInt(f.ID, 10))
+		FileDelete(pathToDelete)
+
+		if IsPdfFile(fileMagic) {
+			path = fmt.Sprintf("%s/feedback/%s.pdf", configuration.Configuration.Server.Paths.Uploads, strconv.FormatInt(f.ID, 10))
+		} else if IsZipFile(fileMagic) {
+			path = fmt.Sprintf("%s/feedback/%s.zip", configuration.Configuration.Server.Paths.Uploads, strconv.FormatInt(f.ID, 10))
+		} else {
+			return "", errors.New("Only PDF and ZIP files are allowed")
+		}
 	}
 
 	// delete path
@@ -20,7 +20,12 @@
 package helper
 
 import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/franela/goblin"
 )
@@ -39,6 +44,153 @@ func TestCourseCreation(t *testing.T) {
 			g.Assert(len(result)).Equal(2)
 		})
 
+		g.It("xlsxColumnName", func() {
+			g.Assert(xlsxColumnName(0)).Equal("A")
+			g.Assert(xlsxColumnName(25)).Equal("Z")
+			g.Assert(xlsxColumnName(26)).Equal("AA")
+			g.Assert(xlsxColumnName(27)).Equal("AB")
+			g.Assert(xlsxColumnName(701)).Equal("ZZ")
+			g.Assert(xlsxColumnName(702)).Equal("AAA")
+		})
+
+		g.It("WriteXLSX", func() {
+			buf := &bytes.Buffer{}
+			err := WriteXLSX(buf, "grades", [][]string{
+				{"name", "points"},
+				{"Max & Moritz", "12"},
+			})
+			g.Assert(err).Equal(nil)
+
+			archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			g.Assert(err).Equal(nil)
+			g.Assert(len(archive.File)).Equal(5)
+
+			sheet := ""
+			for _, f := range archive.File {
+				if f.Name == "xl/worksheets/sheet1.xml" {
+					rc, err := f.Open()
+					g.Assert(err).Equal(nil)
+					content, err := ioutil.ReadAll(rc)
+					g.Assert(err).Equal(nil)
+					rc.Close()
+					sheet = string(content)
+				}
+			}
+
+			g.Assert(strings.Contains(sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">Max &amp; Moritz</t></is></c>`)).IsTrue()
+			g.Assert(strings.Contains(sheet, `<c r="B2"><v>12</v></c>`)).IsTrue()
+		})
+
+		g.It("WriteCalendar", func() {
+			start := time.Date(2019, 4, 2, 10, 15, 0, 0, time.UTC)
+			buf := &bytes.Buffer{}
+			err := WriteCalendar(buf, "infomark", []CalendarEvent{
+				{
+					UID:      "session-1-20190402@infomark",
+					Summary:  "Info2, group 1",
+					Location: "A104",
+					Start:    start,
+					End:      start.Add(105 * time.Minute),
+					Floating: true,
+				},
+				{
+					UID:     "sheet-1@infomark",
+					Summary: strings.Repeat("x", 100),
+					Start:   start,
+				},
+			})
+			g.Assert(err).Equal(nil)
+
+			content := buf.String()
+			g.Assert(strings.HasPrefix(content, "BEGIN:VCALENDAR\r\n")).IsTrue()
+			g.Assert(strings.HasSuffix(content, "END:VCALENDAR\r\n")).IsTrue()
+			g.Assert(strings.Count(content, "BEGIN:VEVENT")).Equal(2)
+			g.Assert(strings.Contains(content, "SUMMARY:Info2\\, group 1\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "DTSTART:20190402T101500\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "DTEND:20190402T120000\r\n")).IsTrue()
+			g.Assert(strings.Contains(content, "DTSTART:20190402T101500Z\r\n")).IsTrue()
+
+			for _, line := range strings.Split(content, "\r\n") {
+				g.Assert(len(line) <= 75).IsTrue()
+			}
+		})
+
+		g.It("AssignToGroups respects group sizes", func() {
+			// everybody prefers the first group
+			bids := [][]int{{10, 1}, {10, 2}, {10, 3}, {10, 4}}
+			assignment, err := AssignToGroups(bids, []int{2, 2}, []int{2, 2})
+			g.Assert(err).Equal(nil)
+			g.Assert(len(assignment)).Equal(4)
+
+			sizes := []int{0, 0}
+			for _, j := range assignment {
+				sizes[j]++
+			}
+			g.Assert(sizes).Equal([]int{2, 2})
+			// students with the highest bids for the second group get it
+			g.Assert(assignment[2]).Equal(1)
+			g.Assert(assignment[3]).Equal(1)
+
+			// too many students
+			_, err = AssignToGroups(bids, []int{0, 0}, []int{1, 1})
+			g.Assert(err != nil).IsTrue()
+
+			// too few students
+			_, err = AssignToGroups(bids, []int{3, 3}, []int{4, 4})
+			g.Assert(err != nil).IsTrue()
+		})
+
+		g.It("AssignToGroups finds the best assignment", func() {
+			bids := [][]int{
+				{3, 9, 1},
+				{8, 8, 2},
+				{0, 10, 5},
+				{7, 1, 1},
+				{2, 6, 9},
+			}
+			minSize := []int{1, 1, 1}
+			maxSize := []int{2, 2, 2}
+
+			assignment, err := AssignToGroups(bids, minSize, maxSize)
+			g.Assert(err).Equal(nil)
+
+			total := 0
+			for i, j := range assignment {
+				total += bids[i][j]
+			}
+
+			// compare with all possible assignments
+			best := -1
+			current := make([]int, len(bids))
+			var enumerate func(i int)
+			enumerate = func(i int) {
+				if i == len(bids) {
+					sizes := make([]int, len(minSize))
+					sum := 0
+					for k, j := range current {
+						sizes[j]++
+						sum += bids[k][j]
+					}
+					for j := range sizes {
+						if sizes[j] < minSize[j] || sizes[j] > maxSize[j] {
+							return
+						}
+					}
+					if sum > best {
+						best = sum
+					}
+					return
+				}
+				for j := range minSize {
+					current[i] = j
+					enumerate(i + 1)
+				}
+			}
+			enumerate(0)
+
+			g.Assert(total).Equal(best)
+		})
+
 	})
 
 }
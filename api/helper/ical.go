@@ -0,0 +1,129 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package helper
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Calendar feeds only consist of single events. Instead of pulling in a full
+// calendar library, WriteCalendar writes the minimal subset of iCalendar
+// (RFC 5545) calendar applications need to subscribe to such a feed.
+
+// CalendarContentType is the mime type of a calendar written by WriteCalendar.
+const CalendarContentType = "text/calendar; charset=utf-8"
+
+// CalendarEvent is a single entry of a calendar. Floating events are given in
+// the local time of the reader (e.g. "tuesdays 10:15"), all others in UTC.
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Floating    bool
+}
+
+// icalEscape escapes a string to be used as a text value.
+func icalEscape(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// icalTime formats a time as date-time value.
+func icalTime(t time.Time, floating bool) string {
+	if floating {
+		return t.Format("20060102T150405")
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icalFold splits a content line into lines of at most 75 octets.
+func icalFold(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+
+	var folded strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			folded.WriteString("\r\n ")
+			width = 1
+		}
+		folded.WriteRune(r)
+		width += size
+	}
+	return folded.String()
+}
+
+// WriteCalendar writes all events as an iCalendar file.
+func WriteCalendar(w io.Writer, name string, events []CalendarEvent) error {
+	bw := bufio.NewWriter(w)
+	stamp := icalTime(time.Now(), false)
+
+	line := func(text string) {
+		bw.WriteString(icalFold(text))
+		bw.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//infomark//calendar//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:" + icalEscape(name))
+
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.UID)
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + icalTime(event.Start, event.Floating))
+		if !event.End.IsZero() {
+			line("DTEND:" + icalTime(event.End, event.Floating))
+		}
+		line("SUMMARY:" + icalEscape(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION:" + icalEscape(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION:" + icalEscape(event.Location))
+		}
+		if event.URL != "" {
+			line("URL:" + event.URL)
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+
+	return bw.Flush()
+}
@@ -100,6 +100,12 @@ func NewServer(config *configuration.ServerConfigurationSchema) (*Server, error)
 		DB:        db,
 		Directory: config.Paths.GeneratedFiles,
 	})
+	c.AddJob(config.CronjobsSendAnnouncementsIntervall(), &cronjob.AnnouncementMailer{
+		Stores: app.NewStores(db),
+	})
+	c.AddJob(config.CronjobsSendGradeReleasesIntervall(), &cronjob.GradeReleaseMailer{
+		Stores: app.NewStores(db),
+	})
 
 	return &Server{
 		HTTP:           &srv,
@@ -124,7 +130,7 @@ func (srv *Server) Start() {
 	log.Info("starting background email sender...")
 	go email.BackgroundSend(email.OutgoingEmailsChannel)
 
-	log.Info("starting cronjob for zipping submissions...")
+	log.Info("starting cronjobs for zipping submissions and sending announcements and grade releases...")
 	srv.Cron.Start()
 
 	quit := make(chan os.Signal, 1)
@@ -45,6 +45,7 @@ func init() {
 	ConsoleCmd.AddCommand(console.CourseCmd)
 	ConsoleCmd.AddCommand(console.SubmissionCmd)
 	ConsoleCmd.AddCommand(console.GroupCmd)
+	ConsoleCmd.AddCommand(console.GradeCmd)
 	ConsoleCmd.AddCommand(console.DatabaseCmd)
 	ConsoleCmd.AddCommand(console.ConfigurationCmd)
 
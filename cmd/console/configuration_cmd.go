@@ -113,6 +113,8 @@ func GenerateExampleConfiguration(domain string, root_path string) *configuratio
 
 	config.Server.Authentication.TotalRequestsPerMinute = 100
 	config.Server.Cronjobs.ZipSubmissionsIntervall = DurationFromString("5m")
+	config.Server.Cronjobs.SendAnnouncementsIntervall = DurationFromString("1m")
+	config.Server.Cronjobs.SendGradeReleasesIntervall = DurationFromString("1m")
 
 	config.Server.Email.Send = false
 	config.Server.Email.SendmailBinary = "/usr/sbin/sendmail"
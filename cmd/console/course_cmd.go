@@ -22,13 +22,27 @@ package console
 import (
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/infomark-org/infomark/api/app"
 	"github.com/infomark-org/infomark/configuration"
+	"github.com/infomark-org/infomark/email"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	CourseCmd.AddCommand(UserEnrollInCourse)
+	CourseCmd.AddCommand(CourseClone)
+
+	CourseExport.Flags().BoolVar(&exportSubmissions, "submissions", false, "include submissions, grades and group members")
+	CourseExport.Flags().BoolVar(&exportAnonymize, "anonymize", false, "replace students by pseudonyms")
+	CourseCmd.AddCommand(CourseExport)
+	CourseCmd.AddCommand(CourseImport)
+
+	CourseImportUsers.Flags().BoolVarP(&importUsersDryRun, "dry-run", "n", false, "only show the report without importing")
+	CourseCmd.AddCommand(CourseImportUsers)
 }
 
 var CourseCmd = &cobra.Command{
@@ -80,3 +94,179 @@ var UserEnrollInCourse = &cobra.Command{
 			user.FirstName, user.LastName, course.ID, role)
 	},
 }
+
+var CourseClone = &cobra.Command{
+	Use:   "clone [courseID] [name] [offsetDays|beginsAt]",
+	Short: "copy a course into a new semester",
+	Long: `copies sheets, tasks, materials, exams and groups (without members) including
+all files into a new course. All dates are shifted either by a number of days
+or such that the new course begins at the given date (YYYY-MM-DD)`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+		name := args[1]
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		var offset time.Duration
+		if days, err := strconv.Atoi(args[2]); err == nil {
+			offset = time.Duration(days) * 24 * time.Hour
+		} else {
+			beginsAt, err := time.Parse("2006-01-02", args[2])
+			if err != nil {
+				log.Fatalf("'%s' is neither a number of days nor a date (YYYY-MM-DD)\n", args[2])
+			}
+			// keep the time of day of the original course
+			beginsAt = time.Date(beginsAt.Year(), beginsAt.Month(), beginsAt.Day(),
+				course.BeginsAt.Hour(), course.BeginsAt.Minute(), course.BeginsAt.Second(), 0,
+				course.BeginsAt.Location())
+			offset = beginsAt.Sub(course.BeginsAt)
+		}
+
+		newCourse, err := app.CloneCourse(stores, course, name, offset)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("course %v has been copied into course %v (%s), dates are shifted by %v\n",
+			course.ID, newCourse.ID, newCourse.Name, offset)
+	},
+}
+
+var (
+	exportSubmissions bool
+	exportAnonymize   bool
+)
+
+var CourseExport = &cobra.Command{
+	Use:   "export [courseID] [file.zip]",
+	Short: "export a course into a portable archive",
+	Long: `writes a zip file containing a manifest and all sheet, test and material files
+of a course. Submissions and grades are only included with --submissions`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		file, err := os.Create(args[1])
+		failWhenSmallestWhiff(err)
+		defer file.Close()
+
+		err = app.ExportCourseArchive(stores, course, file, exportSubmissions, exportAnonymize)
+		failWhenSmallestWhiff(err)
+
+		fmt.Printf("course %v has been exported to %s\n", course.ID, args[1])
+	},
+}
+
+var CourseImport = &cobra.Command{
+	Use:   "import [file.zip]",
+	Short: "create a new course from a course archive",
+	Long:  `users are matched by email, unknown users are created without a password`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		file, err := os.Open(args[0])
+		failWhenSmallestWhiff(err)
+		defer file.Close()
+
+		info, err := file.Stat()
+		failWhenSmallestWhiff(err)
+
+		course, err := app.ImportCourseArchive(stores, file, info.Size())
+		failWhenSmallestWhiff(err)
+
+		fmt.Printf("archive %s has been imported as course %v (%s)\n", args[0], course.ID, course.Name)
+	},
+}
+
+var importUsersDryRun bool
+
+var CourseImportUsers = &cobra.Command{
+	Use:   "import-users [courseID] [file.csv]",
+	Short: "create and enroll users from a csv file",
+	Long: `reads a csv file with the columns email and name (or first_name and last_name)
+and optionally student_number, subject, semester, role and group. Missing accounts
+are created and invited by email to set a password. Every user is enrolled into the
+course and optionally into a group. Nothing is imported if any line has a conflict.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		f, err := os.Open(args[1])
+		failWhenSmallestWhiff(err)
+		defer f.Close()
+
+		rows, err := app.ParseUserImport(f)
+		if err != nil {
+			log.Fatalf("cannot parse %s: %v\n", args[1], err)
+		}
+
+		entries, valid, err := app.PlanUserImport(stores, course.ID, rows)
+		failWhenSmallestWhiff(err)
+
+		for _, entry := range entries {
+			fmt.Printf("line %d: %s %s <%s> %s", entry.Line, entry.FirstName, entry.LastName, entry.Email, entry.Action)
+			if entry.GroupID != 0 {
+				fmt.Printf(", group %d", entry.GroupID)
+			}
+			if entry.Conflict != "" {
+				fmt.Printf(", conflict: %s", entry.Conflict)
+			}
+			fmt.Println()
+		}
+
+		if !valid {
+			log.Fatalf("cannot import %s because of conflicts\n", args[1])
+		}
+
+		if importUsersDryRun {
+			fmt.Printf("dry-run: %d users would be imported into course %s (%d)\n",
+				len(entries), course.Name, course.ID)
+			return
+		}
+
+		if configuration.Configuration.Server.SendEmail() {
+			email.DefaultMail = email.NewSendMailer(configuration.Configuration.Server.Email.SendmailBinary)
+		}
+
+		created, err := app.ApplyUserImport(stores, course.ID, entries)
+		failWhenSmallestWhiff(err)
+
+		for k := range created {
+			msg, err := app.NewUserInviteEmail(course, &created[k])
+			failWhenSmallestWhiff(err)
+			failWhenSmallestWhiff(email.DefaultMail.Send(msg))
+		}
+
+		fmt.Printf("imported %d users (%d new accounts) into course %s (%d)\n",
+			len(entries), len(created), course.Name, course.ID)
+	},
+}
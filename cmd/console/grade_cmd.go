@@ -0,0 +1,184 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package console
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/infomark-org/infomark/api/app"
+	"github.com/infomark-org/infomark/configuration"
+	"github.com/spf13/cobra"
+	null "gopkg.in/guregu/null.v3"
+)
+
+var gradeImportDryRun bool
+
+func init() {
+	GradeImport.Flags().BoolVarP(&gradeImportDryRun, "dry-run", "n", false, "only show the changes without applying them")
+	GradeCmd.AddCommand(GradeExportHistory)
+	GradeCmd.AddCommand(GradeImport)
+}
+
+var GradeCmd = &cobra.Command{
+	Use:   "grade",
+	Short: "Management of grades",
+}
+
+var GradeExportHistory = &cobra.Command{
+	Use:   "export-history [courseID] [file.csv]",
+	Short: "export the audit log of all grade changes in a course",
+	Long: `writes every recorded change of a grade in the given course as a csv file
+including the actor, time and the old and new values`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		entries, err := stores.Grade.GetHistoryOfCourse(course.ID)
+		failWhenSmallestWhiff(err)
+
+		f, err := os.Create(args[1])
+		failWhenSmallestWhiff(err)
+		defer f.Close()
+
+		writer := csv.NewWriter(f)
+		failWhenSmallestWhiff(writer.Write([]string{
+			"id", "created_at", "grade_id", "sheet_id", "task_id", "user_id",
+			"actor_id", "actor_first_name", "actor_last_name", "source",
+			"old_acquired_points", "new_acquired_points",
+			"old_feedback", "new_feedback",
+			"old_tutor_id", "new_tutor_id",
+			"old_assigned_tutor_id", "new_assigned_tutor_id",
+			"old_public_test_status", "new_public_test_status",
+			"old_private_test_status", "new_private_test_status",
+		}))
+
+		for _, e := range entries {
+			failWhenSmallestWhiff(writer.Write([]string{
+				strconv.FormatInt(e.ID, 10),
+				e.CreatedAt.Format(time.RFC3339),
+				formatNullInt(e.GradeID),
+				strconv.FormatInt(e.SheetID, 10),
+				strconv.FormatInt(e.TaskID, 10),
+				strconv.FormatInt(e.UserID, 10),
+				strconv.FormatInt(e.ActorID, 10),
+				e.ActorFirstName,
+				e.ActorLastName,
+				e.Source,
+				strconv.Itoa(e.OldAcquiredPoints),
+				strconv.Itoa(e.NewAcquiredPoints),
+				e.OldFeedback,
+				e.NewFeedback,
+				strconv.FormatInt(e.OldTutorID, 10),
+				strconv.FormatInt(e.NewTutorID, 10),
+				formatNullInt(e.OldAssignedTutorID),
+				formatNullInt(e.NewAssignedTutorID),
+				strconv.Itoa(e.OldPublicTestStatus),
+				strconv.Itoa(e.NewPublicTestStatus),
+				strconv.Itoa(e.OldPrivateTestStatus),
+				strconv.Itoa(e.NewPrivateTestStatus),
+			}))
+		}
+		writer.Flush()
+		failWhenSmallestWhiff(writer.Error())
+
+		fmt.Printf("exported %d grade changes of course %s (%d) to %s\n",
+			len(entries), course.Name, course.ID, args[1])
+	},
+}
+
+var GradeImport = &cobra.Command{
+	Use:   "import [courseID] [file.csv]",
+	Short: "bulk-update points and feedback of grades from a csv file",
+	Long: `reads a csv file with the columns user_id (or student_number), task_id,
+acquired_points and optionally feedback, or the csv file of the grade export with
+one column per task. Every line is validated against the course and the max points
+of the task before anything is changed. The changes are recorded in the history of
+each grade as done by the system user.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		courseID := MustInt64Parameter(args[0], "courseID")
+
+		configuration.MustFindAndReadConfiguration()
+
+		_, stores := MustConnectAndStores()
+
+		course, err := stores.Course.Get(courseID)
+		if err != nil {
+			log.Fatalf("course with id %v not found\n", courseID)
+		}
+
+		f, err := os.Open(args[1])
+		failWhenSmallestWhiff(err)
+		defer f.Close()
+
+		rows, err := app.ParseGradeImport(f)
+		if err != nil {
+			log.Fatalf("cannot parse %s: %v\n", args[1], err)
+		}
+
+		// the system user is allowed to change every grade
+		changes, err := app.PlanGradeImport(stores, course.ID, rows, 1, true)
+		if err != nil {
+			log.Fatalf("cannot import %s: %v\n", args[1], err)
+		}
+
+		for _, change := range changes {
+			fmt.Printf("grade %d (user %d, task %d): points %d -> %d",
+				change.GradeID, change.UserID, change.TaskID,
+				change.OldAcquiredPoints, change.NewAcquiredPoints)
+			if change.OldFeedback != change.NewFeedback {
+				fmt.Printf(", feedback %q -> %q", change.OldFeedback, change.NewFeedback)
+			}
+			fmt.Println()
+		}
+
+		if gradeImportDryRun {
+			fmt.Printf("dry-run: %d of %d lines would change a grade in course %s (%d)\n",
+				len(changes), len(rows), course.Name, course.ID)
+			return
+		}
+
+		// changes from the console are done by the system user
+		failWhenSmallestWhiff(app.ApplyGradeImport(stores, changes, 1))
+
+		fmt.Printf("updated %d grades of course %s (%d)\n", len(changes), course.Name, course.ID)
+	},
+}
+
+// formatNullInt writes missing values as empty cells.
+func formatNullInt(v null.Int) string {
+	if !v.Valid {
+		return ""
+	}
+	return strconv.FormatInt(v.Int64, 10)
+}
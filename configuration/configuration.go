@@ -122,7 +122,9 @@ type ServerConfigurationSchema struct {
 	DistributeJobs bool                        `yaml:"distribute_jobs"`
 	Authentication AuthenticationConfiguration `yaml:"authentication"`
 	Cronjobs       struct {
-		ZipSubmissionsIntervall time.Duration `yaml:"zip_submissions_intervall"`
+		ZipSubmissionsIntervall    time.Duration `yaml:"zip_submissions_intervall"`
+		SendAnnouncementsIntervall time.Duration `yaml:"send_announcements_intervall"`
+		SendGradeReleasesIntervall time.Duration `yaml:"send_grade_releases_intervall"`
 	} `yaml:"cronjobs"`
 	Email struct {
 		Send           bool   `yaml:"send"`
@@ -184,6 +186,26 @@ func (config *ServerConfigurationSchema) CronjobsZipSubmissionsIntervall() strin
 	return fmt.Sprintf("@every %s", secs)
 }
 
+// CronjobsSendAnnouncementsIntervall falls back to every minute for
+// configurations without this setting.
+func (config *ServerConfigurationSchema) CronjobsSendAnnouncementsIntervall() string {
+	secs := config.Cronjobs.SendAnnouncementsIntervall
+	if secs == 0 {
+		secs = time.Minute
+	}
+	return fmt.Sprintf("@every %s", secs)
+}
+
+// CronjobsSendGradeReleasesIntervall falls back to every minute for
+// configurations without this setting.
+func (config *ServerConfigurationSchema) CronjobsSendGradeReleasesIntervall() string {
+	secs := config.Cronjobs.SendGradeReleasesIntervall
+	if secs == 0 {
+		secs = time.Minute
+	}
+	return fmt.Sprintf("@every %s", secs)
+}
+
 type WorkerConfigurationSchema struct {
 	Version  int `json:"version"`
 	Services struct {
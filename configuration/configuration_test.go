@@ -51,6 +51,14 @@ func TestConfiguration(t *testing.T) {
 			config.Cronjobs.ZipSubmissionsIntervall = 4 * time.Second
 			g.Assert(config.CronjobsZipSubmissionsIntervall()).Equal("@every 4s")
 
+			g.Assert(config.CronjobsSendAnnouncementsIntervall()).Equal("@every 1m0s")
+			config.Cronjobs.SendAnnouncementsIntervall = 30 * time.Second
+			g.Assert(config.CronjobsSendAnnouncementsIntervall()).Equal("@every 30s")
+
+			g.Assert(config.CronjobsSendGradeReleasesIntervall()).Equal("@every 1m0s")
+			config.Cronjobs.SendGradeReleasesIntervall = 2 * time.Minute
+			g.Assert(config.CronjobsSendGradeReleasesIntervall()).Equal("@every 2m0s")
+
 		})
 
 		g.It("Should have correct postgres url", func() {
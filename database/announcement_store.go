@@ -0,0 +1,173 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package database
+
+import (
+	"github.com/infomark-org/infomark/model"
+)
+
+type AnnouncementStore struct {
+	db Queryer
+}
+
+func NewAnnouncementStore(db Queryer) *AnnouncementStore {
+	return &AnnouncementStore{
+		db: db,
+	}
+}
+
+func (s *AnnouncementStore) Get(announcementID int64) (*model.Announcement, error) {
+	p := model.Announcement{ID: announcementID}
+	err := s.db.Get(&p, "SELECT * FROM announcements WHERE id = $1 LIMIT 1;", p.ID)
+	return &p, err
+}
+
+func (s *AnnouncementStore) Create(p *model.Announcement) (*model.Announcement, error) {
+	newID, err := Insert(s.db, "announcements", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(newID)
+}
+
+func (s *AnnouncementStore) Update(p *model.Announcement) error {
+	return Update(s.db, "announcements", p.ID, p)
+}
+
+func (s *AnnouncementStore) Delete(announcementID int64) error {
+	return Delete(s.db, "announcements", announcementID)
+}
+
+// AnnouncementsOfCourse returns all announcements of a course including the
+// unpublished ones, pinned announcements first.
+func (s *AnnouncementStore) AnnouncementsOfCourse(courseID int64) ([]model.Announcement, error) {
+	p := []model.Announcement{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  announcements
+WHERE
+  course_id = $1
+ORDER BY
+  pinned DESC, published_at DESC, id DESC`, courseID)
+	return p, err
+}
+
+// FeedOfUser returns all published announcements of a course addressed to a
+// user with the given course role, pinned announcements first. Announcements
+// for a group are addressed to its members and tutors.
+func (s *AnnouncementStore) FeedOfUser(courseID int64, userID int64, role int64) ([]model.Announcement, error) {
+	p := []model.Announcement{}
+	err := s.db.Select(&p, `
+SELECT
+  a.*
+FROM
+  announcements a
+WHERE
+  a.course_id = $1
+AND
+  a.published_at <= now()
+AND
+  $3 = ANY(a.roles)
+AND
+(
+  a.group_id IS NULL
+OR
+  EXISTS (SELECT 1 FROM user_group ug WHERE ug.group_id = a.group_id AND ug.user_id = $2)
+OR
+  EXISTS (SELECT 1 FROM groups g WHERE g.id = a.group_id AND g.tutor_id = $2)
+OR
+  EXISTS (SELECT 1 FROM group_tutors gt WHERE gt.group_id = a.group_id AND gt.user_id = $2)
+)
+ORDER BY
+  a.pinned DESC, a.published_at DESC, a.id DESC`, courseID, userID, role)
+	return p, err
+}
+
+// PendingEmails returns all published announcements whose emails have not
+// been sent yet.
+func (s *AnnouncementStore) PendingEmails() ([]model.Announcement, error) {
+	p := []model.Announcement{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  announcements
+WHERE
+  send_email = true
+AND
+  emails_sent_at IS NULL
+AND
+  published_at <= now()
+ORDER BY
+  published_at ASC`)
+	return p, err
+}
+
+// ClaimEmails marks the emails of an announcement as sent. It returns false
+// if this has been done before, so every announcement is only sent once.
+func (s *AnnouncementStore) ClaimEmails(announcementID int64) (bool, error) {
+	res, err := s.db.Exec(`
+UPDATE
+  announcements
+SET
+  emails_sent_at = now()
+WHERE
+  id = $1
+AND
+  emails_sent_at IS NULL`, announcementID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// Recipients returns all users the announcement is addressed to, who did not
+// mute announcement emails.
+func (s *AnnouncementStore) Recipients(p *model.Announcement) ([]model.User, error) {
+	users := []model.User{}
+	err := s.db.Select(&users, `
+SELECT
+  u.*
+FROM
+  users u
+INNER JOIN user_course uc ON uc.user_id = u.id
+WHERE
+  uc.course_id = $1
+AND
+  uc.role = ANY($2)
+AND
+  u.announcement_emails_muted = false
+AND
+(
+  $3::INT IS NULL
+OR
+  EXISTS (SELECT 1 FROM user_group ug WHERE ug.group_id = $3 AND ug.user_id = u.id)
+OR
+  EXISTS (SELECT 1 FROM groups g WHERE g.id = $3 AND g.tutor_id = u.id)
+OR
+  EXISTS (SELECT 1 FROM group_tutors gt WHERE gt.group_id = $3 AND gt.user_id = u.id)
+)
+ORDER BY
+  u.id ASC`, p.CourseID, p.Roles, p.GroupID)
+	return users, err
+}
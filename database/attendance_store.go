@@ -0,0 +1,139 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"time"
+
+	"github.com/infomark-org/infomark/model"
+)
+
+type AttendanceStore struct {
+	db Queryer
+}
+
+func NewAttendanceStore(db Queryer) *AttendanceStore {
+	return &AttendanceStore{
+		db: db,
+	}
+}
+
+const attendanceSelect = `
+SELECT
+  a.*,
+  s.group_id,
+  u.first_name as user_first_name,
+  u.last_name as user_last_name,
+  u.email as user_email,
+  u.student_number as user_student_number
+FROM
+  attendances a
+INNER JOIN group_sessions s ON s.id = a.session_id
+INNER JOIN users u ON u.id = a.user_id
+`
+
+// GetForSession returns the attendance of all students at a single date of a
+// group session.
+func (s *AttendanceStore) GetForSession(sessionID int64, date time.Time) ([]model.Attendance, error) {
+	p := []model.Attendance{}
+	err := s.db.Select(&p, attendanceSelect+`
+WHERE
+  a.session_id = $1
+AND
+  a.session_date = $2
+ORDER BY
+  u.last_name ASC, u.first_name ASC`, sessionID, date)
+	return p, err
+}
+
+// GetOfGroup returns the attendance of all students in all sessions of a group.
+func (s *AttendanceStore) GetOfGroup(groupID int64) ([]model.Attendance, error) {
+	p := []model.Attendance{}
+	err := s.db.Select(&p, attendanceSelect+`
+WHERE
+  s.group_id = $1
+ORDER BY
+  a.session_date ASC, a.session_id ASC, u.last_name ASC, u.first_name ASC`, groupID)
+	return p, err
+}
+
+// GetOfUserInCourse returns the attendance of a student in all sessions of a course.
+func (s *AttendanceStore) GetOfUserInCourse(userID int64, courseID int64) ([]model.Attendance, error) {
+	p := []model.Attendance{}
+	err := s.db.Select(&p, attendanceSelect+`
+INNER JOIN groups g ON g.id = s.group_id
+WHERE
+  a.user_id = $1
+AND
+  g.course_id = $2
+ORDER BY
+  a.session_date ASC, a.session_id ASC`, userID, courseID)
+	return p, err
+}
+
+// CountsOfCourse sums up the attendance of each student in a course, or only
+// of a single student if the userID is not 0.
+func (s *AttendanceStore) CountsOfCourse(courseID int64, userID int64) ([]model.AttendanceCount, error) {
+	p := []model.AttendanceCount{}
+	err := s.db.Select(&p, `
+SELECT
+  a.user_id,
+  COUNT(*) FILTER (WHERE a.present) attended,
+  COUNT(*) FILTER (WHERE a.presented) presented
+FROM
+  attendances a
+INNER JOIN group_sessions s ON s.id = a.session_id
+INNER JOIN groups g ON g.id = s.group_id
+WHERE
+  g.course_id = $1
+AND
+  ($2 = 0 OR a.user_id = $2)
+GROUP BY
+  a.user_id`, courseID, userID)
+	return p, err
+}
+
+// SetMany stores the attendance of many students in a single transaction.
+// Existing records of the same student and date are replaced.
+func (s *AttendanceStore) SetMany(attendances []model.Attendance) error {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range attendances {
+		if _, err := tx.Exec(`
+INSERT INTO attendances
+  (session_id, session_date, user_id, present, presented, recorded_by)
+VALUES
+  ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (session_id, session_date, user_id) DO UPDATE SET
+  present = EXCLUDED.present,
+  presented = EXCLUDED.presented,
+  recorded_by = EXCLUDED.recorded_by,
+  updated_at = current_timestamp`,
+			p.SessionID, p.SessionDate, p.UserID, p.Present, p.Presented, p.RecordedBy); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
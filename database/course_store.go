@@ -22,15 +22,14 @@ package database
 import (
 	"github.com/infomark-org/infomark/auth/authorize"
 	"github.com/infomark-org/infomark/model"
-	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
 type CourseStore struct {
-	db *sqlx.DB
+	db Queryer
 }
 
-func NewCourseStore(db *sqlx.DB) *CourseStore {
+func NewCourseStore(db Queryer) *CourseStore {
 	return &CourseStore{
 		db: db,
 	}
@@ -104,7 +103,15 @@ func (s *CourseStore) Delete(courseID int64) error {
 }
 
 func (s *CourseStore) Enroll(courseID int64, userID int64, role int64) error {
-	err := s.Disenroll(courseID, userID)
+	// changing the role keeps the group membership, so only the course
+	// enrollment itself is replaced
+	_, err := s.db.Exec(`
+DELETE FROM
+  user_course
+WHERE
+  user_id = $1
+AND
+  course_id = $2; `, userID, courseID)
 	if err != nil {
 		return err
 	}
@@ -116,15 +123,47 @@ VALUES (DEFAULT, $1, $2, $3);
 	return err
 }
 
+// Disenroll removes a user from a course together with the seat in an exercise
+// group and all entries on group waitlists of this course.
 func (s *CourseStore) Disenroll(courseID int64, userID int64) error {
-	_, err := s.db.Exec(`
+	return Transaction(s.db, func(tx Queryer) error {
+		if _, err := tx.Exec(`
+DELETE FROM
+  user_group ug
+USING
+  groups g
+WHERE
+  ug.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  ug.user_id = $2`, courseID, userID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`
+DELETE FROM
+  group_waitlists w
+USING
+  groups g
+WHERE
+  w.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  w.user_id = $2`, courseID, userID); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(`
 DELETE FROM
   user_course
 WHERE
   user_id = $1
 AND
   course_id = $2; `, userID, courseID)
-	return err
+		return err
+	})
 }
 
 func (s *CourseStore) GetUserEnrollment(courseID int64, userID int64) (*model.UserCourse, error) {
@@ -246,8 +285,8 @@ func (s *CourseStore) PointsForUser(userID int64, courseID int64) ([]model.Sheet
 	err := s.db.Select(&p, `
 SELECT
   SUM(g.acquired_points) acquired_points,
-  SUM(t.max_points) max_points,
-  COALESCE(SUM(t.max_points) FILTER(WHERE g.tutor_id <> 1), 0) AS "achievable_points",
+  COALESCE(SUM(t.max_points) FILTER(WHERE NOT t.is_bonus), 0) max_points,
+  COALESCE(SUM(t.max_points) FILTER(WHERE g.tutor_id <> 1 AND NOT t.is_bonus), 0) AS "achievable_points",
   ts.sheet_id sheet_id
 FROM
   grades g
@@ -261,9 +300,9 @@ WHERE
 AND
   c.id = $2
 GROUP BY
-  ts.sheet_id
+  ts.sheet_id, sc.ordering
 ORDER BY
-  ts.sheet_id`, userID, courseID,
+  sc.ordering ASC, ts.sheet_id ASC`, userID, courseID,
 	)
 	return p, err
 
@@ -300,3 +339,124 @@ AND
 	}
 
 }
+
+// GetAdmissionOverrides returns all manual decisions about the exam admission
+// in a course, or only the one of a single student if the userID is not 0.
+func (s *CourseStore) GetAdmissionOverrides(courseID int64, userID int64) ([]model.AdmissionOverride, error) {
+	p := []model.AdmissionOverride{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  admission_overrides
+WHERE
+  course_id = $1
+AND
+  ($2 = 0 OR user_id = $2)
+ORDER BY
+  user_id`, courseID, userID)
+	return p, err
+}
+
+// SetAdmissionOverride creates or replaces the decision about the exam admission of a student.
+func (s *CourseStore) SetAdmissionOverride(p *model.AdmissionOverride) error {
+	_, err := s.db.Exec(`
+INSERT INTO admission_overrides
+  (course_id, user_id, admitted, comment)
+VALUES
+  ($1, $2, $3, $4)
+ON CONFLICT (course_id, user_id) DO UPDATE SET
+  admitted = EXCLUDED.admitted,
+  comment = EXCLUDED.comment,
+  updated_at = current_timestamp`,
+		p.CourseID, p.UserID, p.Admitted, p.Comment)
+	return err
+}
+
+// DeleteAdmissionOverride restores the computed exam admission of a student.
+func (s *CourseStore) DeleteAdmissionOverride(courseID int64, userID int64) error {
+	_, err := s.db.Exec(`
+DELETE FROM
+  admission_overrides
+WHERE
+  course_id = $1
+AND
+  user_id = $2`, courseID, userID)
+	return err
+}
+
+// Lock locks the course until the transaction ends. This serializes
+// enrollments, which must respect the maximum number of students.
+func (s *CourseStore) Lock(courseID int64) error {
+	var id int64
+	return s.db.Get(&id, "SELECT id FROM courses WHERE id = $1 FOR UPDATE", courseID)
+}
+
+// CountStudents returns the number of students enrolled in a course.
+func (s *CourseStore) CountStudents(courseID int64) (int, error) {
+	var count int
+	err := s.db.Get(&count, `
+SELECT
+  count(*)
+FROM
+  user_course
+WHERE
+  course_id = $1
+AND
+  role = 0`, courseID)
+	return count, err
+}
+
+// CreateEnrollmentRequest puts a user into the approval queue of a course.
+func (s *CourseStore) CreateEnrollmentRequest(courseID int64, userID int64) error {
+	_, err := s.db.Exec(`
+INSERT INTO enrollment_requests
+  (course_id, user_id)
+VALUES
+  ($1, $2)
+ON CONFLICT (course_id, user_id) DO NOTHING`, courseID, userID)
+	return err
+}
+
+const enrollmentRequestSelect = `
+SELECT
+  r.*,
+  u.first_name user_first_name,
+  u.last_name user_last_name,
+  u.email user_email,
+  u.student_number user_student_number
+FROM
+  enrollment_requests r
+INNER JOIN users u ON u.id = r.user_id
+WHERE
+  r.course_id = $1`
+
+// GetEnrollmentRequests returns the approval queue of a course (oldest first).
+func (s *CourseStore) GetEnrollmentRequests(courseID int64) ([]model.EnrollmentRequest, error) {
+	p := []model.EnrollmentRequest{}
+	err := s.db.Select(&p, enrollmentRequestSelect+`
+ORDER BY
+  r.created_at ASC, r.id ASC`, courseID)
+	return p, err
+}
+
+// GetEnrollmentRequest returns the pending enrollment of a user.
+func (s *CourseStore) GetEnrollmentRequest(courseID int64, userID int64) (*model.EnrollmentRequest, error) {
+	p := &model.EnrollmentRequest{}
+	err := s.db.Get(p, enrollmentRequestSelect+`
+AND
+  r.user_id = $2`, courseID, userID)
+	return p, err
+}
+
+// DeleteEnrollmentRequest removes a user from the approval queue.
+func (s *CourseStore) DeleteEnrollmentRequest(courseID int64, userID int64) error {
+	_, err := s.db.Exec(`
+DELETE FROM
+  enrollment_requests
+WHERE
+  course_id = $1
+AND
+  user_id = $2`, courseID, userID)
+	return err
+}
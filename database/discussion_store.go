@@ -0,0 +1,196 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package database
+
+import (
+	"github.com/infomark-org/infomark/model"
+	null "gopkg.in/guregu/null.v3"
+)
+
+type DiscussionStore struct {
+	db Queryer
+}
+
+func NewDiscussionStore(db Queryer) *DiscussionStore {
+	return &DiscussionStore{
+		db: db,
+	}
+}
+
+const discussionThreadSelect = `
+SELECT
+  t.*,
+  u.first_name author_first_name,
+  u.last_name author_last_name,
+  (SELECT COUNT(*) FROM discussion_posts p WHERE p.thread_id = t.id) post_count
+FROM
+  discussion_threads t
+INNER JOIN users u ON u.id = t.author_id
+`
+
+const discussionPostSelect = `
+SELECT
+  p.*,
+  u.first_name author_first_name,
+  u.last_name author_last_name
+FROM
+  discussion_posts p
+INNER JOIN users u ON u.id = p.author_id
+`
+
+func (s *DiscussionStore) GetThread(threadID int64) (*model.DiscussionThread, error) {
+	p := model.DiscussionThread{}
+	err := s.db.Get(&p, discussionThreadSelect+`
+WHERE
+  t.id = $1`, threadID)
+	return &p, err
+}
+
+func (s *DiscussionStore) CreateThread(p *model.DiscussionThread) (*model.DiscussionThread, error) {
+	newID, err := Insert(s.db, "discussion_threads", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetThread(newID)
+}
+
+func (s *DiscussionStore) UpdateThread(p *model.DiscussionThread) error {
+	return Update(s.db, "discussion_threads", p.ID, p)
+}
+
+func (s *DiscussionStore) DeleteThread(threadID int64) error {
+	return Delete(s.db, "discussion_threads", threadID)
+}
+
+// ThreadsOfCourse returns all threads of a course the user can see, newest
+// first. Private threads are only returned to their author or if staff is
+// set, threads about unpublished sheets only if staff is set. Threads can be
+// filtered by a sheet or a task (if not 0).
+func (s *DiscussionStore) ThreadsOfCourse(courseID int64, userID int64, staff bool, sheetID int64, taskID int64) ([]model.DiscussionThread, error) {
+	p := []model.DiscussionThread{}
+	err := s.db.Select(&p, discussionThreadSelect+`
+WHERE
+  t.course_id = $1
+AND
+  (t.private = false OR t.author_id = $2 OR $3)
+AND
+  ($3 OR t.sheet_id IS NULL OR EXISTS (SELECT 1 FROM sheets s WHERE s.id = t.sheet_id AND s.publish_at <= now()))
+AND
+  ($4 = 0 OR t.sheet_id = $4)
+AND
+  ($5 = 0 OR t.task_id = $5)
+ORDER BY
+  t.created_at DESC, t.id DESC`, courseID, userID, staff, sheetID, taskID)
+	return p, err
+}
+
+func (s *DiscussionStore) GetPost(postID int64) (*model.DiscussionPost, error) {
+	p := model.DiscussionPost{}
+	err := s.db.Get(&p, discussionPostSelect+`
+WHERE
+  p.id = $1`, postID)
+	return &p, err
+}
+
+func (s *DiscussionStore) CreatePost(p *model.DiscussionPost) (*model.DiscussionPost, error) {
+	newID, err := Insert(s.db, "discussion_posts", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPost(newID)
+}
+
+func (s *DiscussionStore) UpdatePost(p *model.DiscussionPost) error {
+	return Update(s.db, "discussion_posts", p.ID, p)
+}
+
+func (s *DiscussionStore) DeletePost(postID int64) error {
+	return Delete(s.db, "discussion_posts", postID)
+}
+
+// PostsOfThread returns all answers of a thread, endorsed answers first.
+func (s *DiscussionStore) PostsOfThread(threadID int64) ([]model.DiscussionPost, error) {
+	p := []model.DiscussionPost{}
+	err := s.db.Select(&p, discussionPostSelect+`
+WHERE
+  p.thread_id = $1
+ORDER BY
+  p.endorsed DESC, p.created_at ASC, p.id ASC`, threadID)
+	return p, err
+}
+
+// Subscribe notifies the user about new threads of a course (if threadID is
+// null) or about new answers in a thread.
+func (s *DiscussionStore) Subscribe(courseID int64, threadID null.Int, userID int64) error {
+	_, err := s.db.Exec(`
+INSERT INTO discussion_subscriptions
+  (course_id, thread_id, user_id)
+VALUES
+  ($1, $2, $3)
+ON CONFLICT DO NOTHING`, courseID, threadID, userID)
+	return err
+}
+
+func (s *DiscussionStore) Unsubscribe(courseID int64, threadID null.Int, userID int64) error {
+	_, err := s.db.Exec(`
+DELETE FROM
+  discussion_subscriptions
+WHERE
+  course_id = $1
+AND
+  thread_id IS NOT DISTINCT FROM $2
+AND
+  user_id = $3`, courseID, threadID, userID)
+	return err
+}
+
+func (s *DiscussionStore) IsSubscribed(courseID int64, threadID null.Int, userID int64) (bool, error) {
+	var count int
+	err := s.db.Get(&count, `
+SELECT
+  COUNT(*)
+FROM
+  discussion_subscriptions
+WHERE
+  course_id = $1
+AND
+  thread_id IS NOT DISTINCT FROM $2
+AND
+  user_id = $3`, courseID, threadID, userID)
+	return count > 0, err
+}
+
+// Subscribers returns all users subscribed to new threads of a course (if
+// threadID is null) or to new answers in a thread.
+func (s *DiscussionStore) Subscribers(courseID int64, threadID null.Int) ([]model.User, error) {
+	p := []model.User{}
+	err := s.db.Select(&p, `
+SELECT
+  u.*
+FROM
+  users u
+INNER JOIN discussion_subscriptions ds ON ds.user_id = u.id
+WHERE
+  ds.course_id = $1
+AND
+  ds.thread_id IS NOT DISTINCT FROM $2
+ORDER BY
+  u.id ASC`, courseID, threadID)
+	return p, err
+}
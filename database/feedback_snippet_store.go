@@ -0,0 +1,95 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"github.com/infomark-org/infomark/model"
+)
+
+type FeedbackSnippetStore struct {
+	db Queryer
+}
+
+func NewFeedbackSnippetStore(db Queryer) *FeedbackSnippetStore {
+	return &FeedbackSnippetStore{
+		db: db,
+	}
+}
+
+func (s *FeedbackSnippetStore) Get(id int64) (*model.FeedbackSnippet, error) {
+	p := model.FeedbackSnippet{ID: id}
+	err := s.db.Get(&p, `
+SELECT
+  f.*,
+  (SELECT COUNT(*) FROM feedback_snippet_usages u WHERE u.snippet_id = f.id) usage_count
+FROM
+  feedback_snippets f
+WHERE
+  f.id = $1 LIMIT 1
+`, p.ID)
+	return &p, err
+}
+
+func (s *FeedbackSnippetStore) Create(p *model.FeedbackSnippet) (*model.FeedbackSnippet, error) {
+	newID, err := Insert(s.db, "feedback_snippets", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(newID)
+}
+
+func (s *FeedbackSnippetStore) Update(p *model.FeedbackSnippet) error {
+	return Update(s.db, "feedback_snippets", p.ID, p)
+}
+
+func (s *FeedbackSnippetStore) Delete(id int64) error {
+	return Delete(s.db, "feedback_snippets", id)
+}
+
+// GetForCourse returns the snippets of a course, most used first. If taskID is
+// not 0, only snippets of this task and the course-wide snippets are returned.
+func (s *FeedbackSnippetStore) GetForCourse(courseID int64, taskID int64) ([]model.FeedbackSnippet, error) {
+	p := []model.FeedbackSnippet{}
+	err := s.db.Select(&p, `
+SELECT
+  f.*,
+  (SELECT COUNT(*) FROM feedback_snippet_usages u WHERE u.snippet_id = f.id) usage_count
+FROM
+  feedback_snippets f
+WHERE
+  f.course_id = $1
+AND
+  ($2 = 0 OR f.task_id IS NULL OR f.task_id = $2)
+ORDER BY
+  usage_count DESC, f.id ASC
+`, courseID, taskID)
+	return p, err
+}
+
+// RecordUsage counts the usage of a snippet in a grade. Using a snippet
+// several times in the same grade counts only once.
+func (s *FeedbackSnippetStore) RecordUsage(snippetID int64, gradeID int64) error {
+	_, err := s.db.Exec(`
+INSERT INTO
+  feedback_snippet_usages (id, snippet_id, grade_id)
+VALUES (DEFAULT, $1, $2)
+ON CONFLICT (snippet_id, grade_id) DO NOTHING`, snippetID, gradeID)
+	return err
+}
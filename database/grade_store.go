@@ -22,14 +22,13 @@ package database
 import (
 	"github.com/infomark-org/infomark/model"
 	"github.com/infomark-org/infomark/symbol"
-	"github.com/jmoiron/sqlx"
 )
 
 type GradeStore struct {
-	db *sqlx.DB
+	db Queryer
 }
 
-func NewGradeStore(db *sqlx.DB) *GradeStore {
+func NewGradeStore(db Queryer) *GradeStore {
 	return &GradeStore{
 		db: db,
 	}
@@ -49,13 +48,41 @@ SELECT
   s.user_id,
   u.last_name user_last_name,
   u.first_name user_first_name,
-  u.email user_email
+  u.email user_email,
+  ts.task_id,
+  ts.sheet_id
+FROM
+  grades g
+INNER JOIN submissions s ON g.submission_id = s.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN users u ON s.user_id = u.id
+WHERE
+  g.id = $1 LIMIT 1
+`, p.ID)
+	return &p, err
+}
+
+// GetForUpdate reads a grade and locks it until the surrounding transaction
+// ends, such that no concurrent change is overwritten.
+func (s *GradeStore) GetForUpdate(id int64) (*model.Grade, error) {
+	p := model.Grade{ID: id}
+	err := s.db.Get(&p, `
+SELECT
+  g.*,
+  s.user_id,
+  u.last_name user_last_name,
+  u.first_name user_first_name,
+  u.email user_email,
+  ts.task_id,
+  ts.sheet_id
 FROM
   grades g
 INNER JOIN submissions s ON g.submission_id = s.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
 INNER JOIN users u ON s.user_id = u.id
 WHERE
   g.id = $1 LIMIT 1
+FOR UPDATE OF g
 `, p.ID)
 	return &p, err
 }
@@ -68,8 +95,10 @@ func (s *GradeStore) Create(p *model.Grade) (*model.Grade, error) {
 	return s.Get(newID)
 }
 
-func (s *GradeStore) UpdatePrivateTestInfo(gradeID int64, log string, status symbol.TestingResult) error {
-	_, err := s.db.Exec(`
+// UpdatePrivateTestInfo stores the result of the private tests together with
+// the given history entry in a single transaction.
+func (s *GradeStore) UpdatePrivateTestInfo(gradeID int64, log string, status symbol.TestingResult, history *model.GradeHistory) error {
+	return s.execWithHistory(history, `
 UPDATE grades
 SET
   private_execution_state=$4,
@@ -78,11 +107,12 @@ SET
 WHERE
   id = $1
     `, gradeID, log, status, symbol.TestingStateFinished)
-	return err
 }
 
-func (s *GradeStore) UpdatePublicTestInfo(gradeID int64, log string, status symbol.TestingResult) error {
-	_, err := s.db.Exec(`
+// UpdatePublicTestInfo stores the result of the public tests together with
+// the given history entry in a single transaction.
+func (s *GradeStore) UpdatePublicTestInfo(gradeID int64, log string, status symbol.TestingResult, history *model.GradeHistory) error {
+	return s.execWithHistory(history, `
 UPDATE grades
 SET
   public_execution_state=$4,
@@ -91,7 +121,25 @@ SET
 WHERE
   id = $1
     `, gradeID, log, status, symbol.TestingStateFinished)
-	return err
+}
+
+func (s *GradeStore) execWithHistory(history *model.GradeHistory, stmt string, args ...interface{}) error {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(stmt, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := insertHistory(tx, history); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *GradeStore) GetForSubmission(id int64) (*model.Grade, error) {
@@ -164,7 +212,17 @@ INNER JOIN sheet_course sg ON sg.sheet_id = ts.sheet_id
 INNER JOIN users u ON s.user_id = u.id
 INNER JOIN user_group ug ON ug.user_id = u.id
 WHERE
-  g.feedback like '' and g.tutor_id = $1
+  g.feedback like ''
+AND (
+  g.tutor_id = $1
+  OR g.assigned_tutor_id = $1
+  OR EXISTS (
+    SELECT 1
+    FROM group_tutors gt
+    INNER JOIN groups gr ON gr.id = gt.group_id
+    WHERE gt.user_id = $1 AND gr.course_id = $2 AND gt.group_id = ug.group_id
+  )
+)
 AND
   sg.course_id = $2
 AND
@@ -177,6 +235,50 @@ func (s *GradeStore) Update(p *model.Grade) error {
 	return Update(s.db, "grades", p.ID, p)
 }
 
+// UpdateWithHistory writes a grade together with its history entry in a
+// single transaction.
+func (s *GradeStore) UpdateWithHistory(p *model.Grade, history *model.GradeHistory) error {
+	return s.UpdateBatch([]model.Grade{*p}, []model.GradeHistory{*history})
+}
+
+// UpdateBatch writes several grades together with their history entries in a
+// single transaction. Either all changes are stored or none. Changing a grade
+// requires a new reconciliation of its second grading.
+func (s *GradeStore) UpdateBatch(grades []model.Grade, histories []model.GradeHistory) error {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return err
+	}
+
+	for k := range grades {
+		if err := Update(tx, "grades", grades[k].ID, &grades[k]); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(`
+UPDATE
+  second_gradings
+SET
+  reconciled = false,
+  reconciled_by = NULL
+WHERE
+  grade_id = $1`, grades[k].ID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for k := range histories {
+		if err := insertHistory(tx, &histories[k]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (s *GradeStore) GetFiltered(
 	courseID int64,
 	sheetID int64,
@@ -199,7 +301,9 @@ SELECT
   g.*, s.user_id,
   u.last_name user_last_name,
   u.first_name user_first_name,
-  u.email user_email
+  u.email user_email,
+  ts.task_id,
+  ts.sheet_id
 FROM
   grades g
 INNER JOIN submissions s ON s.id = g.submission_id
@@ -293,3 +397,291 @@ WHERE
 
 	return task, err
 }
+
+func (s *GradeStore) CreateHistory(p *model.GradeHistory) (*model.GradeHistory, error) {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertHistory(tx, p); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	res := &model.GradeHistory{}
+	if err := tx.Get(res, "SELECT * FROM grade_histories WHERE id = $1 LIMIT 1;", p.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return res, tx.Commit()
+}
+
+// insertHistory stores a history entry together with the context of its grade,
+// such that the entry stays meaningful after the grade has been deleted.
+func insertHistory(tx Tx, p *model.GradeHistory) error {
+	err := tx.Get(p, `
+SELECT
+  s.user_id,
+  s.task_id,
+  ts.sheet_id,
+  sc.course_id
+FROM
+  grades g
+INNER JOIN submissions s ON g.submission_id = s.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE
+  g.id = $1
+LIMIT 1`, p.GradeID)
+	if err != nil {
+		return err
+	}
+
+	p.ID, err = Insert(tx, "grade_histories", p)
+	return err
+}
+
+func (s *GradeStore) GetHistory(gradeID int64) ([]model.GradeHistory, error) {
+	p := []model.GradeHistory{}
+	err := s.db.Select(&p, `
+SELECT
+  h.*,
+  u.first_name actor_first_name,
+  u.last_name actor_last_name
+FROM
+  grade_histories h
+LEFT JOIN users u ON h.actor_id = u.id
+WHERE
+  h.grade_id = $1
+ORDER BY
+  h.created_at ASC, h.id ASC
+`, gradeID)
+	return p, err
+}
+
+// GetHistoryOfCourse returns all recorded grade changes of a course, including
+// those of grades which have been deleted in the meantime.
+func (s *GradeStore) GetHistoryOfCourse(courseID int64) ([]model.GradeHistory, error) {
+	p := []model.GradeHistory{}
+	err := s.db.Select(&p, `
+SELECT
+  h.*,
+  u.first_name actor_first_name,
+  u.last_name actor_last_name
+FROM
+  grade_histories h
+LEFT JOIN users u ON h.actor_id = u.id
+WHERE
+  h.course_id = $1
+ORDER BY
+  h.created_at ASC, h.id ASC
+`, courseID)
+	return p, err
+}
+
+// GetGradingProgress counts all assigned and finished grades for each tutor.
+// A grade is finished once the tutor has written a feedback. Grades without an
+// assigned tutor are counted for the tutor who graded them.
+func (s *GradeStore) GetGradingProgress(courseID int64, sheetID int64) ([]model.GradingProgress, error) {
+	p := []model.GradingProgress{}
+	err := s.db.Select(&p, `
+SELECT
+  COALESCE(g.assigned_tutor_id, g.tutor_id) tutor_id,
+  COALESCE(u.first_name, '') tutor_first_name,
+  COALESCE(u.last_name, '') tutor_last_name,
+  COUNT(*) total,
+  COUNT(*) FILTER(WHERE g.feedback <> '') done
+FROM
+  grades g
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+LEFT JOIN users u ON u.id = COALESCE(g.assigned_tutor_id, g.tutor_id)
+WHERE
+  sc.course_id = $1
+AND
+  ($2 = 0 OR ts.sheet_id = $2)
+GROUP BY
+  COALESCE(g.assigned_tutor_id, g.tutor_id), u.first_name, u.last_name
+ORDER BY
+  COALESCE(g.assigned_tutor_id, g.tutor_id)
+`, courseID, sheetID)
+	return p, err
+}
+
+// GetUnfinishedOfTutor returns all grades assigned to a tutor without a feedback.
+func (s *GradeStore) GetUnfinishedOfTutor(courseID int64, sheetID int64, tutorID int64) ([]model.Grade, error) {
+	p := []model.Grade{}
+	err := s.db.Select(&p, `
+SELECT
+  g.*,
+  s.user_id,
+  u.last_name user_last_name,
+  u.first_name user_first_name,
+  u.email user_email
+FROM
+  grades g
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+INNER JOIN users u ON s.user_id = u.id
+WHERE
+  sc.course_id = $1
+AND
+  ($2 = 0 OR ts.sheet_id = $2)
+AND
+  COALESCE(g.assigned_tutor_id, g.tutor_id) = $3
+AND
+  g.feedback = ''
+`, courseID, sheetID, tutorID)
+	return p, err
+}
+
+// GetOfUserAndTask returns the grade of the submission of a user for a task.
+func (s *GradeStore) GetOfUserAndTask(userID int64, taskID int64) (*model.Grade, error) {
+	p := model.Grade{}
+	err := s.db.Get(&p, `
+SELECT
+  g.*,
+  s.user_id,
+  u.last_name user_last_name,
+  u.first_name user_first_name,
+  u.email user_email,
+  ts.task_id,
+  ts.sheet_id
+FROM
+  grades g
+INNER JOIN submissions s ON g.submission_id = s.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN users u ON s.user_id = u.id
+WHERE
+  s.user_id = $1
+AND
+  s.task_id = $2
+LIMIT 1
+`, userID, taskID)
+	return &p, err
+}
+
+// GetExportStudents returns all students of a course together with their group.
+// A groupID or userID of 0 does not filter.
+func (s *GradeStore) GetExportStudents(courseID int64, groupID int64, userID int64) ([]model.GradeExportStudent, error) {
+	p := []model.GradeExportStudent{}
+	err := s.db.Select(&p, `
+SELECT
+  u.id user_id,
+  u.first_name user_first_name,
+  u.last_name user_last_name,
+  u.email user_email,
+  u.student_number user_student_number,
+  COALESCE(gs.id, 0) group_id,
+  COALESCE(gs.description, '') group_description
+FROM
+  user_course uc
+INNER JOIN users u ON uc.user_id = u.id
+LEFT JOIN (
+  user_group ug INNER JOIN groups gs ON ug.group_id = gs.id
+) ON ug.user_id = u.id AND gs.course_id = uc.course_id
+WHERE
+  uc.course_id = $1
+AND
+  uc.role = 0
+AND
+  ($2 = 0 OR gs.id = $2)
+AND
+  ($3 = 0 OR u.id = $3)
+ORDER BY
+  u.last_name, u.first_name, u.id
+`, courseID, groupID, userID)
+	return p, err
+}
+
+// GetExportPoints returns the acquired points of all submissions in a course,
+// or only those of a single student if the userID is not 0.
+func (s *GradeStore) GetExportPoints(courseID int64, userID int64) ([]model.GradeExportPoints, error) {
+	p := []model.GradeExportPoints{}
+	err := s.db.Select(&p, `
+SELECT
+  s.user_id,
+  s.task_id,
+  g.acquired_points
+FROM
+  grades g
+INNER JOIN submissions s ON g.submission_id = s.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE
+  sc.course_id = $1
+AND
+  ($2 = 0 OR s.user_id = $2)
+`, courseID, userID)
+	return p, err
+}
+
+func (s *GradeStore) GetSecondGrading(gradeID int64) (*model.SecondGrading, error) {
+	p := model.SecondGrading{}
+	err := s.db.Get(&p, `
+SELECT
+  sg.*,
+  g.tutor_id first_tutor_id,
+  g.acquired_points first_acquired_points,
+  t.id task_id,
+  t.reconcile_threshold
+FROM
+  second_gradings sg
+INNER JOIN grades g ON g.id = sg.grade_id
+INNER JOIN submissions sub ON sub.id = g.submission_id
+INNER JOIN tasks t ON t.id = sub.task_id
+WHERE
+  sg.grade_id = $1 LIMIT 1
+`, gradeID)
+	return &p, err
+}
+
+// SetSecondGrading stores the second grading of a grade. Changing the second
+// grading requires a new reconciliation.
+func (s *GradeStore) SetSecondGrading(p *model.SecondGrading) error {
+	_, err := s.db.Exec(`
+INSERT INTO
+  second_gradings (id, grade_id, tutor_id, acquired_points, feedback)
+VALUES (DEFAULT, $1, $2, $3, $4)
+ON CONFLICT (grade_id) DO UPDATE SET
+  tutor_id = EXCLUDED.tutor_id,
+  acquired_points = EXCLUDED.acquired_points,
+  feedback = EXCLUDED.feedback,
+  reconciled = false,
+  reconciled_by = NULL,
+  updated_at = current_timestamp`,
+		p.GradeID, p.TutorID, p.AcquiredPoints, p.Feedback)
+	return err
+}
+
+func (s *GradeStore) UpdateSecondGrading(p *model.SecondGrading) error {
+	return Update(s.db, "second_gradings", p.ID, p)
+}
+
+// GetSecondGradingsOfCourse returns all second gradings of a course.
+func (s *GradeStore) GetSecondGradingsOfCourse(courseID int64) ([]model.SecondGrading, error) {
+	p := []model.SecondGrading{}
+	err := s.db.Select(&p, `
+SELECT
+  sg.*,
+  g.tutor_id first_tutor_id,
+  g.acquired_points first_acquired_points,
+  t.id task_id,
+  t.reconcile_threshold
+FROM
+  second_gradings sg
+INNER JOIN grades g ON g.id = sg.grade_id
+INNER JOIN submissions sub ON sub.id = g.submission_id
+INNER JOIN tasks t ON t.id = sub.task_id
+INNER JOIN task_sheet ts ON ts.task_id = t.id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE
+  sc.course_id = $1
+ORDER BY
+  sg.grade_id ASC
+`, courseID)
+	return p, err
+}
@@ -0,0 +1,141 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"time"
+
+	"github.com/infomark-org/infomark/model"
+)
+
+type GroupSessionStore struct {
+	db Queryer
+}
+
+func NewGroupSessionStore(db Queryer) *GroupSessionStore {
+	return &GroupSessionStore{
+		db: db,
+	}
+}
+
+func (s *GroupSessionStore) Get(sessionID int64) (*model.GroupSession, error) {
+	p := model.GroupSession{ID: sessionID}
+	err := s.db.Get(&p, "SELECT * FROM group_sessions WHERE id = $1 LIMIT 1;", p.ID)
+	return &p, err
+}
+
+func (s *GroupSessionStore) Create(p *model.GroupSession) (*model.GroupSession, error) {
+	newID, err := Insert(s.db, "group_sessions", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(newID)
+}
+
+func (s *GroupSessionStore) Update(p *model.GroupSession) error {
+	return Update(s.db, "group_sessions", p.ID, p)
+}
+
+func (s *GroupSessionStore) Delete(sessionID int64) error {
+	return Delete(s.db, "group_sessions", sessionID)
+}
+
+func (s *GroupSessionStore) SessionsOfGroup(groupID int64) ([]model.GroupSession, error) {
+	p := []model.GroupSession{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  group_sessions
+WHERE
+  group_id = $1
+ORDER BY
+  weekday ASC, start_time ASC, id ASC`, groupID)
+	return p, err
+}
+
+// FindRoomClashes returns all other sessions in the same room which overlap
+// with the given session. Rooms are compared case-insensitive across all courses.
+func (s *GroupSessionStore) FindRoomClashes(p *model.GroupSession) ([]model.GroupSession, error) {
+	candidates := []model.GroupSession{}
+	err := s.db.Select(&candidates, `
+SELECT
+  *
+FROM
+  group_sessions
+WHERE
+  id <> $1
+AND
+  LOWER(room) = LOWER($2)
+AND
+  weekday = $3`, p.ID, p.Room, p.Weekday)
+	if err != nil {
+		return nil, err
+	}
+
+	clashes := []model.GroupSession{}
+	for k := range candidates {
+		if p.Overlaps(&candidates[k]) {
+			clashes = append(clashes, candidates[k])
+		}
+	}
+	return clashes, nil
+}
+
+func (s *GroupSessionStore) GetCancellations(sessionID int64) ([]time.Time, error) {
+	p := []time.Time{}
+	err := s.db.Select(&p, `
+SELECT
+  cancelled_date
+FROM
+  group_session_cancellations
+WHERE
+  session_id = $1
+ORDER BY
+  cancelled_date ASC`, sessionID)
+	return p, err
+}
+
+// SetCancellations replaces all cancelled dates of a session.
+func (s *GroupSessionStore) SetCancellations(sessionID int64, dates []time.Time) error {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM group_session_cancellations WHERE session_id = $1`, sessionID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, date := range dates {
+		if _, err := tx.Exec(`
+INSERT INTO group_session_cancellations
+  (session_id, cancelled_date)
+VALUES
+  ($1, $2)
+ON CONFLICT DO NOTHING`, sessionID, date); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
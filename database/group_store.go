@@ -21,15 +21,14 @@ package database
 
 import (
 	"github.com/infomark-org/infomark/model"
-	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
 type GroupStore struct {
-	db *sqlx.DB
+	db Queryer
 }
 
-func NewGroupStore(db *sqlx.DB) *GroupStore {
+func NewGroupStore(db Queryer) *GroupStore {
 	return &GroupStore{
 		db: db,
 	}
@@ -214,6 +213,257 @@ func (s *GroupStore) ChangeGroupEnrollmentOfUserInCourse(p *model.GroupEnrollmen
 	return Update(s.db, "user_group", p.ID, p)
 }
 
+// ReplaceGroupEnrollmentsInCourse moves the given users into the given groups
+// of a course in a single transaction. Previous group enrollments of these
+// users in the course are removed.
+func (s *GroupStore) ReplaceGroupEnrollmentsInCourse(courseID int64, enrollments []model.GroupEnrollment) error {
+	userIDs := []int64{}
+	for _, enrollment := range enrollments {
+		userIDs = append(userIDs, enrollment.UserID)
+	}
+
+	tx, err := beginx(s.db)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+DELETE FROM
+  user_group ug
+USING
+  groups g
+WHERE
+  ug.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  ug.user_id = ANY($2)`, courseID, pq.Array(userIDs))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for k := range enrollments {
+		if _, err := Insert(tx, "user_group", &enrollments[k]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteGroupEnrollment removes a user from a group.
+func (s *GroupStore) DeleteGroupEnrollment(userID int64, groupID int64) error {
+	_, err := s.db.Exec(`
+DELETE FROM
+  user_group
+WHERE
+  user_id = $1
+AND
+  group_id = $2`, userID, groupID)
+	return err
+}
+
+// CountMembers returns the number of users enrolled in a group.
+func (s *GroupStore) CountMembers(groupID int64) (int, error) {
+	count := 0
+	err := s.db.Get(&count, `SELECT count(*) FROM user_group WHERE group_id = $1`, groupID)
+	return count, err
+}
+
+// EnrollOrWaitlist enrolls a user into a group if the group has a free seat.
+// Otherwise the user is put on the waitlist of the group. In case the user gets
+// enrolled, all entries of the user on waitlists in the same course are removed.
+func (s *GroupStore) EnrollOrWaitlist(userID int64, groupID int64) (enrolled bool, err error) {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return false, err
+	}
+
+	group := model.Group{}
+	// lock the group to avoid assigning the same seat twice
+	if err = tx.Get(&group, `SELECT * FROM groups WHERE id = $1 FOR UPDATE`, groupID); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	count := 0
+	if err = tx.Get(&count, `SELECT count(*) FROM user_group WHERE group_id = $1`, groupID); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if group.Capacity > 0 && count >= group.Capacity {
+		if _, err = Insert(tx, "group_waitlists", &model.GroupWaitlistEntry{UserID: userID, GroupID: groupID}); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+
+	if err = enrollFromWaitlist(tx, userID, &group); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// PromoteFromWaitlist fills all free seats of a group with users from its
+// waitlist in the order they joined the waitlist. It returns the ids of all
+// promoted users.
+func (s *GroupStore) PromoteFromWaitlist(groupID int64) ([]int64, error) {
+	promoted := []int64{}
+
+	tx, err := beginx(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	group := model.Group{}
+	if err = tx.Get(&group, `SELECT * FROM groups WHERE id = $1 FOR UPDATE`, groupID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for {
+		count := 0
+		if err = tx.Get(&count, `SELECT count(*) FROM user_group WHERE group_id = $1`, groupID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if group.Capacity > 0 && count >= group.Capacity {
+			break
+		}
+
+		entries := []model.GroupWaitlistEntry{}
+		if err = tx.Select(&entries, `
+SELECT
+  *
+FROM
+  group_waitlists
+WHERE
+  group_id = $1
+ORDER BY
+  id ASC
+LIMIT 1`, groupID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if len(entries) == 0 {
+			break
+		}
+
+		if err = enrollFromWaitlist(tx, entries[0].UserID, &group); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		promoted = append(promoted, entries[0].UserID)
+	}
+
+	return promoted, tx.Commit()
+}
+
+// enrollFromWaitlist puts a user into a group and removes the user from all
+// other groups and waitlists of the same course.
+func enrollFromWaitlist(tx Tx, userID int64, group *model.Group) error {
+	if _, err := tx.Exec(`
+DELETE FROM
+  user_group ug
+USING
+  groups g
+WHERE
+  ug.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  ug.user_id = $2`, group.CourseID, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+DELETE FROM
+  group_waitlists w
+USING
+  groups g
+WHERE
+  w.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  w.user_id = $2`, group.CourseID, userID); err != nil {
+		return err
+	}
+
+	_, err := Insert(tx, "user_group", &model.GroupEnrollment{UserID: userID, GroupID: group.ID})
+	return err
+}
+
+// GetWaitlist returns all users waiting for a seat in a group, first come first.
+func (s *GroupStore) GetWaitlist(groupID int64) ([]model.User, error) {
+	p := []model.User{}
+
+	err := s.db.Select(&p, `
+SELECT
+  u.*
+FROM
+  users u
+INNER JOIN
+  group_waitlists w ON w.user_id = u.id
+WHERE
+  w.group_id = $1
+ORDER BY
+  w.id ASC`, groupID)
+	return p, err
+}
+
+// GetWaitlistPosition returns the position (starting at 1) of a user on the
+// waitlist of a group or 0 if the user is not on the waitlist.
+func (s *GroupStore) GetWaitlistPosition(userID int64, groupID int64) (int, error) {
+	position := 0
+	err := s.db.Get(&position, `
+SELECT
+  count(*)
+FROM
+  group_waitlists
+WHERE
+  group_id = $2
+AND
+  id <= (SELECT id FROM group_waitlists WHERE group_id = $2 AND user_id = $1)`, userID, groupID)
+	return position, err
+}
+
+// RemoveFromWaitlist removes a user from the waitlist of a group.
+func (s *GroupStore) RemoveFromWaitlist(userID int64, groupID int64) error {
+	_, err := s.db.Exec(`
+DELETE FROM
+  group_waitlists
+WHERE
+  user_id = $1
+AND
+  group_id = $2`, userID, groupID)
+	return err
+}
+
+// RemoveFromWaitlistsInCourse removes a user from all waitlists of a course.
+func (s *GroupStore) RemoveFromWaitlistsInCourse(userID int64, courseID int64) error {
+	_, err := s.db.Exec(`
+DELETE FROM
+  group_waitlists w
+USING
+  groups g
+WHERE
+  w.group_id = g.id
+AND
+  g.course_id = $1
+AND
+  w.user_id = $2`, courseID, userID)
+	return err
+}
+
 func (s *GroupStore) GetOfTutor(tutorID int64, courseID int64) ([]model.GroupWithTutor, error) {
 	p := []model.GroupWithTutor{}
 
@@ -230,13 +480,102 @@ FROM
 INNER JOIN users u ON g.tutor_id = u.id
 WHERE
   course_id = $2
-AND
+AND (
   g.tutor_id = $1
+  OR EXISTS (SELECT 1 FROM group_tutors gt WHERE gt.group_id = g.id AND gt.user_id = $1)
+)
 ORDER BY
   g.id ASC`, tutorID, courseID)
 	return p, err
 }
 
+// IsTutorOfGroup tests if the user is the primary tutor or an additional
+// tutor of the group.
+func (s *GroupStore) IsTutorOfGroup(userID int64, groupID int64) (bool, error) {
+	var isTutor bool
+	err := s.db.Get(&isTutor, `
+SELECT EXISTS (
+  SELECT 1 FROM groups WHERE id = $2 AND tutor_id = $1
+  UNION ALL
+  SELECT 1 FROM group_tutors WHERE group_id = $2 AND user_id = $1
+)`, userID, groupID)
+	return isTutor, err
+}
+
+// GetCoTutors returns the additional tutors of a group (without the primary tutor).
+func (s *GroupStore) GetCoTutors(groupID int64) ([]model.User, error) {
+	p := []model.User{}
+
+	err := s.db.Select(&p, `
+SELECT
+  u.*
+FROM
+  users u
+INNER JOIN
+  group_tutors gt ON gt.user_id = u.id
+WHERE
+  gt.group_id = $1
+ORDER BY
+  u.id ASC`, groupID)
+	return p, err
+}
+
+// CoTutorsOfCourse returns the additional tutors of all groups in a course.
+func (s *GroupStore) CoTutorsOfCourse(courseID int64) ([]model.GroupCoTutor, error) {
+	p := []model.GroupCoTutor{}
+
+	err := s.db.Select(&p, `
+SELECT
+  gt.group_id,
+  u.id,
+  u.first_name,
+  u.last_name,
+  u.avatar_url,
+  u.email,
+  u.language
+FROM
+  group_tutors gt
+INNER JOIN groups g ON g.id = gt.group_id
+INNER JOIN users u ON u.id = gt.user_id
+WHERE
+  g.course_id = $1
+ORDER BY
+  gt.group_id ASC, u.id ASC`, courseID)
+	return p, err
+}
+
+// SetCoTutors replaces the additional tutors of a group. The primary tutor
+// is skipped if listed.
+func (s *GroupStore) SetCoTutors(groupID int64, tutorIDs []int64) error {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM group_tutors WHERE group_id = $1`, groupID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.Exec(`
+INSERT INTO group_tutors (group_id, user_id)
+SELECT
+  g.id, t.user_id
+FROM
+  groups g, UNNEST($2::int[]) t(user_id)
+WHERE
+  g.id = $1
+AND
+  t.user_id <> g.tutor_id
+ON CONFLICT DO NOTHING`, groupID, pq.Array(tutorIDs))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (s *GroupStore) IdentifyCourseOfGroup(groupID int64) (*model.Course, error) {
 
 	course := &model.Course{}
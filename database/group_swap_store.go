@@ -0,0 +1,246 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package database
+
+import (
+	"errors"
+
+	"github.com/infomark-org/infomark/model"
+	"github.com/infomark-org/infomark/symbol"
+	"github.com/lib/pq"
+)
+
+type GroupSwapStore struct {
+	db Queryer
+}
+
+func NewGroupSwapStore(db Queryer) *GroupSwapStore {
+	return &GroupSwapStore{
+		db: db,
+	}
+}
+
+// loadWishes fills in the wished groups of requests.
+func (s *GroupSwapStore) loadWishes(requests []model.GroupSwapRequest) error {
+	for k := range requests {
+		requests[k].WantedGroupIDs = []int64{}
+		if err := s.db.Select(&requests[k].WantedGroupIDs, `
+SELECT
+  group_id
+FROM
+  group_swap_wishes
+WHERE
+  request_id = $1
+ORDER BY
+  id ASC`, requests[k].ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GroupSwapStore) Get(id int64) (*model.GroupSwapRequest, error) {
+	p := model.GroupSwapRequest{ID: id}
+	if err := s.db.Get(&p, "SELECT * FROM group_swap_requests WHERE id = $1 LIMIT 1;", p.ID); err != nil {
+		return nil, err
+	}
+
+	requests := []model.GroupSwapRequest{p}
+	if err := s.loadWishes(requests); err != nil {
+		return nil, err
+	}
+	return &requests[0], nil
+}
+
+func (s *GroupSwapStore) Create(p *model.GroupSwapRequest) (*model.GroupSwapRequest, error) {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	newID, err := Insert(tx, "group_swap_requests", p)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for _, groupID := range p.WantedGroupIDs {
+		if _, err := tx.Exec(`
+INSERT INTO group_swap_wishes
+  (request_id, group_id)
+VALUES
+  ($1, $2)`, newID, groupID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.Get(newID)
+}
+
+func (s *GroupSwapStore) Update(p *model.GroupSwapRequest) error {
+	return Update(s.db, "group_swap_requests", p.ID, p)
+}
+
+// GetOfCourse returns all requests of a course, optionally only those with
+// the given status (a negative status means all).
+func (s *GroupSwapStore) GetOfCourse(courseID int64, status int) ([]model.GroupSwapRequest, error) {
+	p := []model.GroupSwapRequest{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  group_swap_requests
+WHERE
+  course_id = $1
+AND
+  ($2 < 0 OR status = $2)
+ORDER BY
+  id ASC`, courseID, status)
+	if err != nil {
+		return nil, err
+	}
+	return p, s.loadWishes(p)
+}
+
+// GetOfUserInCourse returns all requests of a student in a course.
+func (s *GroupSwapStore) GetOfUserInCourse(userID int64, courseID int64) ([]model.GroupSwapRequest, error) {
+	p := []model.GroupSwapRequest{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  group_swap_requests
+WHERE
+  user_id = $1
+AND
+  course_id = $2
+ORDER BY
+  id ASC`, userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	return p, s.loadWishes(p)
+}
+
+// FindMatch returns the oldest open request of another student, who is in one
+// of the wished groups and wants to join the group of the given request.
+// Requests of students, who have left the group of their request in the
+// meantime, are ignored. The match is locked until the transaction ends.
+func (s *GroupSwapStore) FindMatch(p *model.GroupSwapRequest) (*model.GroupSwapRequest, error) {
+	matches := []model.GroupSwapRequest{}
+	err := s.db.Select(&matches, `
+SELECT
+  r.*
+FROM
+  group_swap_requests r
+WHERE
+  r.course_id = $1
+AND
+  r.status = $2
+AND
+  r.user_id <> $3
+AND
+  r.from_group_id = ANY($4)
+AND
+  EXISTS (SELECT 1 FROM group_swap_wishes w WHERE w.request_id = r.id AND w.group_id = $5)
+AND
+  EXISTS (SELECT 1 FROM user_group ug WHERE ug.user_id = r.user_id AND ug.group_id = r.from_group_id)
+ORDER BY
+  r.id ASC
+LIMIT 1
+FOR UPDATE OF r`, p.CourseID, symbol.GroupSwapOpen, p.UserID, pq.Array(p.WantedGroupIDs), p.FromGroupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	if err := s.loadWishes(matches); err != nil {
+		return nil, err
+	}
+	return &matches[0], nil
+}
+
+// Execute swaps the groups of the students of two complementary requests and
+// completes both requests in a single transaction. It fails if one of the
+// students is not in the group of the request anymore.
+func (s *GroupSwapStore) Execute(a *model.GroupSwapRequest, b *model.GroupSwapRequest) error {
+	tx, err := beginx(s.db)
+	if err != nil {
+		return err
+	}
+
+	moves := []struct {
+		request *model.GroupSwapRequest
+		toGroup int64
+	}{
+		{a, b.FromGroupID},
+		{b, a.FromGroupID},
+	}
+
+	for _, move := range moves {
+		res, err := tx.Exec(`
+UPDATE
+  user_group
+SET
+  group_id = $3
+WHERE
+  user_id = $1
+AND
+  group_id = $2`, move.request.UserID, move.request.FromGroupID, move.toGroup)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		changed, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if changed != 1 {
+			tx.Rollback()
+			return errors.New("a student is not in the group of the swap request anymore")
+		}
+	}
+
+	for _, request := range []*model.GroupSwapRequest{a, b} {
+		if _, err := tx.Exec(`
+UPDATE
+  group_swap_requests
+SET
+  status = $2,
+  matched_with_id = $3,
+  updated_at = current_timestamp
+WHERE
+  id = $1`, request.ID, symbol.GroupSwapCompleted, request.MatchedWithID); err != nil {
+			tx.Rollback()
+			return err
+		}
+		request.Status = symbol.GroupSwapCompleted
+	}
+
+	return tx.Commit()
+}
@@ -21,17 +21,17 @@ package database
 
 import (
 	"github.com/infomark-org/infomark/model"
-	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // MaterialStore is the store for materials (slides, additional material) for a
 // lecture.
 type MaterialStore struct {
-	db *sqlx.DB
+	db Queryer
 }
 
 // NewMaterialStore creates a new material store.
-func NewMaterialStore(db *sqlx.DB) *MaterialStore {
+func NewMaterialStore(db Queryer) *MaterialStore {
 	return &MaterialStore{
 		db: db,
 	}
@@ -62,9 +62,10 @@ func (s *MaterialStore) Create(p *model.Material, courseID int64) (*model.Materi
 	// now associate sheet with course
 	_, err = s.db.Exec(`
 INSERT INTO
-  material_course (id,material_id,course_id)
+  material_course (id,material_id,course_id,ordering)
 VALUES
-  (DEFAULT, $1, $2);`,
+  (DEFAULT, $1, $2,
+    (SELECT COALESCE(MAX(ordering) + 1, 0) FROM material_course WHERE course_id = $2));`,
 		newID, courseID)
 	if err != nil {
 		return nil, err
@@ -98,10 +99,26 @@ WHERE
 AND
   m.required_role <= $2
 ORDER BY
-  m.lecture_at ASC;`, courseID, givenRole)
+  mc.ordering ASC, m.id ASC;`, courseID, givenRole)
 	return p, err
 }
 
+// Reorder sets the position of the materials within a course to the order of the given ids.
+func (s *MaterialStore) Reorder(courseID int64, materialIDs []int64) error {
+	_, err := s.db.Exec(`
+UPDATE
+  material_course mc
+SET
+  ordering = o.position - 1
+FROM
+  unnest($2::bigint[]) WITH ORDINALITY o(id, position)
+WHERE
+  mc.course_id = $1
+AND
+  mc.material_id = o.id`, courseID, pq.Array(materialIDs))
+	return err
+}
+
 // IdentifyCourseOfMaterial returns the course, which is associated with the material.
 func (s *MaterialStore) IdentifyCourseOfMaterial(sheetID int64) (*model.Course, error) {
 
@@ -0,0 +1,147 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package database
+
+import (
+	"github.com/infomark-org/infomark/model"
+)
+
+type RegradeStore struct {
+	db Queryer
+}
+
+func NewRegradeStore(db Queryer) *RegradeStore {
+	return &RegradeStore{
+		db: db,
+	}
+}
+
+func (s *RegradeStore) Get(id int64) (*model.Regrade, error) {
+	p := model.Regrade{ID: id}
+	err := s.db.Get(&p, `
+SELECT
+  r.*,
+  u.first_name user_first_name,
+  u.last_name user_last_name,
+  u.email user_email,
+  s.task_id,
+  ts.sheet_id
+FROM
+  regrade_requests r
+INNER JOIN grades g ON r.grade_id = g.id
+INNER JOIN submissions s ON g.submission_id = s.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN users u ON r.user_id = u.id
+WHERE
+  r.id = $1 LIMIT 1
+`, p.ID)
+	return &p, err
+}
+
+func (s *RegradeStore) Create(p *model.Regrade) (*model.Regrade, error) {
+	newID, err := Insert(s.db, "regrade_requests", p)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(newID)
+}
+
+func (s *RegradeStore) Update(p *model.Regrade) error {
+	return Update(s.db, "regrade_requests", p.ID, p)
+}
+
+func (s *RegradeStore) GetForGrade(gradeID int64) ([]model.Regrade, error) {
+	p := []model.Regrade{}
+	err := s.db.Select(&p, `
+SELECT
+  r.*,
+  u.first_name user_first_name,
+  u.last_name user_last_name,
+  u.email user_email,
+  s.task_id,
+  ts.sheet_id
+FROM
+  regrade_requests r
+INNER JOIN grades g ON r.grade_id = g.id
+INNER JOIN submissions s ON g.submission_id = s.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN users u ON r.user_id = u.id
+WHERE
+  r.grade_id = $1
+ORDER BY
+  r.created_at ASC
+`, gradeID)
+	return p, err
+}
+
+// GetFiltered returns all regrade requests of a course. The group filter is
+// ignored if groupID is 0, the status filter is ignored if status is -1.
+func (s *RegradeStore) GetFiltered(courseID int64, groupID int64, status int) ([]model.Regrade, error) {
+	p := []model.Regrade{}
+	err := s.db.Select(&p, `
+SELECT
+  r.*,
+  u.first_name user_first_name,
+  u.last_name user_last_name,
+  u.email user_email,
+  s.task_id,
+  ts.sheet_id
+FROM
+  regrade_requests r
+INNER JOIN grades g ON r.grade_id = g.id
+INNER JOIN submissions s ON g.submission_id = s.id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+INNER JOIN users u ON r.user_id = u.id
+WHERE
+  sc.course_id = $1
+AND
+  ($2 = 0 OR r.user_id IN (
+    SELECT ug.user_id FROM user_group ug WHERE ug.group_id = $2))
+AND
+  ($3 = -1 OR r.status = $3)
+ORDER BY
+  r.created_at ASC
+`, courseID, groupID, status)
+	return p, err
+}
+
+func (s *RegradeStore) IdentifyCourseOfRegrade(regradeID int64) (*model.Course, error) {
+
+	course := &model.Course{}
+	err := s.db.Get(course,
+		`
+SELECT
+  c.*
+FROM
+  regrade_requests r
+INNER JOIN grades g ON g.id = r.grade_id
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+INNER JOIN courses c ON sc.course_id = c.id
+WHERE
+  r.id = $1`,
+		regradeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return course, err
+}
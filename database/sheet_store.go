@@ -21,14 +21,14 @@ package database
 
 import (
 	"github.com/infomark-org/infomark/model"
-	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type SheetStore struct {
-	db *sqlx.DB
+	db Queryer
 }
 
-func NewSheetStore(db *sqlx.DB) *SheetStore {
+func NewSheetStore(db Queryer) *SheetStore {
 	return &SheetStore{
 		db: db,
 	}
@@ -57,9 +57,10 @@ func (s *SheetStore) Create(p *model.Sheet, courseID int64) (*model.Sheet, error
 	_, err = s.db.Exec(`
 INSERT INTO
   sheet_course
-  (id,sheet_id,course_id)
+  (id,sheet_id,course_id,ordering)
 VALUES
-  (DEFAULT, $1, $2);`,
+  (DEFAULT, $1, $2,
+    (SELECT COALESCE(MAX(ordering) + 1, 0) FROM sheet_course WHERE course_id = $2));`,
 		newID, courseID)
 	if err != nil {
 		return nil, err
@@ -81,7 +82,9 @@ func (s *SheetStore) SheetsOfCourse(courseID int64) ([]model.Sheet, error) {
 
 	err := s.db.Select(&p, `
 SELECT
-  s.id, s.created_at, s.updated_at, s.name, s.publish_at, s.due_at
+  s.id, s.created_at, s.updated_at, s.name, s.publish_at, s.due_at,
+  s.grades_release_at, s.grades_withheld, s.grading_strategy, s.blind_grading,
+  s.min_percentage, s.grades_release_notify, s.grades_release_notified_at
 FROM
   sheet_course sc
 INNER JOIN
@@ -91,10 +94,103 @@ INNER JOIN
 WHERE
   sc.course_id = $1
 ORDER BY
-  s.publish_at ASC;`, courseID)
+  sc.ordering ASC, s.id ASC;`, courseID)
 	return p, err
 }
 
+// Reorder sets the position of the sheets within a course to the order of the given ids.
+func (s *SheetStore) Reorder(courseID int64, sheetIDs []int64) error {
+	_, err := s.db.Exec(`
+UPDATE
+  sheet_course sc
+SET
+  ordering = o.position - 1
+FROM
+  unnest($2::bigint[]) WITH ORDINALITY o(id, position)
+WHERE
+  sc.course_id = $1
+AND
+  sc.sheet_id = o.id`, courseID, pq.Array(sheetIDs))
+	return err
+}
+
+// PendingGradeReleaseEmails returns all sheets whose grades have been released
+// by their schedule and whose students have not been notified yet.
+func (s *SheetStore) PendingGradeReleaseEmails() ([]model.Sheet, error) {
+	p := []model.Sheet{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  sheets
+WHERE
+  grades_release_notify = true
+AND
+  grades_release_notified_at IS NULL
+AND
+  grades_withheld = false
+AND
+  grades_release_at <= now()
+ORDER BY
+  grades_release_at ASC`)
+	return p, err
+}
+
+// ClaimGradeReleaseEmails marks the students of a sheet as notified about the
+// release of the grades. It returns false if this has been done before, so
+// every release is only announced once.
+func (s *SheetStore) ClaimGradeReleaseEmails(sheetID int64) (bool, error) {
+	res, err := s.db.Exec(`
+UPDATE
+  sheets
+SET
+  grades_release_notified_at = now()
+WHERE
+  id = $1
+AND
+  grades_release_notified_at IS NULL`, sheetID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// RearmGradeReleaseEmails forgets about a previous notification, such that the
+// students are notified again once the grades are released the next time.
+func (s *SheetStore) RearmGradeReleaseEmails(sheetID int64) error {
+	_, err := s.db.Exec(`
+UPDATE
+  sheets
+SET
+  grades_release_notified_at = NULL
+WHERE
+  id = $1`, sheetID)
+	return err
+}
+
+// GradeReleaseRecipients returns all students of a course who did not mute
+// emails of their courses.
+func (s *SheetStore) GradeReleaseRecipients(courseID int64) ([]model.User, error) {
+	users := []model.User{}
+	err := s.db.Select(&users, `
+SELECT
+  u.*
+FROM
+  users u
+INNER JOIN user_course uc ON uc.user_id = u.id
+WHERE
+  uc.course_id = $1
+AND
+  uc.role = 0
+AND
+  u.announcement_emails_muted = false
+ORDER BY
+  u.id ASC`, courseID)
+	return users, err
+}
+
 func (s *SheetStore) IdentifyCourseOfSheet(sheetID int64) (*model.Course, error) {
 
 	course := &model.Course{}
@@ -123,8 +219,9 @@ func (s *SheetStore) PointsForUser(userID int64, sheetID int64) ([]model.TaskPoi
 SELECT
   t.id task_id,
   g.acquired_points,
-  CASE g.tutor_id <> 1 WHEN TRUE THEN t.max_points ELSE 0 END AS "achievable_points",
-  t.max_points
+  CASE g.tutor_id <> 1 AND NOT t.is_bonus WHEN TRUE THEN t.max_points ELSE 0 END AS "achievable_points",
+  t.max_points,
+  t.is_bonus
 FROM
   grades g
 INNER JOIN submissions sub ON g.submission_id = sub.id
@@ -135,7 +232,7 @@ WHERE
 AND
   ts.sheet_id = $2
 ORDER BY
-  ts.sheet_id`, userID, sheetID,
+  ts.ordering ASC, t.id ASC`, userID, sheetID,
 	)
 	return p, err
 
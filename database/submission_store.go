@@ -21,14 +21,13 @@ package database
 
 import (
 	"github.com/infomark-org/infomark/model"
-	"github.com/jmoiron/sqlx"
 )
 
 type SubmissionStore struct {
-	db *sqlx.DB
+	db Queryer
 }
 
-func NewSubmissionStore(db *sqlx.DB) *SubmissionStore {
+func NewSubmissionStore(db Queryer) *SubmissionStore {
 	return &SubmissionStore{
 		db: db,
 	}
@@ -56,6 +55,21 @@ LIMIT 1;`,
 	return &p, err
 }
 
+// SubmissionsOfTask returns all submissions to a task, regardless of groups.
+func (s *SubmissionStore) SubmissionsOfTask(taskID int64) ([]model.Submission, error) {
+	p := []model.Submission{}
+	err := s.db.Select(&p, `
+SELECT
+  *
+FROM
+  submissions
+WHERE
+  task_id = $1
+ORDER BY
+  id ASC`, taskID)
+	return p, err
+}
+
 func (s *SubmissionStore) Create(p *model.Submission) (*model.Submission, error) {
 	newID, err := Insert(s.db, "submissions", p)
 	if err != nil {
@@ -21,14 +21,14 @@ package database
 
 import (
 	"github.com/infomark-org/infomark/model"
-	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type TaskStore struct {
-	db *sqlx.DB
+	db Queryer
 }
 
-func NewTaskStore(db *sqlx.DB) *TaskStore {
+func NewTaskStore(db Queryer) *TaskStore {
 	return &TaskStore{
 		db: db,
 	}
@@ -74,8 +74,9 @@ func (s *TaskStore) Create(p *model.Task, sheetID int64) (*model.Task, error) {
 
 	// now associate sheet with course
 	_, err = s.db.Exec(`INSERT INTO task_sheet
-    (id,task_id,sheet_id)
-    VALUES (DEFAULT, $1, $2);`, newID, sheetID)
+    (id,task_id,sheet_id,ordering)
+    VALUES (DEFAULT, $1, $2,
+      (SELECT COALESCE(MAX(ordering) + 1, 0) FROM task_sheet WHERE sheet_id = $2));`, newID, sheetID)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +104,12 @@ SELECT
   t.max_points,
   t.name,
   t.public_docker_image,
-  t.private_docker_image
+  t.private_docker_image,
+  t.grader_id,
+  t.is_bonus,
+  t.bonus_points,
+  t.double_grading,
+  t.reconcile_threshold
 FROM
   task_sheet ts
 INNER JOIN tasks t ON ts.task_id = t.id
@@ -111,10 +117,26 @@ INNER JOIN sheets s ON ts.sheet_id = s.id
 WHERE
   s.id = $1
 ORDER BY
-  t.name ASC;`, sheetID)
+  ts.ordering ASC, t.id ASC;`, sheetID)
 	return p, err
 }
 
+// Reorder sets the position of the tasks within a sheet to the order of the given ids.
+func (s *TaskStore) Reorder(sheetID int64, taskIDs []int64) error {
+	_, err := s.db.Exec(`
+UPDATE
+  task_sheet ts
+SET
+  ordering = o.position - 1
+FROM
+  unnest($2::bigint[]) WITH ORDINALITY o(id, position)
+WHERE
+  ts.sheet_id = $1
+AND
+  ts.task_id = o.id`, sheetID, pq.Array(taskIDs))
+	return err
+}
+
 func (s *TaskStore) IdentifyCourseOfTask(taskID int64) (*model.Course, error) {
 
 	course := &model.Course{}
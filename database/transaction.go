@@ -0,0 +1,90 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package database
+
+import (
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Queryer is implemented by *sqlx.DB and *sqlx.Tx. Stores built on top of a
+// transaction run all their statements within this transaction.
+type Queryer interface {
+	DB
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+}
+
+// Tx is a transaction which has been started by beginx.
+type Tx interface {
+	Queryer
+	Commit() error
+	Rollback() error
+}
+
+// nestedTx joins an already running transaction. Whether the changes are
+// stored is decided by the outer transaction.
+type nestedTx struct {
+	*sqlx.Tx
+}
+
+// Commit leaves the commit to the outer transaction.
+func (tx nestedTx) Commit() error {
+	return nil
+}
+
+// Rollback leaves the rollback to the outer transaction, which will not commit
+// as the error is passed on.
+func (tx nestedTx) Rollback() error {
+	return nil
+}
+
+// beginx starts a new transaction or joins the transaction the stores are
+// already running in.
+func beginx(db Queryer) (Tx, error) {
+	switch db := db.(type) {
+	case *sqlx.DB:
+		return db.Beginx()
+	case *sqlx.Tx:
+		return nestedTx{db}, nil
+	}
+	return nil, errors.New("database does not support transactions")
+}
+
+// Transaction runs fn within a single transaction. The transaction is committed
+// when fn succeeds and rolled back otherwise.
+func Transaction(db Queryer, fn func(tx Queryer) error) error {
+	tx, err := beginx(db)
+	if err != nil {
+		return err
+	}
+
+	var inner Queryer = tx
+	if nested, ok := tx.(nestedTx); ok {
+		inner = nested.Tx
+	}
+
+	if err := fn(inner); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
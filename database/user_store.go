@@ -21,14 +21,13 @@ package database
 
 import (
 	"github.com/infomark-org/infomark/model"
-	"github.com/jmoiron/sqlx"
 )
 
 type UserStore struct {
-	db *sqlx.DB
+	db Queryer
 }
 
-func NewUserStore(db *sqlx.DB) *UserStore {
+func NewUserStore(db Queryer) *UserStore {
 	return &UserStore{
 		db: db,
 	}
@@ -46,6 +45,12 @@ func (s *UserStore) FindByEmail(email string) (*model.User, error) {
 	return &p, err
 }
 
+func (s *UserStore) FindByCalendarToken(token string) (*model.User, error) {
+	p := model.User{}
+	err := s.db.Get(&p, "SELECT * FROM users WHERE calendar_token = $1 LIMIT 1;", token)
+	return &p, err
+}
+
 func (s *UserStore) Find(query string) ([]model.User, error) {
 	p := []model.User{}
 	err := s.db.Select(&p, `
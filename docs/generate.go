@@ -214,6 +214,17 @@ func main() {
 	f.WriteString("          schema:\n")
 	f.WriteString("            type: string\n")
 	f.WriteString("            format: binary\n")
+	f.WriteString("    SpreadsheetFile:\n")
+	f.WriteString("      description: A table as a download.\n")
+	f.WriteString("      content:\n")
+	f.WriteString("        text/csv:\n")
+	f.WriteString("          schema:\n")
+	f.WriteString("            type: string\n")
+	f.WriteString("            format: binary\n")
+	f.WriteString("        application/vnd.openxmlformats-officedocument.spreadsheetml.sheet:\n")
+	f.WriteString("          schema:\n")
+	f.WriteString("            type: string\n")
+	f.WriteString("            format: binary\n")
 	f.WriteString("    OK:\n")
 	f.WriteString("      description: Post successfully delivered.\n")
 	f.WriteString("    NoContent:\n")
@@ -334,7 +345,7 @@ func main() {
 				f.WriteString("      requestBody:\n")
 				f.WriteString("        required: true\n")
 
-				switch action.Details.Request {
+				switch strings.ToLower(action.Details.Request) {
 				case "zipfile":
 					f.WriteString("        content:\n")
 					f.WriteString("          multipart/form-data:\n")
@@ -359,6 +370,18 @@ func main() {
 					f.WriteString("            encoding:\n")
 					f.WriteString("              file_data:\n")
 					f.WriteString("                contentType: image/jpeg\n")
+				case "csvfile":
+					f.WriteString("        content:\n")
+					f.WriteString("          multipart/form-data:\n")
+					f.WriteString("            schema:\n")
+					f.WriteString("              type: object\n")
+					f.WriteString("              properties:\n")
+					f.WriteString("                file_data:\n")
+					f.WriteString("                  type: string\n")
+					f.WriteString("                  format: binary\n")
+					f.WriteString("            encoding:\n")
+					f.WriteString("              file_data:\n")
+					f.WriteString("                contentType: text/csv\n")
 				case "empty":
 
 				default:
@@ -98,6 +98,23 @@ func SwaggerStructs(structDescr *Struct, depth int) string {
 					fieldDescr.Tag.Required = false
 				}
 
+				if x.X.(*ast.Ident).Name == "null" && x.Sel.Name == "Int" {
+					source = source + fmt.Sprintf("%s    type: integer\n", pre)
+					fieldDescr.Tag.Required = false
+				}
+
+				if x.X.(*ast.Ident).Name == "null" && x.Sel.Name == "Bool" {
+					source = source + fmt.Sprintf("%s    type: boolean\n", pre)
+					fieldDescr.Tag.Required = false
+				}
+
+				if x.X.(*ast.Ident).Name == "null" && x.Sel.Name == "Time" {
+					source = source + fmt.Sprintf("%s    type: string\n", pre)
+					source = source + fmt.Sprintf("%s    format: date-time\n", pre)
+					fieldDescr.Tag.Required = false
+					examples[fieldDescr.Tag.Name] = "'2019-07-30T23:59:59Z'"
+				}
+
 				if x.X.(*ast.Ident).Name == "time" && x.Sel.Name == "Time" {
 					source = source + fmt.Sprintf("%s    type: string\n", pre)
 					source = source + fmt.Sprintf("%s    format: date-time\n", pre)
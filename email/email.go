@@ -222,5 +222,101 @@ Your password can only be changed manually by you.
 `
 )
 
+const (
+	discussionTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+{{.author_name}} wrote a new {{.kind}} in the discussion "{{.title}}" of the course "{{.course_name}}":
+
+{{.body}}
+
+{{.discussion_url}}
+
+`
+)
+
+const (
+	announcementTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+There is a new announcement in the course "{{.course_name}}":
+
+{{.title}}
+
+{{.body}}
+
+{{.course_url}}
+
+`
+)
+
+const (
+	userInviteTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+An account has been created for you and you have been enrolled in the course "{{.course_name}}".
+
+Please choose a password for your account using the following link.
+
+{{.reset_password_url}}/{{.email_address}}/{{.reset_password_token}}
+
+`
+)
+
+const (
+	gradesReleasedTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+The grades for "{{.sheet_name}}" in the course "{{.course_name}}" have been released.
+
+You can look at your points and the feedback of your tutor here:
+
+{{.course_url}}
+
+`
+)
+
+const (
+	regradeDecisionTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+Your regrade request for the task "{{.task_name}}" in the course "{{.course_name}}" has been {{.decision}}.
+
+Your grade is now {{.acquired_points}} point(s).
+
+The tutor responded:
+
+{{.response}}
+
+`
+)
+
+const (
+	waitlistPromotionTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+A seat became available and you have been moved from the waitlist into the group "{{.group_description}}" in the course "{{.course_name}}".
+
+`
+)
+
+const (
+	groupSwapStudentTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+Your group swap request in the course "{{.course_name}}" has been {{.decision}}.
+
+{{.details}}
+
+`
+
+	groupSwapTutorTemplateSrcEN = `Hi {{.first_name}} {{.last_name}}!
+
+The student {{.student_name}} moved from the group "{{.from_group}}" to the group "{{.to_group}}" in the course "{{.course_name}}".
+
+`
+)
+
+var GroupSwapStudentTemplateEN *template.Template = template.Must(template.New("groupSwapStudentTemplateSrcEN").Parse(groupSwapStudentTemplateSrcEN))
+var GroupSwapTutorTemplateEN *template.Template = template.Must(template.New("groupSwapTutorTemplateSrcEN").Parse(groupSwapTutorTemplateSrcEN))
+var WaitlistPromotionTemplateEN *template.Template = template.Must(template.New("waitlistPromotionTemplateSrcEN").Parse(waitlistPromotionTemplateSrcEN))
+var RegradeDecisionTemplateEN *template.Template = template.Must(template.New("regradeDecisionTemplateSrcEN").Parse(regradeDecisionTemplateSrcEN))
+var GradesReleasedTemplateEN *template.Template = template.Must(template.New("gradesReleasedTemplateSrcEN").Parse(gradesReleasedTemplateSrcEN))
 var ConfirmEmailTemplateEN *template.Template = template.Must(template.New("confirmEmailTemplateSrcEN").Parse(confirmEmailTemplateSrcEN))
+var DiscussionTemplateEN *template.Template = template.Must(template.New("discussionTemplateSrcEN").Parse(discussionTemplateSrcEN))
+var AnnouncementTemplateEN *template.Template = template.Must(template.New("announcementTemplateSrcEN").Parse(announcementTemplateSrcEN))
+var UserInviteTemplateEN *template.Template = template.Must(template.New("userInviteTemplateSrcEN").Parse(userInviteTemplateSrcEN))
 var RequestPasswordTokenTemailTemplateEN *template.Template = template.Must(template.New("requestPasswordTokenTemailTemplateSrcEN").Parse(requestPasswordTokenTemailTemplateSrcEN))
@@ -0,0 +1,36 @@
+BEGIN;
+-- reusable comments for grading, either for a single task or the entire course
+CREATE TABLE feedback_snippets (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  course_id INT not null,
+  -- null means the snippet can be used for all tasks of the course
+  task_id INT null,
+  author_id INT null,
+  text TEXT not null,
+  points_delta INT not null DEFAULT 0,
+
+  FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+  FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE,
+  FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX feedback_snippets_course_id_idx ON feedback_snippets (course_id);
+
+-- each snippet counts at most once per grade
+CREATE TABLE feedback_snippet_usages (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  snippet_id INT not null,
+  grade_id INT not null,
+
+  FOREIGN KEY (snippet_id) REFERENCES feedback_snippets (id) ON DELETE CASCADE,
+  FOREIGN KEY (grade_id) REFERENCES grades (id) ON DELETE CASCADE,
+  UNIQUE(snippet_id, grade_id)
+);
+
+COMMIT;
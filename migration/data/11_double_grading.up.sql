@@ -0,0 +1,27 @@
+BEGIN;
+-- tasks which need two independent gradings
+ALTER TABLE tasks ADD COLUMN double_grading BOOLEAN not null DEFAULT false;
+-- largest difference of both gradings which does not require a reconciliation
+ALTER TABLE tasks ADD COLUMN reconcile_threshold INT not null DEFAULT 0;
+
+-- the independent second grading of a grade, the first one is the grade itself
+CREATE TABLE second_gradings (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  grade_id INT not null,
+  tutor_id INT not null,
+  acquired_points INT not null,
+  feedback TEXT not null DEFAULT '',
+
+  reconciled BOOLEAN not null DEFAULT false,
+  reconciled_by INT null,
+
+  FOREIGN KEY (grade_id) REFERENCES grades (id) ON DELETE CASCADE,
+  FOREIGN KEY (tutor_id) REFERENCES users (id) ON DELETE CASCADE,
+  FOREIGN KEY (reconciled_by) REFERENCES users (id) ON DELETE SET NULL,
+  UNIQUE(grade_id)
+);
+
+COMMIT;
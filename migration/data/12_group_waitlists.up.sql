@@ -0,0 +1,23 @@
+BEGIN;
+-- largest number of students in a group, 0 means unlimited
+ALTER TABLE groups ADD COLUMN capacity INT not null DEFAULT 0;
+
+-- students can join groups on their own (first come, first served)
+ALTER TABLE courses ADD COLUMN group_self_enrollment BOOLEAN not null DEFAULT false;
+-- notify students by email when they move up from a waitlist
+ALTER TABLE courses ADD COLUMN waitlist_notification BOOLEAN not null DEFAULT true;
+
+-- students waiting for a free seat in a full group
+CREATE TABLE group_waitlists (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  group_id INT not null,
+  user_id INT not null,
+
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  UNIQUE(group_id, user_id)
+);
+
+COMMIT;
@@ -0,0 +1,35 @@
+BEGIN;
+-- weekly meetings of an exercise group
+CREATE TABLE group_sessions (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  group_id INT not null,
+  -- 0 is sunday, 6 is saturday
+  weekday INT not null,
+  -- local time of day as HH:MM
+  start_time VARCHAR(5) not null,
+  end_time VARCHAR(5) not null,
+  room TEXT not null DEFAULT '',
+  online_url TEXT null,
+  first_date DATE not null,
+  last_date DATE not null,
+
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE
+);
+
+-- single dates a weekly session does not take place
+CREATE TABLE group_session_cancellations (
+  id SERIAL not null primary key,
+  session_id INT not null,
+  cancelled_date DATE not null,
+
+  FOREIGN KEY (session_id) REFERENCES group_sessions (id) ON DELETE CASCADE,
+  UNIQUE(session_id, cancelled_date)
+);
+
+-- secret to subscribe to the personal calendar feed without logging in
+ALTER TABLE users ADD COLUMN calendar_token TEXT null;
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+-- attendance required for the exam admission
+ALTER TABLE courses ADD COLUMN required_attendances INT not null DEFAULT 0;
+ALTER TABLE courses ADD COLUMN required_presentations INT not null DEFAULT 0;
+
+-- attendance of a student at a single date of a weekly group session
+CREATE TABLE attendances (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  session_id INT not null,
+  session_date DATE not null,
+  user_id INT not null,
+  present BOOLEAN not null DEFAULT false,
+  -- the student presented a solution
+  presented BOOLEAN not null DEFAULT false,
+  recorded_by INT null,
+
+  FOREIGN KEY (session_id) REFERENCES group_sessions (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  FOREIGN KEY (recorded_by) REFERENCES users (id) ON DELETE SET NULL,
+  UNIQUE(session_id, session_date, user_id)
+);
+
+COMMIT;
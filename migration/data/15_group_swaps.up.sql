@@ -0,0 +1,34 @@
+BEGIN;
+-- matched group swaps have to be approved by an admin
+ALTER TABLE courses ADD COLUMN group_swap_approval BOOLEAN not null DEFAULT false;
+
+-- a student wants to leave the own group for one of the wished groups
+CREATE TABLE group_swap_requests (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  course_id INT not null,
+  user_id INT not null,
+  from_group_id INT not null,
+  status INT not null DEFAULT 0,
+  -- the complementary request of another student
+  matched_with_id INT null,
+
+  FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  FOREIGN KEY (from_group_id) REFERENCES groups (id) ON DELETE CASCADE,
+  FOREIGN KEY (matched_with_id) REFERENCES group_swap_requests (id) ON DELETE SET NULL
+);
+
+CREATE TABLE group_swap_wishes (
+  id SERIAL not null primary key,
+  request_id INT not null,
+  group_id INT not null,
+
+  FOREIGN KEY (request_id) REFERENCES group_swap_requests (id) ON DELETE CASCADE,
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+  UNIQUE(request_id, group_id)
+);
+
+COMMIT;
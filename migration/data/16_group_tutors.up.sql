@@ -0,0 +1,14 @@
+BEGIN;
+-- additional tutors of a group, the primary tutor remains groups.tutor_id
+CREATE TABLE group_tutors (
+  id SERIAL not null primary key,
+
+  group_id INT not null,
+  user_id INT not null,
+
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  UNIQUE(group_id, user_id)
+);
+
+COMMIT;
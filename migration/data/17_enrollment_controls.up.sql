@@ -0,0 +1,27 @@
+BEGIN;
+-- students must know this key to enroll, empty means no key
+ALTER TABLE courses ADD COLUMN enrollment_key VARCHAR(255) not null DEFAULT '';
+-- enrollment is only possible within this window (if set)
+ALTER TABLE courses ADD COLUMN enrollment_begins_at TIMESTAMP null;
+ALTER TABLE courses ADD COLUMN enrollment_ends_at TIMESTAMP null;
+-- largest number of students, 0 means unlimited
+ALTER TABLE courses ADD COLUMN max_students INT not null DEFAULT 0;
+-- enrollments must be approved by an admin of the course
+ALTER TABLE courses ADD COLUMN enrollment_approval BOOLEAN not null DEFAULT false;
+-- email domains (starting with "@") or student numbers which may enroll, empty means everyone
+ALTER TABLE courses ADD COLUMN enrollment_allowlist TEXT[] null;
+
+-- students waiting for the approval of their enrollment
+CREATE TABLE enrollment_requests (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  course_id INT not null,
+  user_id INT not null,
+
+  FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  UNIQUE(course_id, user_id)
+);
+
+COMMIT;
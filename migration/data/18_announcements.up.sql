@@ -0,0 +1,31 @@
+BEGIN;
+-- persistent messages to the members of a course
+CREATE TABLE announcements (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  course_id INT not null,
+  author_id INT not null,
+  title TEXT not null,
+  -- markdown
+  body TEXT not null DEFAULT '',
+  pinned BOOLEAN not null DEFAULT false,
+  -- announcements are hidden from the feed before this time
+  published_at TIMESTAMP not null DEFAULT current_timestamp,
+  -- course roles which see the announcement
+  roles INT[] not null DEFAULT '{0,1,2}',
+  -- only members and tutors of this group see the announcement (if set)
+  group_id INT null,
+  send_email BOOLEAN not null DEFAULT false,
+  emails_sent_at TIMESTAMP null,
+
+  FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+  FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE CASCADE,
+  FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE
+);
+
+-- users can opt out of announcement emails
+ALTER TABLE users ADD COLUMN announcement_emails_muted BOOLEAN not null DEFAULT false;
+
+COMMIT;
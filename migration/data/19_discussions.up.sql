@@ -0,0 +1,60 @@
+BEGIN;
+-- questions about a course, a sheet or a task
+CREATE TABLE discussion_threads (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  course_id INT not null,
+  -- the thread is about a sheet or a task of the course (if set)
+  sheet_id INT null,
+  task_id INT null,
+  author_id INT not null,
+  title TEXT not null,
+  -- markdown
+  body TEXT not null DEFAULT '',
+  -- only the author and the staff of the course can see the thread
+  private BOOLEAN not null DEFAULT false,
+  -- the author is hidden from other students
+  anonymous BOOLEAN not null DEFAULT false,
+
+  FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+  FOREIGN KEY (sheet_id) REFERENCES sheets (id) ON DELETE CASCADE,
+  FOREIGN KEY (task_id) REFERENCES tasks (id) ON DELETE CASCADE,
+  FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- answers to a question
+CREATE TABLE discussion_posts (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  thread_id INT not null,
+  author_id INT not null,
+  body TEXT not null,
+  anonymous BOOLEAN not null DEFAULT false,
+  -- a tutor or admin marked the answer as correct
+  endorsed BOOLEAN not null DEFAULT false,
+
+  FOREIGN KEY (thread_id) REFERENCES discussion_threads (id) ON DELETE CASCADE,
+  FOREIGN KEY (author_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- users are notified about new threads of a course (thread_id is null)
+-- or new answers in a thread
+CREATE TABLE discussion_subscriptions (
+  id SERIAL not null primary key,
+  course_id INT not null,
+  thread_id INT null,
+  user_id INT not null,
+
+  FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+  FOREIGN KEY (thread_id) REFERENCES discussion_threads (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX discussion_subscriptions_course ON discussion_subscriptions (course_id, user_id) WHERE thread_id IS NULL;
+CREATE UNIQUE INDEX discussion_subscriptions_thread ON discussion_subscriptions (thread_id, user_id) WHERE thread_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,35 @@
+BEGIN;
+-- explicit position of sheets in a course, tasks in a sheet and materials in a course
+ALTER TABLE sheet_course ADD COLUMN ordering INT not null DEFAULT 0;
+ALTER TABLE task_sheet ADD COLUMN ordering INT not null DEFAULT 0;
+ALTER TABLE material_course ADD COLUMN ordering INT not null DEFAULT 0;
+
+-- keep the previous order
+UPDATE sheet_course sc SET ordering = o.position FROM (
+  SELECT
+    sc.id,
+    ROW_NUMBER() OVER (PARTITION BY sc.course_id ORDER BY s.publish_at ASC, s.id ASC) - 1 position
+  FROM
+    sheet_course sc
+  INNER JOIN sheets s ON s.id = sc.sheet_id
+) o WHERE o.id = sc.id;
+
+UPDATE task_sheet ts SET ordering = o.position FROM (
+  SELECT
+    ts.id,
+    ROW_NUMBER() OVER (PARTITION BY ts.sheet_id ORDER BY t.name ASC, t.id ASC) - 1 position
+  FROM
+    task_sheet ts
+  INNER JOIN tasks t ON t.id = ts.task_id
+) o WHERE o.id = ts.id;
+
+UPDATE material_course mc SET ordering = o.position FROM (
+  SELECT
+    mc.id,
+    ROW_NUMBER() OVER (PARTITION BY mc.course_id ORDER BY m.lecture_at ASC, m.id ASC) - 1 position
+  FROM
+    material_course mc
+  INNER JOIN materials m ON m.id = mc.material_id
+) o WHERE o.id = mc.id;
+
+COMMIT;
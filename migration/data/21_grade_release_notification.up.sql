@@ -0,0 +1,6 @@
+BEGIN;
+-- students are notified once the scheduled release of the grades has passed
+ALTER TABLE sheets ADD COLUMN grades_release_notify BOOLEAN not null DEFAULT false;
+-- NULL means nobody has been notified yet
+ALTER TABLE sheets ADD COLUMN grades_release_notified_at TIMESTAMP NULL;
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+-- tutor who is supposed to grade the submission, tutor_id stays 1 (the system)
+-- until somebody actually grades it
+ALTER TABLE grades ADD COLUMN assigned_tutor_id INT NULL;
+ALTER TABLE grades ADD FOREIGN KEY (assigned_tutor_id) REFERENCES users (id) ON DELETE SET NULL;
+
+ALTER TABLE grade_histories ADD COLUMN old_assigned_tutor_id INT NULL;
+ALTER TABLE grade_histories ADD COLUMN new_assigned_tutor_id INT NULL;
+
+-- so far the assignment has been stored as tutor_id
+UPDATE grades SET assigned_tutor_id = tutor_id WHERE tutor_id <> 1;
+
+-- grades nobody has written a feedback for are not graded yet
+UPDATE grades g SET tutor_id = 1
+WHERE
+  g.tutor_id <> 1
+AND
+  g.feedback = ''
+AND
+  NOT EXISTS (
+    SELECT 1 FROM grade_histories h
+    WHERE h.grade_id = g.id
+    AND h.source IN ('manual', 'regrade', 'import', 'batch', 'reconcile')
+  );
+COMMIT;
@@ -0,0 +1,50 @@
+BEGIN;
+-- the history outlives its grade: deleting a grade (e.g. together with its
+-- submission) only drops the reference, the entries themselves stay
+DROP RULE grade_histories_immutable ON grade_histories;
+
+-- the context of an entry is stored alongside, as the grade might be gone
+ALTER TABLE grade_histories ADD COLUMN user_id INT NULL;
+ALTER TABLE grade_histories ADD COLUMN task_id INT NULL;
+ALTER TABLE grade_histories ADD COLUMN sheet_id INT NULL;
+ALTER TABLE grade_histories ADD COLUMN course_id INT NULL;
+
+UPDATE grade_histories h
+SET
+  user_id = s.user_id,
+  task_id = s.task_id,
+  sheet_id = ts.sheet_id,
+  course_id = sc.course_id
+FROM
+  grades g
+INNER JOIN submissions s ON s.id = g.submission_id
+INNER JOIN task_sheet ts ON ts.task_id = s.task_id
+INNER JOIN sheet_course sc ON sc.sheet_id = ts.sheet_id
+WHERE
+  h.grade_id = g.id;
+
+ALTER TABLE grade_histories ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE grade_histories ALTER COLUMN task_id SET NOT NULL;
+ALTER TABLE grade_histories ALTER COLUMN sheet_id SET NOT NULL;
+ALTER TABLE grade_histories ALTER COLUMN course_id SET NOT NULL;
+
+CREATE INDEX grade_histories_course_id_idx ON grade_histories (course_id);
+
+ALTER TABLE grade_histories ALTER COLUMN grade_id DROP NOT NULL;
+ALTER TABLE grade_histories DROP CONSTRAINT grade_histories_grade_id_fkey;
+ALTER TABLE grade_histories ADD FOREIGN KEY (grade_id) REFERENCES grades (id) ON DELETE SET NULL;
+
+-- entries are never modified, the only exception is detaching them from a
+-- deleted grade (a rule would also swallow the update of the foreign key)
+CREATE OR REPLACE FUNCTION grade_histories_immutable() RETURNS trigger AS $$
+BEGIN
+  IF NEW.grade_id IS NULL THEN
+    OLD.grade_id := NULL;
+  END IF;
+  RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER grade_histories_immutable BEFORE UPDATE ON grade_histories
+  FOR EACH ROW EXECUTE PROCEDURE grade_histories_immutable();
+COMMIT;
@@ -0,0 +1,31 @@
+BEGIN;
+-- every change of a grade is recorded here, entries are never modified
+CREATE TABLE grade_histories (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  grade_id INT not null,
+  -- user who caused the change (1 is the system itself, e.g. the worker)
+  actor_id INT not null,
+  -- manual, public_test, private_test, resubmission
+  source TEXT not null,
+
+  old_acquired_points INT not null,
+  new_acquired_points INT not null,
+  old_feedback TEXT not null,
+  new_feedback TEXT not null,
+  old_tutor_id INT not null,
+  new_tutor_id INT not null,
+  old_public_test_status INT not null,
+  new_public_test_status INT not null,
+  old_private_test_status INT not null,
+  new_private_test_status INT not null,
+
+  FOREIGN KEY (grade_id) REFERENCES grades (id) ON DELETE CASCADE
+);
+
+CREATE INDEX grade_histories_grade_id_idx ON grade_histories (grade_id);
+
+CREATE RULE grade_histories_immutable AS ON UPDATE TO grade_histories DO INSTEAD NOTHING;
+
+COMMIT;
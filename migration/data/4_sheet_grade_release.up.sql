@@ -0,0 +1,7 @@
+BEGIN;
+-- grades of a sheet are only visible to students once they are released
+-- NULL means there is no scheduled release (released as soon as graded)
+ALTER TABLE sheets ADD COLUMN grades_release_at TIMESTAMP NULL;
+-- manual switch to hold back grades until they are released explicitly
+ALTER TABLE sheets ADD COLUMN grades_withheld BOOLEAN not null DEFAULT false;
+COMMIT;
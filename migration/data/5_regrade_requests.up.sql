@@ -0,0 +1,31 @@
+BEGIN;
+-- number of days after the release of grades in which students can ask for a regrade
+-- 0 disables regrade requests
+ALTER TABLE courses ADD COLUMN regrade_window_days INT not null DEFAULT 7;
+
+CREATE TABLE regrade_requests (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  grade_id INT not null,
+  user_id INT not null,
+  justification TEXT not null,
+
+  -- 0 open, 1 accepted, 2 rejected
+  status INT not null DEFAULT 0,
+  response TEXT not null DEFAULT '',
+  responder_id INT null,
+  responded_at TIMESTAMP null,
+
+  old_acquired_points INT not null,
+  new_acquired_points INT null,
+
+  FOREIGN KEY (grade_id) REFERENCES grades (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  FOREIGN KEY (responder_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX regrade_requests_grade_id_idx ON regrade_requests (grade_id);
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+-- 0 group tutor, 1 round robin, 2 balanced, 3 by task
+ALTER TABLE sheets ADD COLUMN grading_strategy INT not null DEFAULT 0;
+-- tutor grading this task for everyone (used by strategy 3)
+ALTER TABLE tasks ADD COLUMN grader_id INT null;
+ALTER TABLE tasks ADD FOREIGN KEY (grader_id) REFERENCES users (id) ON DELETE SET NULL;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+-- hide the identity of students from tutors until the grades are released
+ALTER TABLE sheets ADD COLUMN blind_grading BOOLEAN not null DEFAULT false;
+COMMIT;
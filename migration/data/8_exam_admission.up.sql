@@ -0,0 +1,21 @@
+BEGIN;
+-- minimum share (in percent) of the points of a sheet required for the exam admission
+ALTER TABLE sheets ADD COLUMN min_percentage INT not null DEFAULT 0;
+
+-- manual decisions of admins which replace the computed exam admission
+CREATE TABLE admission_overrides (
+  id SERIAL not null primary key,
+  created_at TIMESTAMP not null DEFAULT current_timestamp,
+  updated_at TIMESTAMP not null DEFAULT current_timestamp,
+
+  course_id INT not null,
+  user_id INT not null,
+  admitted BOOLEAN not null,
+  comment TEXT not null DEFAULT '',
+
+  FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+  UNIQUE (course_id, user_id)
+);
+
+COMMIT;
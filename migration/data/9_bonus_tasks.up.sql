@@ -0,0 +1,6 @@
+BEGIN;
+-- bonus tasks only count towards the acquired points but not the achievable ones
+ALTER TABLE tasks ADD COLUMN is_bonus BOOLEAN not null DEFAULT false;
+-- tutors can give up to max_points + bonus_points
+ALTER TABLE tasks ADD COLUMN bonus_points INT not null DEFAULT 0;
+COMMIT;
@@ -122,7 +122,7 @@ def create_sheet_course(sheet_id, course_id, k):
       ('id', VAL.DEFAULT),
       ('sheet_id', sheet_id),
       ('course_id', course_id),
-      # ('ordering', k),
+      ('ordering', k),
   ])
 
   return data
@@ -151,7 +151,7 @@ def create_task_sheet(task_id, sheet_id, k):
       ('id', VAL.DEFAULT),
       ('task_id', task_id),
       ('sheet_id', sheet_id),
-      # ('ordering', k),
+      ('ordering', k),
   ])
 
   return data
@@ -286,11 +286,12 @@ def create_material(fake):
   return data
 
 
-def create_material_course(material_id, course_id):
+def create_material_course(material_id, course_id, k):
   data = OrderedDict([
       ('id', VAL.DEFAULT),
       ('material_id', material_id),
       ('course_id', course_id),
+      ('ordering', k),
   ])
 
   return data
@@ -440,7 +441,7 @@ if __name__ == "__main__":
   for i in range(10):
     course_id = 1
     materials.append(create_material(fake))
-    material_course.append(create_material_course(i + 1, 1))
+    material_course.append(create_material_course(i + 1, 1, i))
 
   with open('mock.sql', 'w') as f:
     f.write('BEGIN;')
@@ -3,14 +3,33 @@ BEGIN;
 DROP TABLE IF EXISTS material_course;
 DROP TABLE IF EXISTS user_exam;
 DROP TABLE IF EXISTS user_course;
+DROP TABLE IF EXISTS enrollment_requests;
+DROP TABLE IF EXISTS announcements;
+DROP TABLE IF EXISTS discussion_subscriptions;
+DROP TABLE IF EXISTS discussion_posts;
+DROP TABLE IF EXISTS discussion_threads;
 DROP TABLE IF EXISTS user_group;
 DROP TABLE IF EXISTS sheet_course;
 DROP TABLE IF EXISTS task_sheet;
 DROP TABLE IF EXISTS group_bids;
+DROP TABLE IF EXISTS group_waitlists;
+DROP TABLE IF EXISTS group_tutors;
+DROP TABLE IF EXISTS group_swap_wishes;
+DROP TABLE IF EXISTS group_swap_requests;
+DROP TABLE IF EXISTS attendances;
+DROP TABLE IF EXISTS group_session_cancellations;
+DROP TABLE IF EXISTS group_sessions;
 --  renamed to task_ratings
 -- DROP TABLE IF EXISTS task_feedbacks;
 DROP TABLE IF EXISTS task_ratings;
 
+DROP TABLE IF EXISTS regrade_requests;
+DROP TABLE IF EXISTS admission_overrides;
+DROP TABLE IF EXISTS feedback_snippet_usages;
+DROP TABLE IF EXISTS feedback_snippets;
+DROP TABLE IF EXISTS second_gradings;
+DROP TABLE IF EXISTS grade_histories;
+
 DROP TABLE IF EXISTS materials;
 DROP TABLE IF EXISTS groups;
 DROP TABLE IF EXISTS grades;
@@ -22,4 +41,6 @@ DROP TABLE IF EXISTS courses;
 DROP TABLE IF EXISTS users;
 DROP TABLE IF EXISTS schema_migrations;
 
+DROP FUNCTION IF EXISTS grade_histories_immutable();
+
 COMMIT;
\ No newline at end of file
@@ -0,0 +1,61 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// AdmissionOverride is a database entity for the decision of an admin about
+// the exam admission of a student, which replaces the computed admission.
+type AdmissionOverride struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	CourseID int64  `db:"course_id"`
+	UserID   int64  `db:"user_id"`
+	Admitted bool   `db:"admitted"`
+	Comment  string `db:"comment"`
+}
+
+// Admission summarizes whether a student may take part in the exams of a
+// course. It is computed from the points and not stored.
+type Admission struct {
+	UserID            int64
+	UserFirstName     string
+	UserLastName      string
+	UserEmail         string
+	UserStudentNumber string
+
+	AcquiredPoints int
+	MaxPoints      int
+	// sheets in which the student missed the minimum percentage
+	MissedSheetIDs []int64
+
+	Attended  int
+	Presented int
+
+	Override null.Bool
+	Comment  string
+	Admitted bool
+}
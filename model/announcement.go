@@ -0,0 +1,52 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Announcement is a database view for a persistent message to the members of
+// a course. The body is written in markdown. An announcement is only visible
+// to the given course roles and, if a group is set, to the members and tutors
+// of that group.
+type Announcement struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	CourseID     int64         `db:"course_id"`
+	AuthorID     int64         `db:"author_id"`
+	Title        string        `db:"title"`
+	Body         string        `db:"body"`
+	Pinned       bool          `db:"pinned"`
+	PublishedAt  time.Time     `db:"published_at"`
+	Roles        pq.Int64Array `db:"roles"`
+	GroupID      null.Int      `db:"group_id"`
+	SendEmail    bool          `db:"send_email"`
+	EmailsSentAt null.Time     `db:"emails_sent_at,readonly"`
+}
+
+// Published tests whether the announcement is visible in the feed.
+func (m *Announcement) Published() bool {
+	return !m.PublishedAt.After(time.Now())
+}
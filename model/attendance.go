@@ -0,0 +1,54 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Attendance is a database entity for the attendance of a student at a single
+// date of a weekly group session.
+type Attendance struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	SessionID   int64     `db:"session_id"`
+	SessionDate time.Time `db:"session_date"`
+	UserID      int64     `db:"user_id"`
+	Present     bool      `db:"present"`
+	Presented   bool      `db:"presented"`
+	RecordedBy  null.Int  `db:"recorded_by"`
+
+	GroupID           int64  `db:"group_id,readonly"`
+	UserFirstName     string `db:"user_first_name,readonly"`
+	UserLastName      string `db:"user_last_name,readonly"`
+	UserEmail         string `db:"user_email,readonly"`
+	UserStudentNumber string `db:"user_student_number,readonly"`
+}
+
+// AttendanceCount sums up the attendance of a student in a course.
+type AttendanceCount struct {
+	UserID    int64 `db:"user_id"`
+	Attended  int   `db:"attended"`
+	Presented int   `db:"presented"`
+}
@@ -21,6 +21,9 @@ package model
 
 import (
 	"time"
+
+	"github.com/lib/pq"
+	null "gopkg.in/guregu/null.v3"
 )
 
 // Course holds specific application settings linked to an entity, which
@@ -35,4 +38,24 @@ type Course struct {
 	BeginsAt           time.Time `db:"begins_at"`
 	EndsAt             time.Time `db:"ends_at"`
 	RequiredPercentage int       `db:"required_percentage"`
+	RegradeWindowDays  int       `db:"regrade_window_days"`
+
+	GroupSelfEnrollment  bool `db:"group_self_enrollment"`
+	WaitlistNotification bool `db:"waitlist_notification"`
+
+	RequiredAttendances   int `db:"required_attendances"`
+	RequiredPresentations int `db:"required_presentations"`
+
+	GroupSwapApproval bool `db:"group_swap_approval"`
+
+	// EnrollmentKey must be given by students to enroll (if not empty).
+	EnrollmentKey      string    `db:"enrollment_key"`
+	EnrollmentBeginsAt null.Time `db:"enrollment_begins_at"`
+	EnrollmentEndsAt   null.Time `db:"enrollment_ends_at"`
+	// MaxStudents is the largest number of students, 0 means unlimited.
+	MaxStudents        int  `db:"max_students"`
+	EnrollmentApproval bool `db:"enrollment_approval"`
+	// EnrollmentAllowlist contains email domains (e.g. "@uni-tuebingen.de") or
+	// student numbers. If not empty only matching users can enroll.
+	EnrollmentAllowlist pq.StringArray `db:"enrollment_allowlist"`
 }
@@ -0,0 +1,63 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// DiscussionThread is a database view for a question about a course, a sheet
+// or a task. Private threads are only visible to the author and the staff of
+// the course. Anonymous threads hide the author from other students.
+type DiscussionThread struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	CourseID  int64    `db:"course_id"`
+	SheetID   null.Int `db:"sheet_id"`
+	TaskID    null.Int `db:"task_id"`
+	AuthorID  int64    `db:"author_id"`
+	Title     string   `db:"title"`
+	Body      string   `db:"body"`
+	Private   bool     `db:"private"`
+	Anonymous bool     `db:"anonymous"`
+
+	AuthorFirstName string `db:"author_first_name,readonly"`
+	AuthorLastName  string `db:"author_last_name,readonly"`
+	PostCount       int    `db:"post_count,readonly"`
+}
+
+// DiscussionPost is a database view for an answer in a discussion thread.
+type DiscussionPost struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	ThreadID  int64  `db:"thread_id"`
+	AuthorID  int64  `db:"author_id"`
+	Body      string `db:"body"`
+	Anonymous bool   `db:"anonymous"`
+	Endorsed  bool   `db:"endorsed"`
+
+	AuthorFirstName string `db:"author_first_name,readonly"`
+	AuthorLastName  string `db:"author_last_name,readonly"`
+}
@@ -19,7 +19,11 @@
 
 package model
 
-import null "gopkg.in/guregu/null.v3"
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
 
 // Enrollment represents a an enrollment-type of a given user
 type Enrollment struct {
@@ -44,3 +48,18 @@ type UserCourse struct {
 	Subject       string      `db:"subject"`
 	Language      string      `db:"language"`
 }
+
+// EnrollmentRequest is a database view of a user waiting for the approval of
+// the enrollment into a course.
+type EnrollmentRequest struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+
+	CourseID int64 `db:"course_id"`
+	UserID   int64 `db:"user_id"`
+
+	UserFirstName     string `db:"user_first_name,readonly"`
+	UserLastName      string `db:"user_last_name,readonly"`
+	UserEmail         string `db:"user_email,readonly"`
+	UserStudentNumber string `db:"user_student_number,readonly"`
+}
@@ -0,0 +1,43 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// FeedbackSnippet is a database entity representing a reusable comment tutors
+// can insert into the feedback of a grade. Snippets without a task can be used
+// for every task of the course.
+type FeedbackSnippet struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	CourseID    int64    `db:"course_id"`
+	TaskID      null.Int `db:"task_id"`
+	AuthorID    null.Int `db:"author_id"`
+	Text        string   `db:"text"`
+	PointsDelta int      `db:"points_delta"`
+
+	UsageCount int `db:"usage_count,readonly"`
+}
@@ -21,6 +21,8 @@ package model
 
 import (
 	"time"
+
+	null "gopkg.in/guregu/null.v3"
 )
 
 // -- 0: pending, 1: running, 2: finished
@@ -47,6 +49,21 @@ type Grade struct {
 	UserFirstName         string `db:"user_first_name,readonly"`
 	UserLastName          string `db:"user_last_name,readonly"`
 	UserEmail             string `db:"user_email,readonly"`
+	TaskID                int64  `db:"task_id,readonly"`
+	SheetID               int64  `db:"sheet_id,readonly"`
+
+	// AssignedTutorID is the tutor who is supposed to grade the submission. In
+	// contrast, TutorID is the tutor who actually graded it (1 means nobody).
+	AssignedTutorID null.Int `db:"assigned_tutor_id"`
+}
+
+// ResponsibleTutorID returns the tutor who is in charge of the grade, which is
+// the assigned tutor or otherwise the tutor who graded it.
+func (p *Grade) ResponsibleTutorID() int64 {
+	if p.AssignedTutorID.Valid {
+		return p.AssignedTutorID.Int64
+	}
+	return p.TutorID
 }
 
 // MissingGrade is a database view containing all grades which are finished
@@ -54,8 +71,34 @@ type Grade struct {
 type MissingGrade struct {
 	Grade
 	CourseID int64 `db:"course_id"`
-	SheetID  int64 `db:"sheet_id"`
-	TaskID   int64 `db:"task_id"`
+}
+
+// GradeExportStudent is a database view of a student and the group as a
+// single row in the export of all grades in a course.
+type GradeExportStudent struct {
+	UserID            int64  `db:"user_id"`
+	UserFirstName     string `db:"user_first_name"`
+	UserLastName      string `db:"user_last_name"`
+	UserEmail         string `db:"user_email"`
+	UserStudentNumber string `db:"user_student_number"`
+	GroupID           int64  `db:"group_id"`
+	GroupDescription  string `db:"group_description"`
+}
+
+// GradeExportPoints is a database view of the points of a student in a task.
+type GradeExportPoints struct {
+	UserID         int64 `db:"user_id"`
+	TaskID         int64 `db:"task_id"`
+	AcquiredPoints int   `db:"acquired_points"`
+}
+
+// GradingProgress is a database view summarizing the work of a tutor.
+type GradingProgress struct {
+	TutorID        int64  `db:"tutor_id"`
+	TutorFirstName string `db:"tutor_first_name"`
+	TutorLastName  string `db:"tutor_last_name"`
+	Total          int    `db:"total"`
+	Done           int    `db:"done"`
 }
 
 // OverviewGrade is a database view containing informations for grades from
@@ -71,3 +114,90 @@ type OverviewGrade struct {
 	Name    string `db:"name"`
 	Points  int    `db:"points"`
 }
+
+// GradeHistory is an immutable record of a single change of a grade.
+type GradeHistory struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+
+	// GradeID is null once the grade has been deleted, the entry itself stays.
+	GradeID null.Int `db:"grade_id"`
+
+	ActorID              int64  `db:"actor_id"`
+	Source               string `db:"source"`
+	OldAcquiredPoints    int    `db:"old_acquired_points"`
+	NewAcquiredPoints    int    `db:"new_acquired_points"`
+	OldFeedback          string `db:"old_feedback"`
+	NewFeedback          string `db:"new_feedback"`
+	OldTutorID           int64  `db:"old_tutor_id"`
+	NewTutorID           int64  `db:"new_tutor_id"`
+	OldPublicTestStatus  int    `db:"old_public_test_status"`
+	NewPublicTestStatus  int    `db:"new_public_test_status"`
+	OldPrivateTestStatus int    `db:"old_private_test_status"`
+	NewPrivateTestStatus int    `db:"new_private_test_status"`
+
+	OldAssignedTutorID null.Int `db:"old_assigned_tutor_id"`
+	NewAssignedTutorID null.Int `db:"new_assigned_tutor_id"`
+
+	// context of the grade at the time of the change
+	UserID   int64 `db:"user_id"`
+	TaskID   int64 `db:"task_id"`
+	SheetID  int64 `db:"sheet_id"`
+	CourseID int64 `db:"course_id"`
+
+	ActorFirstName string `db:"actor_first_name,readonly"`
+	ActorLastName  string `db:"actor_last_name,readonly"`
+}
+
+// NewGradeHistory describes the transition of a grade from "before" to "after".
+func NewGradeHistory(before *Grade, after *Grade, actorID int64, source string) *GradeHistory {
+	return &GradeHistory{
+		GradeID:              null.IntFrom(before.ID),
+		ActorID:              actorID,
+		Source:               source,
+		OldAcquiredPoints:    before.AcquiredPoints,
+		NewAcquiredPoints:    after.AcquiredPoints,
+		OldFeedback:          before.Feedback,
+		NewFeedback:          after.Feedback,
+		OldTutorID:           before.TutorID,
+		NewTutorID:           after.TutorID,
+		OldPublicTestStatus:  before.PublicTestStatus,
+		NewPublicTestStatus:  after.PublicTestStatus,
+		OldPrivateTestStatus: before.PrivateTestStatus,
+		NewPrivateTestStatus: after.PrivateTestStatus,
+
+		OldAssignedTutorID: before.AssignedTutorID,
+		NewAssignedTutorID: after.AssignedTutorID,
+	}
+}
+
+// SecondGrading is the independent second grading of a grade for tasks which
+// require two graders. The grade itself holds the first grading and, after a
+// reconciliation, the agreed points.
+type SecondGrading struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	GradeID        int64    `db:"grade_id"`
+	TutorID        int64    `db:"tutor_id"`
+	AcquiredPoints int      `db:"acquired_points"`
+	Feedback       string   `db:"feedback"`
+	Reconciled     bool     `db:"reconciled"`
+	ReconciledBy   null.Int `db:"reconciled_by"`
+
+	FirstTutorID        int64 `db:"first_tutor_id,readonly"`
+	FirstAcquiredPoints int   `db:"first_acquired_points,readonly"`
+	TaskID              int64 `db:"task_id,readonly"`
+	ReconcileThreshold  int   `db:"reconcile_threshold,readonly"`
+}
+
+// NeedsReconciliation tests if both gradings differ by more than the threshold
+// of the task and nobody has decided on the final points yet.
+func (p *SecondGrading) NeedsReconciliation() bool {
+	diff := p.AcquiredPoints - p.FirstAcquiredPoints
+	if diff < 0 {
+		diff = -diff
+	}
+	return !p.Reconciled && diff > p.ReconcileThreshold
+}
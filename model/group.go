@@ -31,9 +31,12 @@ type Group struct {
 	CreatedAt time.Time `db:"created_at,omitempty"`
 	UpdatedAt time.Time `db:"updated_at,omitempty"`
 
+	// TutorID is the primary tutor, further tutors are stored in group_tutors.
 	TutorID     int64  `db:"tutor_id"`
 	CourseID    int64  `db:"course_id"`
 	Description string `db:"description"`
+	// Capacity is the largest number of students in this group, 0 means unlimited.
+	Capacity int `db:"capacity"`
 }
 
 // GroupEnrollment is a database view for an enrollment of a student into a group.
@@ -45,6 +48,27 @@ type GroupEnrollment struct {
 	GroupID int64 `db:"group_id"`
 }
 
+// GroupWaitlistEntry is a database view for a student waiting for a free seat
+// in a group.
+type GroupWaitlistEntry struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+
+	UserID  int64 `db:"user_id"`
+	GroupID int64 `db:"group_id"`
+}
+
+// GroupCoTutor is a database view of an additional tutor of a group.
+type GroupCoTutor struct {
+	GroupID   int64       `db:"group_id"`
+	ID        int64       `db:"id"`
+	FirstName string      `db:"first_name"`
+	LastName  string      `db:"last_name"`
+	AvatarURL null.String `db:"avatar_url"`
+	Email     string      `db:"email"`
+	Language  string      `db:"language"`
+}
+
 // GroupWithTutor is a database view of a group including tutor information
 type GroupWithTutor struct {
 	Group
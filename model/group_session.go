@@ -0,0 +1,75 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// GroupSession is a database view for a weekly meeting of a group. Start and
+// end are the local time of day formatted as HH:MM.
+type GroupSession struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	GroupID   int64       `db:"group_id"`
+	Weekday   int         `db:"weekday"`
+	StartTime string      `db:"start_time"`
+	EndTime   string      `db:"end_time"`
+	Room      string      `db:"room"`
+	OnlineURL null.String `db:"online_url"`
+	FirstDate time.Time   `db:"first_date"`
+	LastDate  time.Time   `db:"last_date"`
+}
+
+// Overlaps tests whether two weekly sessions take place at the same time on
+// at least one day.
+func (m *GroupSession) Overlaps(other *GroupSession) bool {
+	if m.Weekday != other.Weekday {
+		return false
+	}
+	if m.LastDate.Before(other.FirstDate) || other.LastDate.Before(m.FirstDate) {
+		return false
+	}
+	// HH:MM strings compare like times of day
+	return m.StartTime < other.EndTime && other.StartTime < m.EndTime
+}
+
+// Dates returns all days the session takes place ignoring cancellations.
+func (m *GroupSession) Dates() []time.Time {
+	dates := []time.Time{}
+
+	day := time.Date(m.FirstDate.Year(), m.FirstDate.Month(), m.FirstDate.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(m.LastDate.Year(), m.LastDate.Month(), m.LastDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	for int(day.Weekday()) != m.Weekday {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	for !day.After(last) {
+		dates = append(dates, day)
+		day = day.AddDate(0, 0, 7)
+	}
+
+	return dates
+}
@@ -0,0 +1,43 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// GroupSwapRequest is a database entity for the wish of a student to leave the
+// own group for one of the wished groups of the same course.
+type GroupSwapRequest struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	CourseID      int64    `db:"course_id"`
+	UserID        int64    `db:"user_id"`
+	FromGroupID   int64    `db:"from_group_id"`
+	Status        int      `db:"status"`
+	MatchedWithID null.Int `db:"matched_with_id"`
+
+	// stored in group_swap_wishes
+	WantedGroupIDs []int64 `db:"-"`
+}
@@ -0,0 +1,49 @@
+// InfoMark - a platform for managing courses with
+//            distributing exercise sheets and testing exercise submissions
+// Copyright (C) 2019 ComputerGraphics Tuebingen
+//               2020-present InfoMark.org
+// Authors: Patrick Wieschollek
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+package model
+
+import (
+	"time"
+
+	null "gopkg.in/guregu/null.v3"
+)
+
+// Regrade is a database entity representing the request of a student to
+// reconsider a grade together with the decision of a tutor.
+type Regrade struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at,omitempty"`
+	UpdatedAt time.Time `db:"updated_at,omitempty"`
+
+	GradeID           int64     `db:"grade_id"`
+	UserID            int64     `db:"user_id"`
+	Justification     string    `db:"justification"`
+	Status            int       `db:"status"`
+	Response          string    `db:"response"`
+	ResponderID       null.Int  `db:"responder_id"`
+	RespondedAt       null.Time `db:"responded_at"`
+	OldAcquiredPoints int       `db:"old_acquired_points"`
+	NewAcquiredPoints null.Int  `db:"new_acquired_points"`
+
+	UserFirstName string `db:"user_first_name,readonly"`
+	UserLastName  string `db:"user_last_name,readonly"`
+	UserEmail     string `db:"user_email,readonly"`
+	TaskID        int64  `db:"task_id,readonly"`
+	SheetID       int64  `db:"sheet_id,readonly"`
+}
@@ -21,6 +21,8 @@ package model
 
 import (
 	"time"
+
+	null "gopkg.in/guregu/null.v3"
 )
 
 // Sheet is a database entity representing an entire exercise sheet consisting
@@ -33,6 +35,15 @@ type Sheet struct {
 	Name      string    `db:"name"`
 	PublishAt time.Time `db:"publish_at"`
 	DueAt     time.Time `db:"due_at"`
+
+	GradesReleaseAt null.Time `db:"grades_release_at"`
+	GradesWithheld  bool      `db:"grades_withheld"`
+	GradingStrategy int       `db:"grading_strategy"`
+	BlindGrading    bool      `db:"blind_grading"`
+	MinPercentage   int       `db:"min_percentage"`
+
+	GradesReleaseNotify     bool      `db:"grades_release_notify"`
+	GradesReleaseNotifiedAt null.Time `db:"grades_release_notified_at,readonly"`
 }
 
 // SheetPoints contains the performance of a specific student
@@ -36,6 +36,17 @@ type Task struct {
 	MaxPoints          int         `db:"max_points"`
 	PublicDockerImage  null.String `db:"public_docker_image"`
 	PrivateDockerImage null.String `db:"private_docker_image"`
+	GraderID           null.Int    `db:"grader_id"`
+	IsBonus            bool        `db:"is_bonus"`
+	BonusPoints        int         `db:"bonus_points"`
+	DoubleGrading      bool        `db:"double_grading"`
+	ReconcileThreshold int         `db:"reconcile_threshold"`
+}
+
+// MaxAcquirablePoints is the largest number of points a student can get in
+// this task including the bonus allowance.
+func (t *Task) MaxAcquirablePoints() int {
+	return t.MaxPoints + t.BonusPoints
 }
 
 // TaskRating contains the feedback of students to a task.
@@ -70,10 +81,11 @@ func (body *TaskRating) Validate() error {
 
 // TaskPoints is a performance summary of a student for a given task
 type TaskPoints struct {
-	AchievablePoints int `db:"achievable_points"`
-	AquiredPoints    int `db:"acquired_points"`
-	MaxPoints        int `db:"max_points"`
-	TaskID           int `db:"task_id"`
+	AchievablePoints int  `db:"achievable_points"`
+	AquiredPoints    int  `db:"acquired_points"`
+	MaxPoints        int  `db:"max_points"`
+	TaskID           int  `db:"task_id"`
+	IsBonus          bool `db:"is_bonus"`
 }
 
 // Validate validates TaskPoints
@@ -45,6 +45,9 @@ type User struct {
 	ResetPasswordToken null.String `db:"reset_password_token"`
 	ConfirmEmailToken  null.String `db:"confirm_email_token"`
 	Root               bool        `db:"root"`
+	CalendarToken      null.String `db:"calendar_token"`
+
+	AnnouncementEmailsMuted bool `db:"announcement_emails_muted"`
 }
 
 // FullName is a wrapper for returning the fullname of a user
@@ -38,6 +38,13 @@ const (
 	CtxKeySheet        key = iota
 	CtxKeyGrade        key = iota
 	CtxKeyExam         key = iota
+	CtxKeyRegrade      key = iota
+	CtxKeySnippet      key = iota
+	CtxKeyGroupSession key = iota
+	CtxKeyGroupSwap    key = iota
+	CtxKeyAnnouncement key = iota
+	CtxKeyThread       key = iota
+	CtxKeyPost         key = iota
 	// ...
 )
 
@@ -58,6 +65,43 @@ const (
 
 )
 
+// these are the origins of a change recorded in the grade history
+const (
+	GradeChangeManual       = "manual"       // tutor or admin edited the grade
+	GradeChangePublicTest   = "public_test"  // worker reported the public test result
+	GradeChangePrivateTest  = "private_test" // worker reported the private test result
+	GradeChangeResubmission = "resubmission" // new upload triggered a re-test
+	GradeChangeRegrade      = "regrade"      // tutor decided on a regrade request
+	GradeChangeReassignment = "reassignment" // admin assigned another tutor
+	GradeChangeImport       = "import"       // bulk-update from a csv file
+	GradeChangeBatch        = "batch"        // many grades edited in one request
+	GradeChangeReconcile    = "reconcile"    // admin agreed on points of two gradings
+)
+
+// these are the strategies to assign new submissions of a sheet to tutors
+const (
+	GradingByGroupTutor = 0 // the tutor of the group of the student
+	GradingRoundRobin   = 1 // tutors of the course take turns
+	GradingBalanced     = 2 // the tutor with the fewest grades in the sheet
+	GradingByTask       = 3 // a fixed tutor grades a task for everyone
+)
+
+// these are the states of a regrade request
+const (
+	RegradeOpen     = 0 // waiting for a decision of a tutor
+	RegradeAccepted = 1 // the grade was reconsidered
+	RegradeRejected = 2 // the grade stays as it is
+)
+
+// these are the states of a group swap request
+const (
+	GroupSwapOpen      = 0 // waiting for a complementary request
+	GroupSwapMatched   = 1 // waiting for the approval of an admin
+	GroupSwapCompleted = 2 // both students changed their groups
+	GroupSwapCancelled = 3 // withdrawn by the student
+	GroupSwapRejected  = 4 // declined by an admin
+)
+
 func (t TestingResult) AsInt64() int64 {
 	if t == TestingResultSuccess {
 		return 0